@@ -1,101 +1,58 @@
 package main
 
 import (
-	"bytes"
-	"encoding/binary"
-	"encoding/json"
+	"crypto/tls"
+	"flag"
 	"fmt"
-	"net"
-	"time"
+	"os"
 
-	"github.com/google/uuid"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/internal/transport"
 )
 
-// Envelope 与服务端一致（简化必要字段）
-  type Envelope struct {
-  	Version    string `json:"version"`
-  	Type       string `json:"type"`
-  	Encoding   string `json:"encoding,omitempty"` // 建议填 "json"
-  	Mid        string `json:"mid,omitempty"`
-  	Correlation string `json:"correlation_id,omitempty"`
-  	From       string `json:"from,omitempty"`
-  	To         string `json:"to,omitempty"`
-  	Ts         int64  `json:"ts"`
-  	Data       []byte `json:"data,omitempty"` // Go 的 json 会自动 base64
-  }
-
-  func writeFrame(conn net.Conn, payload []byte) error {
-  	var header [4]byte
-  	binary.BigEndian.PutUint32(header[:], uint32(len(payload)))
-  	if _, err := conn.Write(header[:]); err != nil {
-  		return err
-  	}
-  	_, err := conn.Write(payload)
-  	return err
-  }
-
-  func readFrame(conn net.Conn) ([]byte, error) {
-  	var header [4]byte
-  	if _, err := conn.Read(header[:]); err != nil {
-  		return nil, err
-  	}
-  	n := int(binary.BigEndian.Uint32(header[:]))
-  	buf := make([]byte, n)
-  	_, err := ioReadFull(conn, buf)
-  	return buf, err
-  }
-
-  // 兼容老 Go 版本的 ReadFull（避免额外 import）
-  func ioReadFull(conn net.Conn, buf []byte) (int, error) {
-  	read := 0
-  	for read < len(buf) {
-  		n, err := conn.Read(buf[read:])
-  		if err != nil {
-  			return read, err
-  		}
-  		read += n
-  	}
-  	return read, nil
-  }
-
-  func main() {
-  	addr := "127.0.0.1:8080"
-  	conn, err := net.Dial("tcp", addr)
-  	if err != nil {
-  		panic(err)
-  	}
-  	defer conn.Close()
-  	fmt.Println("connected:", addr)
-
-  	// 读取欢迎 Envelope
-  	if buf, err := readFrame(conn); err == nil {
-  		var env Envelope
-  		if err := json.Unmarshal(buf, &env); err == nil {
-  			fmt.Printf("welcome: type=%s ts=%d data=%q\n", env.Type, env.Ts,
-  string(env.Data))
-  		} else {
-  			fmt.Println("decode welcome failed:", err)
-  		}
-  	} else {
-  		fmt.Println("read welcome failed:", err)
-  	}
-
-  	// 发送一条 text 消息
-  	env := Envelope{
-  		Version:  "1.0",
-  		Type:     "text",
-  		Encoding: "json",
-  		Mid:      uuid.NewString(),
-  		From:     "go-client",
-  		Ts:       time.Now().UnixMilli(),
-  		Data:     []byte("Hello from Go client"),
-  	}
-  	var payload bytes.Buffer
-  	if err := json.NewEncoder(&payload).Encode(&env); err != nil {
-  		panic(err)
-  	}
-  	if err := writeFrame(conn, payload.Bytes()); err != nil {
-  		panic(err)
-  	}
-  	fmt.Println("sent one text envelope")
-  }
\ No newline at end of file
+func main() {
+	var (
+		addr     = flag.String("addr", "127.0.0.1:8080", "server address: host:port, tls://host:port, unix:///path/to.sock, ws://host:port/ws or wss://host:port/ws")
+		caFile   = flag.String("ca", "", "CA certificate for verifying the server (tls:// and wss://)")
+		certFile = flag.String("cert", "", "client certificate for mutual TLS")
+		keyFile  = flag.String("key", "", "client private key for mutual TLS")
+		insecure = flag.Bool("insecure", false, "skip server certificate verification")
+	)
+	flag.Parse()
+
+	tlsCfg, err := transport.ClientTLSConfig(*caFile, *certFile, *keyFile, *insecure)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tls config:", err)
+		os.Exit(2)
+	}
+	// 与服务端默认一致：JSON 编解码器
+	conn, err := transport.DialClient(*addr, &protocol.JSONCodec{}, tlsCfg)
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+	fmt.Println("connected:", *addr)
+	if state := conn.TLSState(); state != nil {
+		fmt.Printf("tls: version=%#x cipher=%s\n", state.Version, tls.CipherSuiteName(state.CipherSuite))
+	}
+	factory := protocol.NewMessageFactory()
+
+	// 读取欢迎 Envelope
+	if env, err := conn.Read(1 << 20); err == nil {
+		if p, err := protocol.DecodePayload[protocol.TextPayload](env); err == nil {
+			fmt.Printf("welcome: type=%s ts=%d text=%q\n", env.Type, env.Ts, p.Text)
+		} else {
+			fmt.Printf("welcome: type=%s ts=%d data=%q\n", env.Type, env.Ts, string(env.Data))
+		}
+	} else {
+		fmt.Println("read welcome failed:", err)
+	}
+
+	// 发送一条 text 消息
+	env := factory.CreateTextMessage("Hello from Go client")
+	env.From = "go-client"
+	if err := conn.Write(env); err != nil {
+		panic(err)
+	}
+	fmt.Println("sent one text envelope")
+}
@@ -1,11 +1,9 @@
 package main
 
 import (
-	"bytes"
 	"encoding/base64"
 	"flag"
 	"fmt"
-	"net"
 	"os"
 	"unicode/utf8"
 
@@ -19,6 +17,8 @@ func parseCodec(s string) (int, error) {
 		return protocol.CodecJson, nil
 	case "protobuf", "pb", "PB", "1":
 		return protocol.CodecProtobuf, nil
+	case "cbor", "CBOR", "3":
+		return protocol.CodecCbor, nil
 	default:
 		return -1, fmt.Errorf("unknown codec: %s", s)
 	}
@@ -26,9 +26,13 @@ func parseCodec(s string) (int, error) {
 
 func main() {
 	var (
-		addr   = flag.String("addr", "localhost:8080", "server address")
-		codecS = flag.String("codec", "json", "codec: json|protobuf")
-		max    = flag.Int("max", 1<<20, "max frame size in bytes")
+		addr     = flag.String("addr", "localhost:8080", "server address: host:port, tls://host:port, unix:///path/to.sock, ws://host:port/ws or wss://host:port/ws")
+		codecS   = flag.String("codec", "json", "codec: json|protobuf|cbor")
+		max      = flag.Int("max", 1<<20, "max frame size in bytes")
+		caFile   = flag.String("ca", "", "CA certificate for verifying the server (tls:// and wss://)")
+		certFile = flag.String("cert", "", "client certificate for mutual TLS")
+		keyFile  = flag.String("key", "", "client private key for mutual TLS")
+		insecure = flag.Bool("insecure", false, "skip server certificate verification")
 	)
 	flag.Parse()
 
@@ -43,24 +47,22 @@ func main() {
 		os.Exit(2)
 	}
 
-	conn, err := net.Dial("tcp", *addr)
+	tlsCfg, err := transport.ClientTLSConfig(*caFile, *certFile, *keyFile, *insecure)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tls config error: %v\n", err)
+		os.Exit(2)
+	}
+	conn, err := transport.DialClient(*addr, mc, tlsCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "dial error: %v\n", err)
 		os.Exit(1)
 	}
 	defer conn.Close()
 
-	fc := transport.NewFrameCodec()
 	for {
-		data, err := fc.ReadFrame(conn)
+		env, err := conn.Read(*max)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "read frame error: %v\n", err)
-			os.Exit(1)
-		}
-
-		var env protocol.Envelope
-		if err := mc.Decode(bytes.NewReader(data), &env, *max); err != nil {
-			fmt.Fprintf(os.Stderr, "decode envelope error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "read envelope error: %v\n", err)
 			os.Exit(1)
 		}
 
@@ -69,6 +71,8 @@ func main() {
 		fmt.Printf("  ts:   %d\n", env.Ts)
 		if len(env.Data) == 0 {
 			fmt.Printf("  data: <empty>\n")
+		} else if p, err := protocol.DecodePayloadAny(env); err == nil {
+			fmt.Printf("  data(%s): %+v\n", env.Encoding, p)
 		} else if utf8.Valid(env.Data) {
 			fmt.Printf("  data(text): %s\n", string(env.Data))
 		} else {
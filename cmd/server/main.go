@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"github.com/hongjun500/chat-go/internal/protocol"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hongjun500/chat-go/internal/bus/redisstream"
 	"github.com/hongjun500/chat-go/internal/chat"
 	"github.com/hongjun500/chat-go/internal/command"
 	"github.com/hongjun500/chat-go/internal/config"
+	"github.com/hongjun500/chat-go/internal/i18n"
 	"github.com/hongjun500/chat-go/internal/observe"
+	"github.com/hongjun500/chat-go/internal/server"
 	"github.com/hongjun500/chat-go/internal/subscriber"
 	"github.com/hongjun500/chat-go/internal/transport"
 	"github.com/hongjun500/chat-go/pkg/logger"
@@ -18,6 +24,9 @@ import (
 func main() {
 	cfg := config.Load()
 	logger.SetLevel(cfg.LogLevel)
+	if l, ok := i18n.Match(cfg.Locale); !ok || !i18n.SetDefault(l) {
+		logger.L().Sugar().Warnw("unsupported_locale", "locale", cfg.Locale, "default", i18n.Default())
+	}
 	hub := chat.NewHub()
 	// 初始化命令注册表（解环：在 main 中创建并传递）
 	cmdReg := command.NewRegistry()
@@ -27,67 +36,179 @@ func main() {
 	// 注册标准订阅者合集
 	subscriber.RegisterAll(hub)
 
-	// 并发启动 TCP/WS/HTTP（静态页 ws.html 用于 WebSocket 测试）
-	// 新抽象：使用协议无关的 Gateway + 统一的Transport接口
-	go func() {
-		tcpSrv := transport.NewTCPServer(cfg.TCPAddr)
-		gw := transport.NewSimpleGateway()
-		logger.L().Sugar().Infow("starting_tcp_server", "addr", cfg.TCPAddr, "codec", cfg.TCPCodec)
-		_ = tcpSrv.Start(context.Background(), cfg.TCPAddr, gw, transport.Options{
-			OutBuffer:    cfg.OutBuffer,
-			ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
-			WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
-			MaxFrameSize: cfg.MaxFrameSize,
-			// 配置协议管理器
-			TCPProtocolManager: protocol.NewProtocolManager(cfg.TCPCodec),
-			HeartbeatInterval:  time.Second * 30,
-			HeartbeatTimeout:   time.Minute * 1,
+	// 可选：TLS（TCP 与 WebSocket 共用，证书文件变化时热加载）
+	var tlsCfg *tls.Config
+	if tlsOpts := (transport.TLSOptions{
+		CertFile:       cfg.TLSCert,
+		KeyFile:        cfg.TLSKey,
+		ClientCAFile:   cfg.TLSClientCA,
+		MinVersion:     cfg.TLSMin,
+		CipherPolicy:   cfg.TLSCiphers,
+		ReloadInterval: time.Duration(cfg.TLSReload) * time.Second,
+	}); tlsOpts.Enabled() {
+		var err error
+		if tlsCfg, err = transport.NewTLSConfig(context.Background(), tlsOpts); err != nil {
+			logger.L().Sugar().Errorw("tls_config_failed", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	// 连接准入在全部传输间共享，上限按合计计算
+	admission := transport.NewAdmission(cfg.MaxConns, cfg.MaxPerIP, cfg.AcceptRate, cfg.AcceptBurst)
+
+	// 统一生命周期：全部传输与后台任务由 server 管理，SIGINT/SIGTERM 时排空会话后退出
+	srv := server.New(time.Duration(cfg.Drain) * time.Second)
+	// 新抽象：使用协议无关的 Gateway + 统一的Transport接口，每个传输一个网关
+	srv.AddTransport(transport.NewTCPServer(cfg.TCPAddr), cfg.TCPAddr, transport.NewChatGateway(hub, cmdReg), transport.Options{
+		OutBuffer:    cfg.OutBuffer,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
+		MaxFrameSize: cfg.MaxFrameSize,
+		// 配置协议管理器
+		TCPProtocolManager: protocol.NewProtocolManager(cfg.TCPCodec),
+		CodecNegotiation:   cfg.TCPNegotiate,
+		MaxLineLength:      cfg.LineMax,
+		HeartbeatInterval:  time.Duration(cfg.PingEvery) * time.Second,
+		HeartbeatTimeout:   time.Duration(cfg.PingTimeout) * time.Second,
+		TLS:                tlsCfg,
+		Admission:          admission,
+	})
+
+	wsSrv := transport.NewWebSocketServer("/ws")
+	if cfg.WSOrigins != "" {
+		wsSrv.AllowedOrigins = strings.Split(cfg.WSOrigins, ",")
+	}
+	wsSrv.Tokens = transport.StaticTokens(cfg.WSTokens)
+	// 令牌绑定的身份在登录前即保留昵称，未认证的会话不能冒用
+	hub.ReserveNames(transport.TokenIdentities(cfg.WSTokens))
+	wsSrv.MaxConnsPerIP = cfg.WSMaxPerIP
+	srv.AddTransport(wsSrv, cfg.WSAddr, transport.NewChatGateway(hub, cmdReg), transport.Options{
+		OutBuffer:    cfg.OutBuffer,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
+		// 配置协议管理器
+		WSProtocolManager:  protocol.NewProtocolManager(cfg.WSCodec),
+		WSReadBufferSize:   cfg.WSReadBuf,
+		WSWriteBufferSize:  cfg.WSWriteBuf,
+		WSCompression:      cfg.WSCompress,
+		WSCompressionLevel: cfg.WSCompressLv,
+		MaxFrameSize:       cfg.MaxFrameSize,
+		HeartbeatInterval:  time.Duration(cfg.PingEvery) * time.Second,
+		HeartbeatTimeout:   time.Duration(cfg.PingTimeout) * time.Second,
+		TLS:                tlsCfg,
+		Admission:          admission,
+	})
+
+	// SSE + HTTP POST：受限网络的回退传输，配置地址后启用
+	if cfg.SSEAddr != "" {
+		srv.AddTransport(transport.NewSSEServer("/sse"), cfg.SSEAddr, transport.NewChatGateway(hub, cmdReg), transport.Options{
+			OutBuffer:   cfg.OutBuffer,
+			ReadTimeout: time.Duration(cfg.ReadTimeout) * time.Second,
+			TLS:         tlsCfg,
+			Admission:   admission,
 		})
-	}()
-	go func() {
-		wsSrv := transport.NewWebSocketServer("/ws")
-		gw := transport.NewSimpleGateway()
-		logger.L().Sugar().Infow("starting_ws_server", "addr", cfg.WSAddr, "codec", cfg.WSCodec)
-		_ = wsSrv.Start(context.Background(), cfg.WSAddr, gw, transport.Options{
-			OutBuffer:    cfg.OutBuffer,
-			ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
-			WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
-			// 配置协议管理器
-			WSProtocolManager: protocol.NewProtocolManager(cfg.WSCodec),
+	}
+
+	if cfg.PollAddr != "" {
+		pollSrv := transport.NewLongPollServer("/poll")
+		if cfg.PollHold > 0 {
+			pollSrv.Hold = time.Duration(cfg.PollHold) * time.Second
+		}
+		srv.AddTransport(pollSrv, cfg.PollAddr, transport.NewChatGateway(hub, cmdReg), transport.Options{
+			OutBuffer:   cfg.OutBuffer,
+			ReadTimeout: time.Duration(cfg.ReadTimeout) * time.Second,
+			TLS:         tlsCfg,
+			Admission:   admission,
+		})
+	}
+
+	if cfg.IRCAddr != "" {
+		srv.AddTransport(transport.NewIRCServer("chat-go", cfg.IRCChan), cfg.IRCAddr, transport.NewChatGateway(hub, cmdReg), transport.Options{
+			ReadTimeout:       time.Duration(cfg.ReadTimeout) * time.Second * 2,
+			WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Second,
+			HeartbeatInterval: time.Duration(cfg.ReadTimeout) * time.Second,
+			TLS:               tlsCfg,
+			Admission:         admission,
+		})
+	}
+
+	if cfg.LineAddr != "" {
+		srv.AddTransport(transport.NewLineServer(), cfg.LineAddr, transport.NewChatGateway(hub, cmdReg), transport.Options{
+			ReadTimeout:   time.Duration(cfg.ReadTimeout) * time.Second * 10,
+			WriteTimeout:  time.Duration(cfg.WriteTimeout) * time.Second,
+			MaxLineLength: cfg.LineMax,
+			TLS:           tlsCfg,
+			Admission:     admission,
 		})
-	}()
-	go func() {
+	}
+
+	if cfg.UnixSocket != "" {
+		unixSrv := transport.NewUnixServer()
+		if mode, err := strconv.ParseUint(cfg.UnixMode, 8, 32); err == nil {
+			unixSrv.Mode = os.FileMode(mode)
+		}
+		unixSrv.Owner = cfg.UnixOwner
+		unixSrv.PeerIdentity = cfg.UnixPeerID
+		srv.AddTransport(unixSrv, cfg.UnixSocket, transport.NewChatGateway(hub, cmdReg), transport.Options{
+			OutBuffer:          cfg.OutBuffer,
+			ReadTimeout:        time.Duration(cfg.ReadTimeout) * time.Second,
+			WriteTimeout:       time.Duration(cfg.WriteTimeout) * time.Second,
+			MaxFrameSize:       cfg.MaxFrameSize,
+			MaxLineLength:      cfg.LineMax,
+			TCPProtocolManager: protocol.NewProtocolManager(cfg.TCPCodec),
+			CodecNegotiation:   cfg.TCPNegotiate,
+			HeartbeatInterval:  time.Duration(cfg.PingEvery) * time.Second,
+			HeartbeatTimeout:   time.Duration(cfg.PingTimeout) * time.Second,
+			Admission:          admission,
+		})
+	}
+
+	// HTTP 观测服务（静态页 ws.html 用于 WebSocket 测试）
+	srv.AddTask("http@"+cfg.HTTPAddr, func(ctx context.Context) error {
 		logger.L().Sugar().Infow("starting_http_server", "addr", cfg.HTTPAddr)
-		_ = observe.StartHTTP(cfg.HTTPAddr)
-	}()
+		return observe.StartHTTP(ctx, cfg.HTTPAddr)
+	})
+
+	// 作者在 CHAT_EDIT_WINDOW 秒内可以编辑或删除自己的消息
+	hub.SetEditWindow(time.Duration(cfg.EditLimit) * time.Second)
+	// 管理员角色只授予 CHAT_MODERATORS 中的已认证身份，可以随时编辑或删除任何消息
+	if cfg.Moderators != "" {
+		hub.SetModerators(strings.Split(cfg.Moderators, ","))
+	}
+
+	// 在线状态：无活动超过 CHAT_AWAY_AFTER 秒自动设为 away
+	if cfg.AwayAfter > 0 {
+		srv.AddTask("presence", func(ctx context.Context) error {
+			hub.RunPresence(ctx, time.Duration(cfg.AwayAfter)*time.Second)
+			return nil
+		})
+	}
 
 	// 可选：Redis Stream 分布式同步
 	if cfg.RedisEnable && cfg.RedisAddr != "" {
-		go func() {
-			bus := redisstream.New(cfg.RedisAddr, cfg.RedisDB, cfg.RedisStream, cfg.RedisGroup)
-			_ = bus.EnsureGroup(context.Background())
-
-			// 发布本地事件：仅针对 chat 消息类（可扩展系统通知/文件等）
-			hub.Subscribe(chat.EventMessageLocal, func(e chat.Event) {
-				me := e.(*chat.MessageEvent)
-				_ = bus.Publish(context.Background(), &redisstream.Message{Type: "message", When: me.When, From: me.From, Text: me.Content})
-			})
-			hub.Subscribe(chat.EventMessageDirect, func(e chat.Event) {
-				de := e.(*chat.DirectMessageEvent)
-				_ = bus.Publish(context.Background(), &redisstream.Message{Type: "direct", When: de.When, From: de.From, To: de.To, Text: de.Content})
-			})
-
-			// 消费远端事件 -> 转为本地 Remote 事件
-			_ = bus.Consume(context.Background(), "consumer-"+time.Now().Format("150405"), func(ctx context.Context, m *redisstream.Message) error {
-				switch m.Type {
-				case "message":
-					hub.BroadcastRemote(m.From, m.Text, m.When)
-				case "direct":
-					hub.Emit(&chat.DirectMessageEvent{When: m.When, From: m.From, To: m.To, Content: m.Text})
-				}
-				return nil
-			})
-		}()
+		// 每个节点使用自己的消费组读取全部消息，发布的消息带节点 ID，读回自己发布的消息时跳过
+		node := cfg.RedisNode
+		if node == "" {
+			host, _ := os.Hostname()
+			node = host + "-" + strconv.Itoa(os.Getpid())
+		}
+		bus := redisstream.New(cfg.RedisAddr, cfg.RedisDB, cfg.RedisStream, redisstream.NodeGroup(cfg.RedisGroup, node))
+		relay := redisstream.NewRelay(hub, bus, node)
+		relay.Start()
+
+		// 消费远端事件 -> 转为本地 Remote 事件；停机时关闭连接以中断阻塞读取
+		srv.AddTask("redis", func(ctx context.Context) error {
+			_ = bus.EnsureGroup(ctx)
+			go func() {
+				<-ctx.Done()
+				_ = bus.Close()
+			}()
+			return bus.Consume(ctx, node, relay.Handle)
+		})
+	}
+
+	if err := srv.Run(context.Background()); err != nil {
+		logger.L().Sugar().Errorw("server_failed", "err", err)
+		os.Exit(1)
 	}
-	select {}
 }
@@ -3,6 +3,7 @@ package redisstream
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -15,11 +16,16 @@ type Bus struct {
 }
 
 type Message struct {
-	Type string    `json:"type"`
-	When time.Time `json:"when"`
-	From string    `json:"from,omitempty"`
-	To   string    `json:"to,omitempty"`
-	Text string    `json:"text,omitempty"`
+	Type       string    `json:"type"`
+	Node       string    `json:"node,omitempty"` // id of the publishing node, lets a node skip its own messages
+	ID         string    `json:"id,omitempty"`   // message/direct/receipt/edit/delete: message id, correlates receipts and later edits
+	When       time.Time `json:"when"`
+	From       string    `json:"from,omitempty"`
+	To         string    `json:"to,omitempty"`
+	Recipients []string  `json:"recipients,omitempty"` // direct: all recipients (more than one for a group message); To carries the first one; edit/delete: recipients of the changed direct message
+	By         string    `json:"by,omitempty"`         // edit/delete: user who changed the message, From is its author
+	Text       string    `json:"text,omitempty"`
+	Status     string    `json:"status,omitempty"` // presence: user status, When is the last-seen time when offline; typing: start or stop; receipt: delivered or read
 }
 
 func New(addr string, db int, stream, group string) *Bus {
@@ -38,6 +44,11 @@ func (b *Bus) Publish(ctx context.Context, m *Message) error {
 	return b.cli.XAdd(ctx, &redis.XAddArgs{Stream: b.stream, Values: map[string]any{"data": payload}}).Err()
 }
 
+// Close closes the Redis client; a blocked Consume returns promptly
+func (b *Bus) Close() error {
+	return b.cli.Close()
+}
+
 type Handler func(ctx context.Context, m *Message) error
 
 // Consume blocks and delivers messages to handler; call cancel to stop
@@ -57,6 +68,9 @@ func (b *Bus) Consume(ctx context.Context, consumer string, handler Handler) err
 			if ctx.Err() != nil {
 				return ctx.Err()
 			}
+			if errors.Is(err, redis.ErrClosed) {
+				return err
+			}
 			// transient errors: continue
 			continue
 		}
@@ -0,0 +1,134 @@
+package redisstream
+
+import (
+	"context"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// Publisher publishes a message to the bus; *Bus implements it
+type Publisher interface {
+	Publish(ctx context.Context, m *Message) error
+}
+
+// NodeGroup is the consumer group of one node. Every node reads the whole stream in its own group;
+// nodes sharing a group would split the messages between them instead of each seeing all of them
+func NodeGroup(group, node string) string {
+	return group + ":" + node
+}
+
+// Relay synchronizes a Hub with the bus: events produced on this node are published stamped with
+// the node id, and messages read from the bus become Remote events on the Hub. A node also reads its
+// own messages back from the stream; Handle skips them by the node stamp.
+type Relay struct {
+	hub  *chat.Hub
+	pub  Publisher
+	node string
+}
+
+// NewRelay creates a relay for the node; node must be unique across the cluster
+func NewRelay(hub *chat.Hub, pub Publisher, node string) *Relay {
+	return &Relay{hub: hub, pub: pub, node: node}
+}
+
+// Start subscribes to local Hub events and publishes them to the bus
+func (r *Relay) Start() {
+	r.hub.Subscribe(chat.EventMessageLocal, func(e chat.Event) {
+		me := e.(*chat.MessageEvent)
+		r.publish(&Message{Type: "message", ID: me.ID, When: me.When, From: me.From, Text: me.Content})
+	})
+	// edits and deletes: only changes authorized on this node; When is the change time, nodes use it to drop duplicate or stale changes
+	publishEdit := func(e chat.Event) {
+		me := e.(*chat.MessageEditEvent)
+		if me.Remote {
+			return
+		}
+		typ := "edit"
+		if me.Deleted {
+			typ = "delete"
+		}
+		r.publish(&Message{Type: typ, ID: me.ID, When: me.When, From: me.From, By: me.By, Recipients: me.To, Text: me.Content})
+	}
+	r.hub.Subscribe(chat.EventMessageEdit, publishEdit)
+	r.hub.Subscribe(chat.EventMessageDelete, publishEdit)
+	r.hub.Subscribe(chat.EventMessageDirect, func(e chat.Event) {
+		de := e.(*chat.DirectMessageEvent)
+		if de.Remote {
+			return
+		}
+		// a group message is published once, every node delivers it to the recipients connected there
+		r.publish(&Message{Type: "direct", ID: de.ID, When: de.When, From: de.From, To: de.To[0], Recipients: de.To, Text: de.Content})
+	})
+	// receipts: delivered/read produced on the recipient's node go back to the sender's node (sent is reported by the sender's node)
+	r.hub.Subscribe(chat.EventReceipt, func(e chat.Event) {
+		re := e.(*chat.ReceiptEvent)
+		if re.Remote || re.State == chat.DeliverySent {
+			return
+		}
+		r.publish(&Message{Type: "receipt", ID: re.ID, When: re.When, From: re.User, To: re.From, Status: string(re.State)})
+	})
+	// presence: only changes produced on this node; invisible is published as offline, other nodes cannot tell the difference
+	r.hub.Subscribe(chat.EventPresence, func(e chat.Event) {
+		pe := e.(*chat.PresenceEvent)
+		if pe.Remote {
+			return
+		}
+		p := pe.Presence.Visible()
+		when := pe.When
+		if p.Status == chat.StatusOffline {
+			when = p.LastSeen
+		}
+		r.publish(&Message{Type: "presence", When: when, From: p.User, Text: p.Text, Status: string(p.Status)})
+	})
+	// typing: only start/stop produced on this node, already throttled by the Hub
+	r.hub.Subscribe(chat.EventTyping, func(e chat.Event) {
+		te := e.(*chat.TypingEvent)
+		if te.Remote {
+			return
+		}
+		status := "stop"
+		if te.Active {
+			status = "start"
+		}
+		r.publish(&Message{Type: "typing", When: te.When, From: te.From, To: te.To, Status: status})
+	})
+}
+
+func (r *Relay) publish(m *Message) {
+	m.Node = r.node
+	if err := r.pub.Publish(context.Background(), m); err != nil {
+		logger.L().Sugar().Warnw("bus_publish_failed", "type", m.Type, "err", err)
+	}
+}
+
+// Handle applies a message read from the bus to the Hub as a Remote event; usable as a Consume handler.
+// Messages published by this node are skipped, so local events are not applied twice.
+func (r *Relay) Handle(_ context.Context, m *Message) error {
+	if m.Node == r.node {
+		return nil
+	}
+	switch m.Type {
+	case "message":
+		r.hub.BroadcastRemote(m.ID, m.From, m.Text, m.When)
+	case "edit", "delete":
+		r.hub.ApplyRemoteEdit(&chat.MessageEditEvent{When: m.When, ID: m.ID, From: m.From, To: m.Recipients, By: m.By, Content: m.Text, Deleted: m.Type == "delete"})
+	case "direct":
+		to := m.Recipients
+		if len(to) == 0 {
+			to = []string{m.To}
+		}
+		r.hub.ReceiveRemoteDirect(m.ID, m.From, to, m.Text, m.When)
+	case "receipt":
+		r.hub.ApplyRemoteReceipt(m.ID, m.To, m.From, chat.DeliveryState(m.Status))
+	case "presence":
+		p := chat.Presence{User: m.From, Status: chat.Status(m.Status), Text: m.Text, Since: m.When}
+		if p.Status == chat.StatusOffline {
+			p.LastSeen = m.When
+		}
+		r.hub.ApplyRemotePresence(p)
+	case "typing":
+		r.hub.ApplyRemoteTyping(m.From, m.To, m.Status == "start", m.When)
+	}
+	return nil
+}
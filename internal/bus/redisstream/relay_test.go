@@ -0,0 +1,173 @@
+package redisstream
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+)
+
+// memBus delivers every published message to all relays, the publisher included,
+// like a stream read by every node in its own consumer group
+type memBus struct {
+	mu     sync.Mutex
+	relays []*Relay
+}
+
+func (b *memBus) Publish(ctx context.Context, m *Message) error {
+	b.mu.Lock()
+	relays := append([]*Relay(nil), b.relays...)
+	b.mu.Unlock()
+	for _, r := range relays {
+		cp := *m
+		_ = r.Handle(ctx, &cp)
+	}
+	return nil
+}
+
+// newNodes creates n hubs joined by one in-memory bus
+func newNodes(n int) (*memBus, []*chat.Hub) {
+	bus := &memBus{}
+	hubs := make([]*chat.Hub, n)
+	for i := range hubs {
+		hubs[i] = chat.NewHub()
+		r := NewRelay(hubs[i], bus, "node"+strconv.Itoa(i))
+		r.Start()
+		bus.relays = append(bus.relays, r)
+	}
+	return bus, hubs
+}
+
+func connect(hub *chat.Hub, id, name string) *chat.Client {
+	c := chat.NewClientWithBuffer(id, 8)
+	c.SetName(name)
+	hub.RegisterClient(c)
+	return c
+}
+
+// eventually polls cond until it holds or a timeout expires
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestRelayPresence presence set on one node reaches the other; the node's own echo does not
+// turn its local entry into a remote one, so the chosen status survives a reconnect
+func TestRelayPresence(t *testing.T) {
+	_, hubs := newNodes(2)
+	a, b := hubs[0], hubs[1]
+
+	alice := connect(a, "a1", "alice")
+	a.SetPresence("alice", chat.StatusBusy, "meeting")
+	eventually(t, "busy on node1", func() bool {
+		p, _ := b.PresenceOf("alice")
+		return p.Status == chat.StatusBusy && p.Text == "meeting"
+	})
+
+	a.UnregisterClient(alice)
+	eventually(t, "offline on node1", func() bool {
+		p, _ := b.PresenceOf("alice")
+		return p.Status == chat.StatusOffline
+	})
+	// let the echo of the offline change reach node0 before reconnecting
+	time.Sleep(20 * time.Millisecond)
+	connect(a, "a2", "alice")
+	if p, _ := a.PresenceOf("alice"); p.Status != chat.StatusBusy {
+		t.Errorf("reconnect on node0 = %s, want the chosen busy status back", p.Status)
+	}
+}
+
+// TestRelayTyping typing started and stopped on one node ends stopped on the other, even though
+// the two events may be published out of order; the node does not apply its own echo
+func TestRelayTyping(t *testing.T) {
+	_, hubs := newNodes(2)
+	a, b := hubs[0], hubs[1]
+	events := func(hub *chat.Hub) func() []*chat.TypingEvent {
+		var mu sync.Mutex
+		var got []*chat.TypingEvent
+		hub.Subscribe(chat.EventTyping, func(e chat.Event) {
+			mu.Lock()
+			got = append(got, e.(*chat.TypingEvent))
+			mu.Unlock()
+		})
+		return func() []*chat.TypingEvent {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]*chat.TypingEvent(nil), got...)
+		}
+	}
+	onA, onB := events(a), events(b)
+
+	a.Typing("alice", "", true)
+	a.Typing("alice", "", false)
+	time.Sleep(50 * time.Millisecond)
+
+	for _, e := range onA() {
+		if e.Remote {
+			t.Errorf("node0 applied its own echo: %+v", e)
+		}
+	}
+	// node1 applies start then stop, or only the stop when it arrives first; a start without
+	// a matching stop is an indicator left on until it expires
+	started, stopped := 0, 0
+	for _, e := range onB() {
+		if e.Active {
+			started++
+		} else {
+			stopped++
+		}
+	}
+	if started != stopped {
+		t.Errorf("node1 saw %d starts and %d stops", started, stopped)
+	}
+}
+
+// TestRelayGroupDirect a group message reaches every node exactly once: the sender's node does not
+// deliver its own echo again, and each other node delivers it to the recipients connected there
+func TestRelayGroupDirect(t *testing.T) {
+	bus, hubs := newNodes(3)
+	connect(hubs[0], "a", "alice")
+	connect(hubs[0], "b", "bob")
+	connect(hubs[1], "c", "carol")
+	connect(hubs[2], "d", "dave")
+	counts := make([]func() int, len(hubs))
+	for i, hub := range hubs {
+		var mu sync.Mutex
+		n := 0
+		hub.Subscribe(chat.EventMessageDirect, func(chat.Event) {
+			mu.Lock()
+			n++
+			mu.Unlock()
+		})
+		counts[i] = func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return n
+		}
+	}
+
+	if _, err := hubs[0].SendDirect("m1", "alice", []string{"bob", "carol", "dave"}, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	// a message from an older node without an id still arrives once on every other node
+	_ = bus.Publish(context.Background(), &Message{Type: "direct", Node: "legacy", When: time.Now(), From: "alice", To: "bob", Recipients: []string{"bob", "carol", "dave"}, Text: "hello"})
+	for i := range hubs {
+		i := i
+		eventually(t, "node"+strconv.Itoa(i)+" direct", func() bool { return counts[i]() >= 2 })
+	}
+	time.Sleep(20 * time.Millisecond)
+	for i, n := range counts {
+		if got := n(); got != 2 {
+			t.Errorf("node%d delivered %d direct messages, want 2", i, got)
+		}
+	}
+}
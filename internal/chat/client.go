@@ -2,19 +2,34 @@ package chat
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/hongjun500/chat-go/internal/i18n"
 	"github.com/hongjun500/chat-go/internal/observe"
 )
 
+// EventObserver 传输层对结构化事件的处理（如 IRC 将聊天消息渲染为 PRIVMSG），
+// 返回 true 表示已处理，Hub 不再发送渲染后的文本
+type EventObserver func(Event) bool
+
 // Client 客户端连接实例
 // 职责：维护用户状态与待发送消息缓冲；不直接操作底层连接。
 type Client struct {
-	ID        string
-	Name      string
-	Meta      map[string]string // 扩展元数据
-	out       chan string
-	closeOnce sync.Once
-	closed    chan struct{}
+	ID            string
+	name          string
+	nameMu        sync.RWMutex         // 保护 name：登录、改名与各 goroutine 的读取可能并发
+	Meta          map[string]string    // 扩展元数据
+	Observer      EventObserver        // 可选，注册到 Hub 之前设置
+	Latency       func() time.Duration // 可选，传输层心跳测得的往返时延，注册到 Hub 之前设置
+	locale        atomic.Value         // i18n.Locale，会话语言
+	lastSent      atomic.Value         // string，本连接最近发送的消息 ID
+	moderator     atomic.Bool          // 服务端按配置授予的管理员角色，用户不能通过命令自行获得
+	authenticated atomic.Bool          // 昵称是传输层认证的身份
+	out           chan string
+	sendMu        sync.RWMutex // Send 持读锁，Close 持写锁，保证不会向已关闭的 out 写入
+	closeOnce     sync.Once
+	closed        chan struct{}
 }
 
 // NewClientWithBuffer 允许指定发送缓冲区大小
@@ -30,8 +45,37 @@ func NewClientWithBuffer(id string, bufferSize int) *Client {
 	}
 }
 
+// Name 客户端当前的昵称，未登录时为空
+func (c *Client) Name() string {
+	c.nameMu.RLock()
+	defer c.nameMu.RUnlock()
+	return c.name
+}
+
+// SetName 设置昵称。已注册到 Hub 的客户端应通过 Hub.Rename 改名，以同步更新在线状态
+func (c *Client) SetName(name string) {
+	c.nameMu.Lock()
+	defer c.nameMu.Unlock()
+	c.name = name
+}
+
+// Moderator 客户端是否拥有管理员角色：可以随时编辑或删除任何人的消息
+func (c *Client) Moderator() bool {
+	return c.moderator.Load()
+}
+
+// Authenticated 客户端的昵称是否为传输层认证的身份（客户端证书、令牌或 Unix 对端用户）
+func (c *Client) Authenticated() bool {
+	return c.authenticated.Load()
+}
+
 // Send 非阻塞写入到 client 输出缓冲，缓冲溢出策略：暂时直接丢弃
 func (c *Client) Send(message string) {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.IsClosed() {
+		return
+	}
 	select {
 	case c.out <- message:
 	default:
@@ -41,6 +85,49 @@ func (c *Client) Send(message string) {
 	}
 }
 
+// SendLocalized 按客户端语言渲染文案后发送
+func (c *Client) SendLocalized(key string, args ...any) {
+	c.Send(i18n.T(c.Locale(), key, args...))
+}
+
+// observe 交给观察者处理事件，返回是否已处理
+func (c *Client) observe(e Event) bool {
+	return c.Observer != nil && c.Observer(e)
+}
+
+// RTT 传输层测得的往返时延，未知时为 0
+func (c *Client) RTT() time.Duration {
+	if c.Latency == nil {
+		return 0
+	}
+	return c.Latency()
+}
+
+// Locale 客户端语言，未设置时为默认语言
+func (c *Client) Locale() i18n.Locale {
+	if l, ok := c.locale.Load().(i18n.Locale); ok {
+		return l
+	}
+	return i18n.Default()
+}
+
+// SetLocale 设置客户端语言
+func (c *Client) SetLocale(l i18n.Locale) {
+	c.locale.Store(l)
+}
+
+// LastSent 本连接最近发送的聊天室消息或私信的 ID，供无法得知消息 ID 的文本客户端（/edit last）使用。
+// 按连接而不是昵称记录，同名的其它连接或改名不影响
+func (c *Client) LastSent() (string, bool) {
+	id, ok := c.lastSent.Load().(string)
+	return id, ok && id != ""
+}
+
+// SetLastSent 记录本连接最近发送的消息 ID，由发送消息的网关或命令在发送成功后调用
+func (c *Client) SetLastSent(id string) {
+	c.lastSent.Store(id)
+}
+
 // Outgoing 返回只读输出通道，transport 读取并写到网络
 func (c *Client) Outgoing() <-chan string {
 	return c.out
@@ -48,6 +135,8 @@ func (c *Client) Outgoing() <-chan string {
 
 func (c *Client) Close() {
 	c.closeOnce.Do(func() {
+		c.sendMu.Lock()
+		defer c.sendMu.Unlock()
 		close(c.closed)
 		close(c.out)
 	})
@@ -1,13 +1,53 @@
 package chat
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
 
 type DirectMessageEvent struct {
-	When    time.Time
-	From    string
-	To      string
-	Content string
+	When         time.Time
+	ID           string // 消息 ID：发送者信封的 mid，为空时由服务端分配
+	Conversation string // 会话 ID，由发送者与全部接收者确定，见 ConversationID
+	From         string
+	To           []string // 全部接收者，多于一个时为群组私信
+	Content      string
+	Remote       bool // 来自其它节点，不再向总线转发
 }
 
 func (e *DirectMessageEvent) Type() EventType { return EventMessageDirect }
 func (e *DirectMessageEvent) Time() time.Time { return e.When }
+
+// ConversationID 由参与者集合（发送者与接收者）得出稳定的会话 ID：与顺序和重复无关，
+// 各节点独立计算结果一致，同一组人之间的私信属于同一会话
+func ConversationID(from string, to []string) string {
+	names := append([]string{from}, to...)
+	sort.Strings(names)
+	h := sha256.New()
+	for i, n := range names {
+		if i > 0 && n == names[i-1] {
+			continue
+		}
+		h.Write([]byte(n))
+		h.Write([]byte{0})
+	}
+	return "dm-" + hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// Recipients 规范化接收者列表：去掉首尾空白与空名，去重并保持原有顺序
+func Recipients(to []string) []string {
+	out := make([]string, 0, len(to))
+	seen := make(map[string]bool, len(to))
+	for _, n := range to {
+		n = strings.TrimSpace(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
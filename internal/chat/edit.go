@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultEditWindow 作者可以编辑或删除自己消息的默认时限
+const DefaultEditWindow = 15 * time.Minute
+
+// maxEditableMessages 可编辑/删除的消息记录数，超出时丢弃最早的记录
+const maxEditableMessages = 10000
+
+// 编辑/删除消息的错误
+var (
+	ErrMessageNotFound = errors.New("message not found")
+	ErrMessageDeleted  = errors.New("message has been deleted")
+	ErrNotAuthor       = errors.New("only the author or a moderator can change this message")
+	ErrEditExpired     = errors.New("edit window has expired")
+)
+
+// MessageEditEvent 消息被编辑或删除。删除时 Deleted 为 true、Content 为空（墓碑），不再携带原内容
+type MessageEditEvent struct {
+	When         time.Time // 编辑或删除的时间
+	ID           string    // 原消息 ID（发送者信封的 mid）
+	Conversation string    // 私信的会话 ID，聊天室消息为空
+	From         string    // 原消息作者
+	To           []string  // 私信接收者，聊天室消息为空
+	By           string    // 编辑或删除者，管理员操作他人消息时与作者不同
+	Content      string    // 编辑后的内容
+	History      []string  // 之前的各个版本，最早的在前
+	Deleted      bool
+	Remote       bool // 来自其它节点，不再向总线转发
+}
+
+func (e *MessageEditEvent) Type() EventType {
+	if e.Deleted {
+		return EventMessageDelete
+	}
+	return EventMessageEdit
+}
+
+func (e *MessageEditEvent) Time() time.Time { return e.When }
+
+// MessageRecord 可编辑/删除的消息：聊天室消息与私信
+type MessageRecord struct {
+	ID           string
+	Conversation string
+	From         string
+	To           []string // 私信接收者，聊天室消息为空
+	Content      string
+	When         time.Time
+	History      []string  // 之前的各个版本，最早的在前
+	Edited       time.Time // 最近一次编辑的时间，未编辑时为零值
+	Deleted      time.Time // 删除时间，未删除时为零值；删除后保留记录作为墓碑
+}
+
+// messageStore 按消息 ID 记录消息
+type messageStore struct {
+	mu     sync.Mutex
+	byID   map[string]*MessageRecord
+	order  []string
+	window time.Duration // 作者可以编辑或删除的时限，<= 0 表示不限
+}
+
+// add 记录一条消息。ID 已存在时不覆盖：同一作者在同一会话中的消息视为重复（客户端重试），返回 false；
+// 其他作者或其它会话的消息返回 ErrDuplicateID
+func (s *messageStore) add(r *MessageRecord) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.byID[r.ID]; ok {
+		if old.From != r.From || old.Conversation != r.Conversation {
+			return false, ErrDuplicateID
+		}
+		return false, nil
+	}
+	s.byID[r.ID] = r
+	s.order = append(s.order, r.ID)
+	if len(s.order) > maxEditableMessages {
+		for _, id := range s.order[:len(s.order)-maxEditableMessages] {
+			delete(s.byID, id)
+		}
+		s.order = append([]string(nil), s.order[len(s.order)-maxEditableMessages:]...)
+	}
+	return true, nil
+}
+
+// authorize 检查 by 能否修改消息 id，返回消息记录。调用方持有 s.mu
+func (s *messageStore) authorize(id, by string, moderator bool, now time.Time) (*MessageRecord, error) {
+	r, ok := s.byID[id]
+	switch {
+	case !ok:
+		return nil, ErrMessageNotFound
+	case !r.Deleted.IsZero():
+		return nil, ErrMessageDeleted
+	case moderator:
+		return r, nil
+	case r.From != by:
+		return nil, ErrNotAuthor
+	case s.window > 0 && now.Sub(r.When) > s.window:
+		return nil, ErrEditExpired
+	}
+	return r, nil
+}
+
+// SetEditWindow 设置作者可以编辑或删除自己消息的时限，<= 0 表示不限；管理员不受限制
+func (h *Hub) SetEditWindow(d time.Duration) {
+	h.messages.mu.Lock()
+	defer h.messages.mu.Unlock()
+	h.messages.window = d
+}
+
+// SendRoom 向聊天室发送一条消息并记录以支持编辑与删除，返回消息 ID；id 为空时由服务端分配。
+// 同一作者以同一 id 重复发送（客户端重试）时不再广播；id 已被其他作者的消息使用时返回 ErrDuplicateID
+func (h *Hub) SendRoom(id, from, content string) (string, error) {
+	if id == "" {
+		id = newMessageID()
+	}
+	now := time.Now()
+	added, err := h.messages.add(&MessageRecord{ID: id, From: from, Content: content, When: now})
+	if err != nil {
+		return "", err
+	}
+	if added {
+		h.Emit(&MessageEvent{When: now, ID: id, From: from, Content: content, Local: true})
+	}
+	return id, nil
+}
+
+// EditMessage by 将消息 id 的内容改为 content：作者在编辑时限内或管理员任何时候可以编辑，已删除的消息不能编辑。
+// 之前的内容保留在历史中，编辑通知发给消息原来的所有接收者
+func (h *Hub) EditMessage(id, by string, moderator bool, content string) error {
+	h.messages.mu.Lock()
+	// 在锁内取时间，使同一消息的修改时间与加锁顺序一致
+	now := time.Now()
+	r, err := h.messages.authorize(id, by, moderator, now)
+	if err != nil || r.Content == content {
+		h.messages.mu.Unlock()
+		return err
+	}
+	r.History = append(r.History, r.Content)
+	r.Content, r.Edited = content, now
+	e := editEvent(r, by, now, false)
+	h.messages.mu.Unlock()
+	h.changed(e)
+	return nil
+}
+
+// DeleteMessage by 删除消息 id，授权规则同 EditMessage。内容与历史被清除，记录保留为墓碑
+func (h *Hub) DeleteMessage(id, by string, moderator bool) error {
+	h.messages.mu.Lock()
+	// 在锁内取时间，使同一消息的修改时间与加锁顺序一致
+	now := time.Now()
+	r, err := h.messages.authorize(id, by, moderator, now)
+	if err != nil {
+		h.messages.mu.Unlock()
+		return err
+	}
+	r.Content, r.History, r.Deleted = "", nil, now
+	e := editEvent(r, by, now, false)
+	h.messages.mu.Unlock()
+	h.changed(e)
+	return nil
+}
+
+// ApplyRemoteEdit 应用其它节点同步的编辑或删除（已在来源节点授权）。本节点有记录时更新记录，
+// 早于本节点最近一次修改的编辑视为过期或重复而忽略；没有记录时只通知本节点上的接收者
+func (h *Hub) ApplyRemoteEdit(e *MessageEditEvent) {
+	h.messages.mu.Lock()
+	r, ok := h.messages.byID[e.ID]
+	if !ok {
+		h.messages.mu.Unlock()
+		ev := &MessageEditEvent{When: e.When, ID: e.ID, From: e.From, To: e.To, By: e.By, Content: e.Content, Deleted: e.Deleted, Remote: true}
+		if len(e.To) > 0 {
+			ev.Conversation = ConversationID(e.From, e.To)
+		}
+		h.Emit(ev)
+		return
+	}
+	if !r.Deleted.IsZero() || !e.When.After(r.Edited) {
+		h.messages.mu.Unlock()
+		return
+	}
+	if e.Deleted {
+		r.Content, r.History, r.Deleted = "", nil, e.When
+	} else {
+		r.History = append(r.History, r.Content)
+		r.Content, r.Edited = e.Content, e.When
+	}
+	ev := editEvent(r, e.By, e.When, true)
+	h.messages.mu.Unlock()
+	h.changed(ev)
+}
+
+// changed 在 messages.mu 之外同步私信记录中的内容并发出编辑/删除事件
+func (h *Hub) changed(e *MessageEditEvent) {
+	h.direct.setContent(e)
+	h.Emit(e)
+}
+
+// MessageOf 查询消息记录
+func (h *Hub) MessageOf(id string) (MessageRecord, bool) {
+	h.messages.mu.Lock()
+	defer h.messages.mu.Unlock()
+	if r, ok := h.messages.byID[id]; ok {
+		cp := *r
+		cp.History = append([]string(nil), r.History...)
+		return cp, true
+	}
+	return MessageRecord{}, false
+}
+
+// editEvent 消息记录当前状态对应的编辑/删除事件（副本），调用方持有 messages.mu
+func editEvent(r *MessageRecord, by string, when time.Time, remote bool) *MessageEditEvent {
+	return &MessageEditEvent{
+		When:         when,
+		ID:           r.ID,
+		Conversation: r.Conversation,
+		From:         r.From,
+		To:           append([]string(nil), r.To...),
+		By:           by,
+		Content:      r.Content,
+		History:      append([]string(nil), r.History...),
+		Deleted:      !r.Deleted.IsZero(),
+		Remote:       remote,
+	}
+}
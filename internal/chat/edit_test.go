@@ -0,0 +1,168 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func subscribeEdits(hub *Hub) <-chan *MessageEditEvent {
+	ch := make(chan *MessageEditEvent, 16)
+	fn := func(e Event) { ch <- e.(*MessageEditEvent) }
+	hub.Subscribe(EventMessageEdit, fn)
+	hub.Subscribe(EventMessageDelete, fn)
+	return ch
+}
+
+func waitEdit(t *testing.T, ch <-chan *MessageEditEvent) *MessageEditEvent {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for edit event")
+		return nil
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	hub := NewHub()
+	edits := subscribeEdits(hub)
+	hub.SendRoom("m1", "alice", "helo")
+
+	if err := hub.EditMessage("m1", "alice", false, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	hub.EditMessage("m1", "alice", false, "hello!")
+	// 事件异步分发，两次编辑的通知可能乱序到达
+	e := waitEdit(t, edits)
+	if next := waitEdit(t, edits); next.Content == "hello!" {
+		e = next
+	}
+	if e.Content != "hello!" || e.By != "alice" || len(e.History) != 2 || e.History[0] != "helo" || len(e.To) != 0 {
+		t.Fatalf("edit event = %+v", e)
+	}
+	if r, _ := hub.MessageOf("m1"); r.Content != "hello!" || r.Edited.IsZero() {
+		t.Errorf("record = %+v", r)
+	}
+
+	if err := hub.EditMessage("m1", "bob", false, "hacked"); !errors.Is(err, ErrNotAuthor) {
+		t.Errorf("other user: err = %v", err)
+	}
+	if err := hub.EditMessage("nope", "alice", false, "x"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("unknown message: err = %v", err)
+	}
+
+	// 超过编辑时限后作者不能修改，管理员仍可以
+	hub.SetEditWindow(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if err := hub.EditMessage("m1", "alice", false, "late"); !errors.Is(err, ErrEditExpired) {
+		t.Errorf("expired: err = %v", err)
+	}
+	if err := hub.EditMessage("m1", "mod", true, "moderated"); err != nil {
+		t.Errorf("moderator: err = %v", err)
+	}
+	if e := waitEdit(t, edits); e.By != "mod" || e.From != "alice" {
+		t.Errorf("moderator edit = %+v", e)
+	}
+}
+
+// TestRoomMessageID 聊天室消息 ID 属于作者：他人复用同一 mid 被拒绝而不是静默丢弃，重试不再广播；mid 为空时由服务端分配
+func TestRoomMessageID(t *testing.T) {
+	hub := NewHub()
+	msgs := make(chan *MessageEvent, 4)
+	hub.Subscribe(EventMessageLocal, func(e Event) { msgs <- e.(*MessageEvent) })
+
+	if id, err := hub.SendRoom("m1", "alice", "hi"); err != nil || id != "m1" {
+		t.Fatalf("send = %q, %v", id, err)
+	}
+	<-msgs
+	if _, err := hub.SendRoom("m1", "bob", "spoof"); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("reused mid: err = %v", err)
+	}
+	if _, err := hub.SendRoom("m1", "alice", "hi"); err != nil {
+		t.Errorf("retry: err = %v", err)
+	}
+	id, err := hub.SendRoom("", "alice", "untagged")
+	if err != nil || id == "" {
+		t.Fatalf("send without mid = %q, %v", id, err)
+	}
+	if e := <-msgs; e.ID != id || e.Content != "untagged" {
+		t.Errorf("broadcast = %+v, want id %q", e, id)
+	}
+	if r, _ := hub.MessageOf("m1"); r.From != "alice" || r.Content != "hi" {
+		t.Errorf("record = %+v", r)
+	}
+}
+
+func TestDeleteMessage(t *testing.T) {
+	hub := NewHub()
+	edits := subscribeEdits(hub)
+	hub.SendDirect("d1", "alice", []string{"bob", "carol"}, "secret")
+	hub.EditMessage("d1", "alice", false, "secret!")
+	waitEdit(t, edits)
+
+	if err := hub.DeleteMessage("d1", "alice", false); err != nil {
+		t.Fatal(err)
+	}
+	e := waitEdit(t, edits)
+	if !e.Deleted || e.Type() != EventMessageDelete || e.Content != "" || len(e.History) != 0 || len(e.To) != 2 {
+		t.Fatalf("tombstone = %+v", e)
+	}
+	if r, _ := hub.DirectRecordOf("d1", "bob"); r.Content != "" {
+		t.Errorf("direct record keeps content %q", r.Content)
+	}
+	if err := hub.EditMessage("d1", "alice", false, "again"); !errors.Is(err, ErrMessageDeleted) {
+		t.Errorf("edit after delete: err = %v", err)
+	}
+	if err := hub.DeleteMessage("d1", "mod", true); !errors.Is(err, ErrMessageDeleted) {
+		t.Errorf("delete twice: err = %v", err)
+	}
+}
+
+// TestConcurrentEdits 并发编辑后私信记录与消息记录的内容一致
+func TestConcurrentEdits(t *testing.T) {
+	hub := NewHub()
+	hub.SendDirect("d1", "alice", []string{"bob"}, "v0")
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hub.EditMessage("d1", "alice", false, fmt.Sprintf("v%d", i))
+		}(i)
+	}
+	wg.Wait()
+	m, _ := hub.MessageOf("d1")
+	if r, _ := hub.DirectRecordOf("d1", "bob"); r.Content != m.Content || len(m.History) != 20 {
+		t.Errorf("direct record %q, message %q with %d versions", r.Content, m.Content, len(m.History))
+	}
+}
+
+func TestApplyRemoteEdit(t *testing.T) {
+	hub := NewHub()
+	edits := subscribeEdits(hub)
+	hub.BroadcastRemote("r1", "dave", "hi", time.Now())
+
+	later := time.Now().Add(time.Second)
+	hub.ApplyRemoteEdit(&MessageEditEvent{When: later, ID: "r1", From: "dave", By: "dave", Content: "hi there"})
+	if e := waitEdit(t, edits); !e.Remote || e.Content != "hi there" || len(e.History) != 1 {
+		t.Fatalf("remote edit = %+v", e)
+	}
+	// 重复或更早的修改被忽略
+	hub.ApplyRemoteEdit(&MessageEditEvent{When: later, ID: "r1", From: "dave", By: "dave", Content: "hi there"})
+	hub.ApplyRemoteEdit(&MessageEditEvent{When: later.Add(-time.Millisecond), ID: "r1", From: "dave", By: "dave", Content: "old"})
+	select {
+	case e := <-edits:
+		t.Fatalf("stale edit applied: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 本节点没有记录时仍通知接收者
+	hub.ApplyRemoteEdit(&MessageEditEvent{When: later, ID: "x1", From: "dave", To: []string{"erin"}, By: "dave", Deleted: true})
+	if e := waitEdit(t, edits); !e.Deleted || e.Conversation != ConversationID("dave", []string{"erin"}) {
+		t.Fatalf("unknown remote delete = %+v", e)
+	}
+}
@@ -14,6 +14,11 @@ const (
 	EventSystemNotice  EventType = "system.notice"
 	EventFileTransfer  EventType = "file.transfer"
 	EventHeartbeat     EventType = "heartbeat"
+	EventPresence      EventType = "presence.changed" // 在线状态变化
+	EventTyping        EventType = "typing"           // 正在输入提示
+	EventReceipt       EventType = "message.receipt"  // 私信投递状态变化
+	EventMessageEdit   EventType = "message.edit"     // 消息被编辑
+	EventMessageDelete EventType = "message.delete"   // 消息被删除
 )
 
 type Event interface {
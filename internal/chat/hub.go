@@ -25,12 +25,39 @@ type Hub struct {
 	// 封禁名单：用户名 -> 过期时间（零值表示永久）
 	banMu  sync.RWMutex
 	banned map[string]time.Time
+
+	// 在线状态：用户名 -> 状态记录
+	presence presenceStore
+	// 进行中的正在输入提示
+	typing typingStore
+	// 私信记录与投递状态
+	direct directStore
+	// 可编辑/删除的消息
+	messages messageStore
+	// 管理员与已认证身份
+	login loginStore
 }
 
 func NewHub() *Hub {
 	return &Hub{
 		handlers: make(map[EventType][]handlerEntry),
 		banned:   make(map[string]time.Time),
+		presence: presenceStore{users: make(map[string]*presenceEntry)},
+		typing: typingStore{
+			active:   make(map[typingKey]*typingEntry),
+			synced:   make(map[typingKey]time.Time),
+			throttle: DefaultTypingThrottle,
+			expiry:   DefaultTypingExpiry,
+		},
+		direct: directStore{
+			byKey: make(map[directKey]*DirectRecord),
+			convs: make(map[conversationKey][]*DirectRecord),
+		},
+		messages: messageStore{
+			byID:   make(map[string]*MessageRecord),
+			window: DefaultEditWindow,
+		},
+		login: loginStore{reserved: make(map[string]bool)},
 	}
 }
 
@@ -86,11 +113,11 @@ func (h *Hub) Emit(e Event) {
 	}
 }
 
-// RegisterClient 注册客户端并发出 UserJoined 事件
+// RegisterClient 注册客户端并发出 UserJoined 事件，不检查昵称是否已被使用；网关登录使用 Login
 func (h *Hub) RegisterClient(c *Client) {
-	h.clients.Store(c.ID, c)
-	h.Emit(&UserEvent{When: time.Now(), User: c, Desc: "joined"})
-	observe.AddOnline(1)
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	h.registerLocked(c)
 }
 
 // UnregisterClient 注销客户端并发出 UserLeave 事件
@@ -98,6 +125,7 @@ func (h *Hub) UnregisterClient(c *Client) {
 	if _, loaded := h.clients.LoadAndDelete(c.ID); loaded {
 		c.Close()
 		h.Emit(&UserEvent{When: time.Now(), User: c, Desc: "leave"})
+		h.presenceOffline(c)
 		observe.AddOnline(-1)
 		return
 	}
@@ -109,7 +137,7 @@ func (h *Hub) UnregisterClient(c *Client) {
 func (h *Hub) KickByName(name string) bool {
 	found := false
 	h.clients.Range(func(_, v any) bool {
-		if c, ok := v.(*Client); ok && c.Name == name {
+		if c, ok := v.(*Client); ok && c.Name() == name {
 			h.UnregisterClient(c)
 			found = true
 		}
@@ -150,22 +178,46 @@ func (h *Hub) IsBanned(name string) bool {
 	return false
 }
 
-// BroadcastLocal 触发本地消息事件
+// BroadcastLocal 触发本地消息事件，消息不可编辑；需要编辑与删除时使用 SendRoom
 func (h *Hub) BroadcastLocal(from, content string) {
 	h.Emit(&MessageEvent{When: time.Now(), From: from, Content: content, Local: true})
 }
 
-// BroadcastRemote 触发远端同步消息事件（来自其它节点）
-func (h *Hub) BroadcastRemote(from, content string, t time.Time) {
-	h.Emit(&MessageEvent{When: t, From: from, Content: content, Local: false})
+// BroadcastRemote 触发远端同步消息事件（来自其它节点）；id 非空时记录，以便应用之后同步的编辑与删除。
+// 同一条消息重复到达或 id 与本节点其他作者的消息冲突时忽略
+func (h *Hub) BroadcastRemote(id, from, content string, t time.Time) {
+	if id != "" {
+		if added, _ := h.messages.add(&MessageRecord{ID: id, From: from, Content: content, When: t}); !added {
+			return
+		}
+	}
+	h.Emit(&MessageEvent{When: t, ID: id, From: from, Content: content, Local: false})
 }
 
-// ListNames 返回在线用户名（简单实现）
+// ListNames 返回在线用户名（简单实现），隐身用户不列出
 func (h *Hub) ListNames() []string {
 	var out []string
 	h.clients.Range(func(k, v any) bool {
+		if c, ok := v.(*Client); ok && !h.invisible(c.Name()) {
+			out = append(out, c.Name())
+		}
+		return true
+	})
+	return out
+}
+
+// invisible 用户是否处于隐身状态
+func (h *Hub) invisible(name string) bool {
+	p, ok := h.PresenceOf(name)
+	return ok && p.Status == StatusInvisible
+}
+
+// ListClients 返回在线客户端快照
+func (h *Hub) ListClients() []*Client {
+	var out []*Client
+	h.clients.Range(func(_, v any) bool {
 		if c, ok := v.(*Client); ok {
-			out = append(out, c.Name)
+			out = append(out, c)
 		}
 		return true
 	})
@@ -190,7 +242,7 @@ func (h *Hub) SendToUser(userName string, msg string) bool {
 		if !ok {
 			return true
 		}
-		if c.Name == userName {
+		if c.Name() == userName {
 			c.Send(msg)
 			found = true
 			// 不 break，避免同名并发连接时发送给多个；如需只发一个可返回 false 以中止
@@ -199,3 +251,71 @@ func (h *Hub) SendToUser(userName string, msg string) bool {
 	})
 	return found
 }
+
+// SendToAllLocalized 按每个客户端的语言渲染文案后广播
+func (h *Hub) SendToAllLocalized(key string, args ...any) {
+	h.clients.Range(func(_, v any) bool {
+		if c, ok := v.(*Client); ok {
+			c.SendLocalized(key, args...)
+		}
+		return true
+	})
+}
+
+// SendToUserLocalized 按目标客户端的语言渲染文案后点对点发送，返回是否找到目标
+func (h *Hub) SendToUserLocalized(userName string, key string, args ...any) bool {
+	found := false
+	h.clients.Range(func(_, v any) bool {
+		if c, ok := v.(*Client); ok && c.Name() == userName {
+			c.SendLocalized(key, args...)
+			found = true
+		}
+		return true
+	})
+	return found
+}
+
+// BroadcastEvent 向所有客户端投递事件：观察者已处理的客户端跳过，其余发送 render 渲染的文本
+func (h *Hub) BroadcastEvent(e Event, render func(*Client) string) {
+	h.clients.Range(func(_, v any) bool {
+		if c, ok := v.(*Client); ok && !c.observe(e) {
+			c.Send(render(c))
+		}
+		return true
+	})
+}
+
+// NotifyObservers 只向观察者投递事件（如推送结构化的状态变化），没有观察者或观察者未处理的客户端不发送文本
+func (h *Hub) NotifyObservers(e Event) {
+	h.clients.Range(func(_, v any) bool {
+		if c, ok := v.(*Client); ok {
+			c.observe(e)
+		}
+		return true
+	})
+}
+
+// NotifyUser 只向指定用户的观察者投递事件，规则同 NotifyObservers
+func (h *Hub) NotifyUser(userName string, e Event) {
+	h.clients.Range(func(_, v any) bool {
+		if c, ok := v.(*Client); ok && c.Name() == userName {
+			c.observe(e)
+		}
+		return true
+	})
+}
+
+// DeliverEvent 向指定用户投递事件，规则同 BroadcastEvent，返回是否找到目标
+func (h *Hub) DeliverEvent(userName string, e Event, render func(*Client) string) bool {
+	found := false
+	h.clients.Range(func(_, v any) bool {
+		if c, ok := v.(*Client); ok && c.Name() == userName {
+			if !c.observe(e) {
+				c.Send(render(c))
+			}
+			found = true
+		}
+		return true
+	})
+	return found
+}
@@ -8,7 +8,7 @@ import (
 func TestHubRegisterUnregister(t *testing.T) {
 	hub := NewHub()
 	c := NewClientWithBuffer("id1", 8)
-	c.Name = "alice"
+	c.SetName("alice")
 	hub.RegisterClient(c)
 
 	names := hub.ListNames()
@@ -46,7 +46,8 @@ func TestSendToAll(t *testing.T) {
 	hub := NewHub()
 	a := NewClientWithBuffer("a", 8)
 	b := NewClientWithBuffer("b", 8)
-	a.Name, b.Name = "alice", "bob"
+	a.SetName("alice")
+	b.SetName("bob")
 	hub.RegisterClient(a)
 	hub.RegisterClient(b)
 
@@ -57,7 +58,7 @@ func TestSendToAll(t *testing.T) {
 		case s := <-c.Outgoing():
 			return s
 		case <-time.After(2 * time.Second):
-			t.Fatalf("timeout waiting message for %s", c.Name)
+			t.Fatalf("timeout waiting message for %s", c.Name())
 			return ""
 		}
 	}
@@ -67,3 +68,41 @@ func TestSendToAll(t *testing.T) {
 		t.Fatalf("empty messages")
 	}
 }
+
+func TestBroadcastEventObserver(t *testing.T) {
+	hub := NewHub()
+	a := NewClientWithBuffer("a", 8)
+	b := NewClientWithBuffer("b", 8)
+	a.SetName("alice")
+	b.SetName("bob")
+	var observed []Event
+	b.Observer = func(e Event) bool {
+		observed = append(observed, e)
+		return true
+	}
+	hub.RegisterClient(a)
+	hub.RegisterClient(b)
+
+	me := &MessageEvent{When: time.Now(), From: "carol", Content: "hi", Local: true}
+	hub.BroadcastEvent(me, func(*Client) string { return "carol: hi" })
+	if !hub.DeliverEvent("bob", &DirectMessageEvent{From: "carol", To: []string{"bob"}}, func(*Client) string { return "dm" }) {
+		t.Fatal("bob should be found")
+	}
+
+	select {
+	case s := <-a.Outgoing():
+		if s != "carol: hi" {
+			t.Errorf("alice got %q", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("alice should receive rendered text")
+	}
+	select {
+	case s := <-b.Outgoing():
+		t.Errorf("observed client must not receive text, got %q", s)
+	default:
+	}
+	if len(observed) != 2 || observed[0] != me {
+		t.Errorf("observed = %v", observed)
+	}
+}
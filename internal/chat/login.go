@@ -0,0 +1,123 @@
+package chat
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/observe"
+)
+
+// 登录的错误
+var (
+	ErrNameInUse    = errors.New("nickname is already in use")
+	ErrNameReserved = errors.New("nickname is reserved for an authenticated user")
+)
+
+// loginStore 登录相关的服务端配置与已认证身份
+type loginStore struct {
+	mu         sync.RWMutex
+	moderators map[string]bool // 拥有管理员角色的已认证身份
+	reserved   map[string]bool // 已认证身份占用的昵称，未认证的会话不能使用
+}
+
+// SetModerators 设置拥有管理员角色的已认证身份（客户端证书、令牌或 Unix 对端用户）。
+// 只影响之后的登录；未认证的会话即使昵称相同也不会获得管理员角色
+func (h *Hub) SetModerators(identities []string) {
+	m := make(map[string]bool, len(identities))
+	for _, id := range identities {
+		if id != "" {
+			m[id] = true
+		}
+	}
+	h.login.mu.Lock()
+	defer h.login.mu.Unlock()
+	h.login.moderators = m
+}
+
+// ReserveNames 为已认证身份保留昵称（如令牌绑定的身份），未认证的会话不能使用；这些身份登录前即生效
+func (h *Hub) ReserveNames(identities []string) {
+	h.login.mu.Lock()
+	defer h.login.mu.Unlock()
+	for _, id := range identities {
+		if id != "" {
+			h.login.reserved[id] = true
+		}
+	}
+}
+
+// Login 以 name 登录：未注册的客户端设置昵称并注册到 Hub，已注册的客户端改名。
+// 昵称是消息作者、私信与回执的身份：
+//   - 未认证的会话不能使用已认证身份的昵称（ErrNameReserved），也不能使用本节点的连接或其它节点在线用户的昵称（ErrNameInUse）；
+//   - authenticated 表示 name 是传输层认证的身份：首次登录后该昵称为此身份保留，同一身份可以有多个连接，
+//     之前以该昵称登录的未认证会话被断开；按 SetModerators 授予管理员角色。
+//
+// 检查与注册在 presence.mu 下完成，并发登录同一昵称只有一个未认证会话成功
+func (h *Hub) Login(c *Client, name string, authenticated bool) error {
+	impostors, err := h.loginLocked(c, name, authenticated)
+	if err != nil {
+		return err
+	}
+	for _, o := range impostors {
+		h.UnregisterClient(o)
+	}
+	return nil
+}
+
+// loginLocked 在 presence.mu 下检查并登录，返回需要断开的未认证同名会话
+func (h *Hub) loginLocked(c *Client, name string, authenticated bool) ([]*Client, error) {
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	_, registered := h.clients.Load(c.ID)
+	if registered && c.Name() == name {
+		return nil, nil
+	}
+	h.login.mu.Lock()
+	switch {
+	case authenticated:
+		h.login.reserved[name] = true
+	case h.login.reserved[name]:
+		h.login.mu.Unlock()
+		return nil, ErrNameReserved
+	case h.nameInUseLocked(name):
+		h.login.mu.Unlock()
+		return nil, ErrNameInUse
+	}
+	c.moderator.Store(authenticated && h.login.moderators[name])
+	h.login.mu.Unlock()
+	c.authenticated.Store(authenticated)
+
+	var impostors []*Client
+	if authenticated {
+		h.clients.Range(func(_, v any) bool {
+			if o, ok := v.(*Client); ok && o.Name() == name && !o.Authenticated() {
+				impostors = append(impostors, o)
+			}
+			return true
+		})
+	}
+	if registered {
+		h.renameLocked(c, name)
+	} else {
+		c.SetName(name)
+		h.registerLocked(c)
+	}
+	return impostors, nil
+}
+
+// nameInUseLocked 本节点有昵称为 name 的连接，或其它节点同步的状态显示该用户在线。调用方持有 presence.mu
+func (h *Hub) nameInUseLocked(name string) bool {
+	if h.countLocal(name) > 0 {
+		return true
+	}
+	e, ok := h.presence.users[name]
+	return ok && e.remote && e.Status != StatusOffline
+}
+
+// registerLocked 注册客户端、发出 UserJoined 事件并上线。调用方持有 presence.mu
+func (h *Hub) registerLocked(c *Client) {
+	h.clients.Store(c.ID, c)
+	h.Emit(&UserEvent{When: time.Now(), User: c, Desc: "joined"})
+	h.onlineLocked(c.Name(), time.Now())
+	observe.AddOnline(1)
+}
@@ -0,0 +1,105 @@
+package chat
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLoginNameInUse 在线用户的昵称不能被另一个连接登录或改名占用，下线后可以使用
+func TestLoginNameInUse(t *testing.T) {
+	hub := NewHub()
+	alice := NewClientWithBuffer("a", 8)
+	if err := hub.Login(alice, "alice", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := hub.Login(alice, "alice", false); err != nil {
+		t.Errorf("login again with own name: %v", err)
+	}
+	mallory := NewClientWithBuffer("m", 8)
+	if err := hub.Login(mallory, "alice", false); !errors.Is(err, ErrNameInUse) {
+		t.Errorf("login as online alice: err = %v", err)
+	}
+	if err := hub.Login(mallory, "mallory", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := hub.Login(mallory, "alice", false); !errors.Is(err, ErrNameInUse) || mallory.Name() != "mallory" {
+		t.Errorf("rename to online alice: err = %v, name = %s", err, mallory.Name())
+	}
+	// 其它节点在线的用户
+	hub.ApplyRemotePresence(Presence{User: "bob", Status: StatusOnline, Since: time.Now()})
+	if err := hub.Login(mallory, "bob", false); !errors.Is(err, ErrNameInUse) {
+		t.Errorf("rename to remote bob: err = %v", err)
+	}
+
+	hub.UnregisterClient(alice)
+	if err := hub.Login(mallory, "alice", false); err != nil {
+		t.Errorf("rename after alice left: %v", err)
+	}
+}
+
+// TestLoginConcurrent 并发登录同一昵称只有一个成功
+func TestLoginConcurrent(t *testing.T) {
+	hub := NewHub()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if hub.Login(NewClientWithBuffer(string(rune('a'+i)), 8), "alice", false) == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if ok != 1 {
+		t.Errorf("%d logins succeeded, want 1", ok)
+	}
+}
+
+// TestLoginModerator 管理员角色只授予配置中的已认证身份
+func TestLoginModerator(t *testing.T) {
+	hub := NewHub()
+	hub.SetModerators([]string{"root"})
+	root := NewClientWithBuffer("r", 8)
+	if err := hub.Login(root, "root", false); err != nil {
+		t.Fatal(err)
+	}
+	if root.Moderator() {
+		t.Error("unauthenticated root got the moderator role")
+	}
+	hub.UnregisterClient(root)
+	root = NewClientWithBuffer("r2", 8)
+	if err := hub.Login(root, "root", true); err != nil {
+		t.Fatal(err)
+	}
+	if !root.Moderator() {
+		t.Error("authenticated root is not a moderator")
+	}
+}
+
+// TestLoginReserved 已认证身份的昵称不能被未认证会话使用，下线后仍然保留；同一身份可以有多个连接
+func TestLoginReserved(t *testing.T) {
+	hub := NewHub()
+	a1, a2 := NewClientWithBuffer("a1", 8), NewClientWithBuffer("a2", 8)
+	if err := hub.Login(a1, "alice", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := hub.Login(a2, "alice", true); err != nil {
+		t.Errorf("second connection of alice: %v", err)
+	}
+	mallory := NewClientWithBuffer("m", 8)
+	if err := hub.Login(mallory, "alice", false); !errors.Is(err, ErrNameReserved) {
+		t.Errorf("unauthenticated alice: err = %v", err)
+	}
+	hub.UnregisterClient(a1)
+	hub.UnregisterClient(a2)
+	if err := hub.Login(mallory, "alice", false); !errors.Is(err, ErrNameReserved) {
+		t.Errorf("unauthenticated alice after logout: err = %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import "time"
 // MessageEvent 表示一条聊天消息（包含系统 / 用户消息）
 type MessageEvent struct {
 	When    time.Time
+	ID      string // 消息 ID：发送者信封的 mid 或服务端分配；系统广播为空，不可编辑或删除
 	From    string
 	Content string
 	Local   bool // 本地生成还是远端同步
@@ -18,3 +19,8 @@ func (e *MessageEvent) Type() EventType {
 }
 
 func (e *MessageEvent) Time() time.Time { return e.When }
+
+// Text 消息的文本呈现
+func (e *MessageEvent) Text() string {
+	return "[" + e.When.Format("2006-01-02 15:04:05") + "] " + e.From + ": " + e.Content
+}
@@ -0,0 +1,249 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status 用户在线状态
+type Status string
+
+const (
+	StatusOnline    Status = "online"
+	StatusAway      Status = "away"
+	StatusBusy      Status = "busy"
+	StatusInvisible Status = "invisible" // 本人可见，他人看到的是 offline
+	StatusOffline   Status = "offline"
+)
+
+// ParseStatus 解析用户可设置的状态（online、away、busy、invisible），大小写不敏感
+func ParseStatus(s string) (Status, bool) {
+	switch st := Status(strings.ToLower(strings.TrimSpace(s))); st {
+	case StatusOnline, StatusAway, StatusBusy, StatusInvisible:
+		return st, true
+	}
+	return "", false
+}
+
+// Presence 用户在线状态快照
+type Presence struct {
+	User     string
+	Status   Status
+	Text     string    // 自定义状态文本
+	Since    time.Time // 进入当前状态的时间
+	LastSeen time.Time // 最后在线时间，仅 offline 时有值
+	Auto     bool      // 由空闲检测自动设置的 away，有活动时自动恢复 online
+}
+
+// Visible 他人看到的状态：隐身显示为 offline，最后在线时间为开始隐身的时间，不显示状态文本
+func (p Presence) Visible() Presence {
+	if p.Status != StatusInvisible {
+		return p
+	}
+	return Presence{User: p.User, Status: StatusOffline, Since: p.Since, LastSeen: p.Since}
+}
+
+// PresenceEvent 用户在线状态变化
+type PresenceEvent struct {
+	When     time.Time
+	Presence Presence
+	Remote   bool // 来自其它节点，不再向总线转发
+}
+
+func (e *PresenceEvent) Type() EventType { return EventPresence }
+func (e *PresenceEvent) Time() time.Time { return e.When }
+
+// presenceEntry Hub 内部的状态记录
+type presenceEntry struct {
+	Presence
+	chosen     Status    // 用户手动设置的状态，下线后保留，重新上线时恢复
+	lastActive time.Time // 本节点最后一次活动时间，用于空闲检测
+	remote     bool      // 状态由其它节点同步而来
+}
+
+// presenceStore 按用户名记录在线状态；离线用户保留记录以提供最后在线时间
+type presenceStore struct {
+	mu    sync.Mutex
+	users map[string]*presenceEntry
+}
+
+// countLocal 本节点上昵称为 name 的客户端数量
+func (h *Hub) countLocal(name string) int {
+	n := 0
+	h.clients.Range(func(_, v any) bool {
+		if c, ok := v.(*Client); ok && c.Name() == name {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// emitPresence 发出状态变化事件，调用方持有 presence.mu
+func (h *Hub) emitPresence(e *presenceEntry, now time.Time) {
+	h.Emit(&PresenceEvent{When: now, Presence: e.Presence})
+}
+
+// presenceOffline 客户端在本节点下线，与 Rename 互斥
+func (h *Hub) presenceOffline(c *Client) {
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	h.offlineLocked(c.Name(), time.Now())
+}
+
+// onlineLocked 用户在本节点上线：恢复上次手动设置的状态（未设置时为 online）与状态文本。调用方持有 presence.mu
+func (h *Hub) onlineLocked(name string, now time.Time) {
+	e, ok := h.presence.users[name]
+	if !ok {
+		e = &presenceEntry{Presence: Presence{User: name}}
+		h.presence.users[name] = e
+	}
+	e.lastActive = now
+	if ok && !e.remote && e.Status != StatusOffline {
+		// 同名的另一个连接，状态不变
+		return
+	}
+	e.remote = false
+	e.Status, e.Auto = StatusOnline, false
+	if e.chosen != "" {
+		e.Status = e.chosen
+	}
+	e.Since, e.LastSeen = now, time.Time{}
+	h.emitPresence(e, now)
+}
+
+// offlineLocked 用户在本节点的最后一个连接断开后记为 offline 并记录最后在线时间；自定义文本保留到下次上线。
+// 调用方持有 presence.mu
+func (h *Hub) offlineLocked(name string, now time.Time) {
+	e, ok := h.presence.users[name]
+	if !ok || e.remote || e.Status == StatusOffline || h.countLocal(name) > 0 {
+		return
+	}
+	e.Status, e.Auto = StatusOffline, false
+	e.Since, e.LastSeen = now, now
+	h.emitPresence(e, now)
+}
+
+// Rename 修改客户端昵称并同步在线状态：旧昵称没有其它连接时记为离线，新昵称上线。
+// 整个过程持有 presence.mu，与上线、下线互斥，避免按旧昵称或新昵称统计连接数时看到中间状态。
+// 不检查新昵称是否已被使用；网关改名使用 Login
+func (h *Hub) Rename(c *Client, name string) {
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	h.renameLocked(c, name)
+}
+
+// renameLocked 修改昵称并同步在线状态。调用方持有 presence.mu
+func (h *Hub) renameLocked(c *Client, name string) {
+	old := c.Name()
+	if old == name {
+		return
+	}
+	c.SetName(name)
+	if _, ok := h.clients.Load(c.ID); !ok {
+		return
+	}
+	now := time.Now()
+	h.offlineLocked(old, now)
+	h.onlineLocked(name, now)
+}
+
+// Touch 记录客户端的一次活动；自动设置的 away 恢复为 online
+func (h *Hub) Touch(c *Client) {
+	now := time.Now()
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	e, ok := h.presence.users[c.Name()]
+	if !ok || e.remote {
+		return
+	}
+	e.lastActive = now
+	if e.Auto {
+		e.Status, e.Auto, e.Since = StatusOnline, false, now
+		h.emitPresence(e, now)
+	}
+}
+
+// SetPresence 设置在线用户的状态与自定义文本，返回 false 表示用户不在本节点在线
+func (h *Hub) SetPresence(name string, status Status, text string) bool {
+	now := time.Now()
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	e, ok := h.presence.users[name]
+	if !ok || e.remote || e.Status == StatusOffline {
+		return false
+	}
+	if e.Status != status {
+		e.Since = now
+	}
+	e.Status, e.Text, e.Auto, e.chosen = status, text, false, status
+	e.lastActive = now
+	h.emitPresence(e, now)
+	return true
+}
+
+// PresenceOf 查询用户的真实状态（隐身不做转换，展示给他人前应调用 Visible），未知用户返回 false
+func (h *Hub) PresenceOf(name string) (Presence, bool) {
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	if e, ok := h.presence.users[name]; ok {
+		return e.Presence, true
+	}
+	return Presence{}, false
+}
+
+// ApplyRemotePresence 应用其它节点同步的状态，p.Since 为状态变化的时间。用户在本节点在线时以本节点为准，忽略远端状态；
+// 事件异步发布，早于已同步状态的变化视为乱序到达而忽略。保留用户在本节点手动设置的状态，之后在本节点上线时恢复
+func (h *Hub) ApplyRemotePresence(p Presence) {
+	if p.User == "" {
+		return
+	}
+	now := time.Now()
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	if h.countLocal(p.User) > 0 {
+		return
+	}
+	e, ok := h.presence.users[p.User]
+	if !ok {
+		e = &presenceEntry{}
+		h.presence.users[p.User] = e
+	} else if e.remote && p.Since.Before(e.Since) {
+		return
+	}
+	e.Presence, e.remote = p, true
+	h.Emit(&PresenceEvent{When: now, Presence: p, Remote: true})
+}
+
+// CheckIdle 将超过 idle 没有活动的 online 用户自动设为 away
+func (h *Hub) CheckIdle(idle time.Duration) {
+	now := time.Now()
+	h.presence.mu.Lock()
+	defer h.presence.mu.Unlock()
+	for _, e := range h.presence.users {
+		if e.remote || e.Status != StatusOnline || now.Sub(e.lastActive) < idle {
+			continue
+		}
+		e.Status, e.Auto, e.Since = StatusAway, true, now
+		h.emitPresence(e, now)
+	}
+}
+
+// RunPresence 按 idle 的一部分为周期执行空闲检测，直到 ctx 结束；idle <= 0 时直接返回
+func (h *Hub) RunPresence(ctx context.Context, idle time.Duration) {
+	if idle <= 0 {
+		return
+	}
+	ticker := time.NewTicker(max(idle/10, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.CheckIdle(idle)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+// waitPresence 等待下一条状态变化事件
+func waitPresence(t *testing.T, ch <-chan *PresenceEvent) *PresenceEvent {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for presence event")
+		return nil
+	}
+}
+
+func subscribePresence(hub *Hub) <-chan *PresenceEvent {
+	ch := make(chan *PresenceEvent, 16)
+	hub.Subscribe(EventPresence, func(e Event) { ch <- e.(*PresenceEvent) })
+	return ch
+}
+
+func TestPresenceOnlineOffline(t *testing.T) {
+	hub := NewHub()
+	events := subscribePresence(hub)
+
+	a1 := NewClientWithBuffer("a1", 8)
+	a1.SetName("alice")
+	hub.RegisterClient(a1)
+	if e := waitPresence(t, events); e.Presence.User != "alice" || e.Presence.Status != StatusOnline {
+		t.Fatalf("register: got %+v", e.Presence)
+	}
+	if !hub.SetPresence("alice", StatusBusy, "meeting") {
+		t.Fatal("set presence of online user failed")
+	}
+	waitPresence(t, events)
+
+	// 同名的第二个连接不改变状态，第一个断开后仍在线
+	a2 := NewClientWithBuffer("a2", 8)
+	a2.SetName("alice")
+	hub.RegisterClient(a2)
+	hub.UnregisterClient(a1)
+	if p, _ := hub.PresenceOf("alice"); p.Status != StatusBusy || p.Text != "meeting" {
+		t.Fatalf("with another connection: got %+v", p)
+	}
+
+	before := time.Now()
+	hub.UnregisterClient(a2)
+	e := waitPresence(t, events)
+	if e.Presence.Status != StatusOffline || e.Presence.LastSeen.Before(before) {
+		t.Fatalf("last connection closed: got %+v", e.Presence)
+	}
+	if hub.SetPresence("alice", StatusAway, "") {
+		t.Error("set presence of offline user must fail")
+	}
+
+	// 重新上线恢复 online 之外的自定义状态
+	a3 := NewClientWithBuffer("a3", 8)
+	a3.SetName("alice")
+	hub.RegisterClient(a3)
+	if e := waitPresence(t, events); e.Presence.Status != StatusBusy || !e.Presence.LastSeen.IsZero() {
+		t.Fatalf("back online: got %+v", e.Presence)
+	}
+}
+
+func TestPresenceAutoAway(t *testing.T) {
+	hub := NewHub()
+	events := subscribePresence(hub)
+	c := NewClientWithBuffer("c", 8)
+	c.SetName("bob")
+	hub.RegisterClient(c)
+	waitPresence(t, events)
+
+	hub.CheckIdle(time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	hub.CheckIdle(10 * time.Millisecond)
+	if e := waitPresence(t, events); e.Presence.Status != StatusAway || !e.Presence.Auto {
+		t.Fatalf("idle: got %+v", e.Presence)
+	}
+	hub.Touch(c)
+	if e := waitPresence(t, events); e.Presence.Status != StatusOnline {
+		t.Fatalf("activity after auto away: got %+v", e.Presence)
+	}
+
+	// 手动设置的 away 不因活动恢复，也不被空闲检测覆盖
+	hub.SetPresence("bob", StatusAway, "brb")
+	waitPresence(t, events)
+	hub.Touch(c)
+	hub.CheckIdle(0)
+	if p, _ := hub.PresenceOf("bob"); p.Status != StatusAway || p.Auto || p.Text != "brb" {
+		t.Fatalf("manual away: got %+v", p)
+	}
+}
+
+func TestPresenceInvisible(t *testing.T) {
+	hub := NewHub()
+	c := NewClientWithBuffer("c", 8)
+	c.SetName("carol")
+	hub.RegisterClient(c)
+	hub.SetPresence("carol", StatusInvisible, "hidden")
+
+	p, _ := hub.PresenceOf("carol")
+	if p.Status != StatusInvisible {
+		t.Fatalf("own status = %s", p.Status)
+	}
+	v := p.Visible()
+	if v.Status != StatusOffline || v.Text != "" || v.LastSeen.IsZero() {
+		t.Errorf("visible to others = %+v", v)
+	}
+	if names := hub.ListNames(); len(names) != 0 {
+		t.Errorf("invisible user listed: %v", names)
+	}
+}
+
+func TestPresenceRemote(t *testing.T) {
+	hub := NewHub()
+	events := subscribePresence(hub)
+	seen := time.Now().Add(-time.Minute)
+	hub.ApplyRemotePresence(Presence{User: "dave", Status: StatusOffline, LastSeen: seen})
+	e := waitPresence(t, events)
+	if !e.Remote || e.Presence.Status != StatusOffline || !e.Presence.LastSeen.Equal(seen) {
+		t.Fatalf("remote: got %+v", e)
+	}
+	if hub.SetPresence("dave", StatusBusy, "") {
+		t.Error("remote user status must not be set locally")
+	}
+
+	// 本节点在线时以本节点为准
+	c := NewClientWithBuffer("d", 8)
+	c.SetName("dave")
+	hub.RegisterClient(c)
+	waitPresence(t, events)
+	hub.ApplyRemotePresence(Presence{User: "dave", Status: StatusOffline, LastSeen: seen})
+	if p, _ := hub.PresenceOf("dave"); p.Status != StatusOnline {
+		t.Errorf("local user overridden by remote: %+v", p)
+	}
+}
+
+func TestPresenceRename(t *testing.T) {
+	hub := NewHub()
+	c := NewClientWithBuffer("c", 8)
+	c.SetName("erin")
+	hub.RegisterClient(c)
+	hub.Rename(c, "erin2")
+	if p, _ := hub.PresenceOf("erin"); p.Status != StatusOffline {
+		t.Errorf("old name = %s, want offline", p.Status)
+	}
+	if p, _ := hub.PresenceOf("erin2"); p.Status != StatusOnline {
+		t.Errorf("new name = %s, want online", p.Status)
+	}
+
+	// 改名与同名连接的下线并发：改名完成后新昵称仍有连接，应保持在线
+	for i := 0; i < 50; i++ {
+		a, b := NewClientWithBuffer("a", 8), NewClientWithBuffer("b", 8)
+		a.SetName("frank")
+		b.SetName("gina")
+		hub.RegisterClient(a)
+		hub.RegisterClient(b)
+		done := make(chan struct{})
+		go func() {
+			hub.Rename(a, "gina")
+			close(done)
+		}()
+		hub.UnregisterClient(b)
+		<-done
+		if p, _ := hub.PresenceOf("gina"); p.Status != StatusOnline {
+			t.Fatalf("round %d: gina = %s, want online", i, p.Status)
+		}
+		hub.UnregisterClient(a)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	if s, ok := ParseStatus(" Busy "); !ok || s != StatusBusy {
+		t.Errorf("ParseStatus(Busy) = %q, %v", s, ok)
+	}
+	for _, bad := range []string{"offline", "", "sleeping"} {
+		if _, ok := ParseStatus(bad); ok {
+			t.Errorf("ParseStatus(%q) accepted", bad)
+		}
+	}
+}
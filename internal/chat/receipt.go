@@ -0,0 +1,264 @@
+package chat
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 发送私信的错误
+var (
+	ErrNoRecipients = errors.New("no recipients")
+	ErrDuplicateID  = errors.New("message id is already used by another sender")
+)
+
+// DeliveryState 私信投递状态，只会前进：sent -> delivered -> read
+type DeliveryState string
+
+const (
+	DeliverySent      DeliveryState = "sent"      // 服务端已接收
+	DeliveryDelivered DeliveryState = "delivered" // 已投递到接收者的会话
+	DeliveryRead      DeliveryState = "read"      // 接收者已读
+)
+
+// rank 状态先后顺序，未知状态为 0
+func (s DeliveryState) rank() int {
+	switch s {
+	case DeliverySent:
+		return 1
+	case DeliveryDelivered:
+		return 2
+	case DeliveryRead:
+		return 3
+	}
+	return 0
+}
+
+// maxDirectRecords 每对发送者/接收者保留的私信记录数，超出时丢弃最早的记录
+const maxDirectRecords = 1000
+
+// DirectRecord 私信及其投递状态，群组私信每个接收者各有一条记录
+type DirectRecord struct {
+	ID           string
+	Conversation string
+	From         string
+	To           string
+	Content      string
+	When         time.Time
+	State        DeliveryState
+	Updated      time.Time // 最近一次状态变化的时间
+	changed      time.Time // 最近一次编辑或删除的时间，较早的修改不再覆盖内容
+}
+
+// ReceiptEvent 私信投递状态变化，推送给私信的发送者。
+// 已读回执是累计的：ID 及之前 From 发给 User 的私信均已读
+type ReceiptEvent struct {
+	When   time.Time
+	ID     string // 私信 ID
+	From   string // 私信发送者，即回执的接收者
+	User   string // 私信接收者
+	State  DeliveryState
+	Remote bool // 来自其它节点，不再向总线转发
+}
+
+func (e *ReceiptEvent) Type() EventType { return EventReceipt }
+func (e *ReceiptEvent) Time() time.Time { return e.When }
+
+type directKey struct{ id, to string }
+
+// conversationKey 同一会话中一个发送者发给一个接收者的私信序列，已读标记在其中累计
+type conversationKey struct{ conv, from, to string }
+
+// directStore 私信记录：按 (ID, 接收者) 索引，并按会话、发送者、接收者保留发送顺序以支持累计已读
+type directStore struct {
+	mu    sync.Mutex
+	byKey map[directKey]*DirectRecord
+	convs map[conversationKey][]*DirectRecord
+}
+
+// add 为每个接收者记录一条私信，返回各接收者的当前状态。任一接收者已有同 ID 的记录时不覆盖：
+// 同一发送者视为重复（客户端重试），返回 false；其他发送者的记录返回 ErrDuplicateID
+func (s *directStore) add(m *DirectMessageEvent) ([]DeliveryState, bool, error) {
+	states := make([]DeliveryState, len(m.To))
+	dup := false
+	for i, to := range m.To {
+		states[i] = DeliverySent
+		if old, ok := s.byKey[directKey{m.ID, to}]; ok {
+			if old.From != m.From {
+				return nil, false, ErrDuplicateID
+			}
+			states[i], dup = old.State, true
+		}
+	}
+	if dup {
+		return states, false, nil
+	}
+	for _, to := range m.To {
+		r := &DirectRecord{ID: m.ID, Conversation: m.Conversation, From: m.From, To: to, Content: m.Content, When: m.When, State: DeliverySent, Updated: m.When}
+		s.byKey[directKey{m.ID, to}] = r
+		ck := conversationKey{m.Conversation, m.From, to}
+		conv := append(s.convs[ck], r)
+		if len(conv) > maxDirectRecords {
+			for _, old := range conv[:len(conv)-maxDirectRecords] {
+				delete(s.byKey, directKey{old.ID, old.To})
+			}
+			conv = append([]*DirectRecord(nil), conv[len(conv)-maxDirectRecords:]...)
+		}
+		s.convs[ck] = conv
+	}
+	return states, true, nil
+}
+
+// setContent 私信被编辑或删除后同步各接收者记录中的内容。在 messages.mu 之外调用，
+// 并发的修改可能乱序到达，早于记录中最近一次修改的忽略
+func (s *directStore) setContent(e *MessageEditEvent) {
+	if len(e.To) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, to := range e.To {
+		if r, ok := s.byKey[directKey{e.ID, to}]; ok && r.From == e.From && !e.When.Before(r.changed) {
+			r.Content, r.changed = e.Content, e.When
+		}
+	}
+}
+
+// newMessageID 为客户端没有指定 mid 的消息分配 ID
+func newMessageID() string {
+	return uuid.New().String()
+}
+
+// record 私信对应的可编辑消息记录
+func (m *DirectMessageEvent) record() *MessageRecord {
+	return &MessageRecord{ID: m.ID, Conversation: m.Conversation, From: m.From, To: m.To, Content: m.Content, When: m.When}
+}
+
+// addDirect 记录私信，返回各接收者的当前状态与是否为新消息。ID 在聊天室消息与私信间全局唯一，
+// 已被其他发送者或其它会话的消息使用时返回 ErrDuplicateID
+func (h *Hub) addDirect(m *DirectMessageEvent) ([]DeliveryState, bool, error) {
+	if _, err := h.messages.add(m.record()); err != nil {
+		return nil, false, err
+	}
+	h.direct.mu.Lock()
+	defer h.direct.mu.Unlock()
+	return h.direct.add(m)
+}
+
+// SendDirect 向一个或多个接收者发送私信，接收者多于一个时为群组私信，会话 ID 由参与者集合决定。
+// id 为空时由服务端分配，返回私信的 ID。私信按接收者分别记录并跟踪投递状态，向发送者回执每个接收者的 sent；
+// 同一发送者以同一 id 重复发送（客户端重试）时不再投递，只向发送者回执当前状态；
+// id 已被其他发送者使用时返回 ErrDuplicateID
+func (h *Hub) SendDirect(id, from string, to []string, content string) (string, error) {
+	to = Recipients(to)
+	if len(to) == 0 {
+		return "", ErrNoRecipients
+	}
+	if id == "" {
+		id = newMessageID()
+	}
+	now := time.Now()
+	m := &DirectMessageEvent{When: now, ID: id, Conversation: ConversationID(from, to), From: from, To: to, Content: content}
+	states, added, err := h.addDirect(m)
+	if err != nil {
+		return "", err
+	}
+	for i, user := range to {
+		h.Emit(&ReceiptEvent{When: now, ID: id, From: from, User: user, State: states[i]})
+	}
+	if added {
+		h.Emit(m)
+	}
+	return id, nil
+}
+
+// ReceiveRemoteDirect 投递其它节点转发的私信；同一条私信重复到达或 ID 与本节点其他发送者的消息冲突时忽略。
+// 旧版节点转发的私信可能没有 ID，此时在本节点分配
+func (h *Hub) ReceiveRemoteDirect(id, from string, to []string, content string, when time.Time) {
+	to = Recipients(to)
+	if len(to) == 0 {
+		return
+	}
+	if id == "" {
+		id = newMessageID()
+	}
+	m := &DirectMessageEvent{When: when, ID: id, Conversation: ConversationID(from, to), From: from, To: to, Content: content, Remote: true}
+	if _, added, err := h.addDirect(m); err != nil || !added {
+		return
+	}
+	h.Emit(m)
+}
+
+// MarkDelivered 私信已投递到接收者 to 的会话，向发送者回执 delivered
+func (h *Hub) MarkDelivered(id, to string) {
+	now := time.Now()
+	h.direct.mu.Lock()
+	defer h.direct.mu.Unlock()
+	r, ok := h.direct.byKey[directKey{id, to}]
+	if !ok || r.State.rank() >= DeliveryDelivered.rank() {
+		return
+	}
+	r.State, r.Updated = DeliveryDelivered, now
+	h.Emit(&ReceiptEvent{When: now, ID: id, From: r.From, User: to, State: DeliveryDelivered})
+}
+
+// MarkRead reader 标记 peer 发来的私信已读到 upTo（含）为止，向 peer 发出一条累计的已读回执。
+// 已读只在 upTo 所属的会话中累计，不影响一对一与群组私信中的其它会话。
+// upTo 不是 peer 发给 reader 的私信时返回 false
+func (h *Hub) MarkRead(reader, peer, upTo string) bool {
+	return h.markRead(reader, peer, upTo, false)
+}
+
+// ApplyRemoteReceipt 应用其它节点同步的回执（本节点发出的私信在其它节点送达或已读），
+// 只处理本节点有记录的私信，状态不会回退
+func (h *Hub) ApplyRemoteReceipt(id, from, user string, state DeliveryState) {
+	if state == DeliveryRead {
+		h.markRead(user, from, id, true)
+		return
+	}
+	now := time.Now()
+	h.direct.mu.Lock()
+	defer h.direct.mu.Unlock()
+	r, ok := h.direct.byKey[directKey{id, user}]
+	if !ok || r.From != from || r.State.rank() >= state.rank() {
+		return
+	}
+	r.State, r.Updated = state, now
+	h.Emit(&ReceiptEvent{When: now, ID: id, From: from, User: user, State: state, Remote: true})
+}
+
+func (h *Hub) markRead(reader, peer, upTo string, remote bool) bool {
+	now := time.Now()
+	h.direct.mu.Lock()
+	defer h.direct.mu.Unlock()
+	target, ok := h.direct.byKey[directKey{upTo, reader}]
+	if !ok || target.From != peer {
+		return false
+	}
+	changed := false
+	for _, r := range h.direct.convs[conversationKey{target.Conversation, peer, reader}] {
+		if r.State != DeliveryRead {
+			r.State, r.Updated = DeliveryRead, now
+			changed = true
+		}
+		if r == target {
+			break
+		}
+	}
+	if changed {
+		h.Emit(&ReceiptEvent{When: now, ID: upTo, From: peer, User: reader, State: DeliveryRead, Remote: remote})
+	}
+	return true
+}
+
+// DirectRecordOf 查询发给 to 的私信 id 的记录
+func (h *Hub) DirectRecordOf(id, to string) (DirectRecord, bool) {
+	h.direct.mu.Lock()
+	defer h.direct.mu.Unlock()
+	if r, ok := h.direct.byKey[directKey{id, to}]; ok {
+		return *r, true
+	}
+	return DirectRecord{}, false
+}
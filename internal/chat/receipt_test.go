@@ -0,0 +1,204 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDirectReceipts(t *testing.T) {
+	hub := NewHub()
+	receipts := make(chan *ReceiptEvent, 16)
+	hub.Subscribe(EventReceipt, func(e Event) { receipts <- e.(*ReceiptEvent) })
+	next := func() *ReceiptEvent {
+		t.Helper()
+		select {
+		case e := <-receipts:
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for receipt")
+			return nil
+		}
+	}
+
+	hub.SendDirect("m1", "alice", []string{"bob"}, "one")
+	if e := next(); e.ID != "m1" || e.From != "alice" || e.User != "bob" || e.State != DeliverySent {
+		t.Fatalf("send: got %+v", e)
+	}
+	hub.SendDirect("m2", "alice", []string{"bob"}, "two")
+	next()
+	hub.SendDirect("m3", "alice", []string{"bob"}, "three")
+	next()
+
+	hub.MarkDelivered("m1", "bob")
+	if e := next(); e.ID != "m1" || e.State != DeliveryDelivered {
+		t.Fatalf("delivered: got %+v", e)
+	}
+
+	// 已读是累计的：m1、m2 记为已读，只发一条回执
+	if !hub.MarkRead("bob", "alice", "m2") {
+		t.Fatal("mark read failed")
+	}
+	if e := next(); e.ID != "m2" || e.State != DeliveryRead || e.User != "bob" {
+		t.Fatalf("read: got %+v", e)
+	}
+	for id, want := range map[string]DeliveryState{"m1": DeliveryRead, "m2": DeliveryRead, "m3": DeliverySent} {
+		if r, _ := hub.DirectRecordOf(id, "bob"); r.State != want {
+			t.Errorf("%s state = %s, want %s", id, r.State, want)
+		}
+	}
+
+	// 状态不回退，重复已读不再回执
+	hub.MarkDelivered("m1", "bob")
+	hub.MarkRead("bob", "alice", "m1")
+	if hub.MarkRead("alice", "bob", "m2") {
+		t.Error("reader must be the recipient")
+	}
+	if hub.MarkRead("bob", "alice", "nope") {
+		t.Error("unknown message marked read")
+	}
+
+	// 重试同一 id 只回执当前状态
+	hub.SendDirect("m1", "alice", []string{"bob"}, "one")
+	if e := next(); e.ID != "m1" || e.State != DeliveryRead {
+		t.Fatalf("retry: got %+v", e)
+	}
+	select {
+	case e := <-receipts:
+		t.Fatalf("unexpected receipt %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestDirectMessageID 私信 ID 属于发送者：他人复用同一 mid 被拒绝，不影响原私信；mid 为空时由服务端分配
+func TestDirectMessageID(t *testing.T) {
+	hub := NewHub()
+	receipts := make(chan *ReceiptEvent, 16)
+	hub.Subscribe(EventReceipt, func(e Event) { receipts <- e.(*ReceiptEvent) })
+
+	if id, err := hub.SendDirect("m1", "alice", []string{"bob"}, "hi"); err != nil || id != "m1" {
+		t.Fatalf("send = %q, %v", id, err)
+	}
+	<-receipts
+	if _, err := hub.SendDirect("m1", "carol", []string{"bob"}, "spoof"); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("reused mid: err = %v", err)
+	}
+	if _, err := hub.SendDirect("m1", "alice", []string{"carol"}, "other conversation"); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("mid reused in another conversation: err = %v", err)
+	}
+	if r, _ := hub.DirectRecordOf("m1", "bob"); r.From != "alice" || r.Content != "hi" {
+		t.Errorf("record = %+v", r)
+	}
+	if _, err := hub.SendDirect("m2", "alice", []string{" "}, "nobody"); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("no recipients: err = %v", err)
+	}
+
+	id, err := hub.SendDirect("", "alice", []string{"bob"}, "untagged")
+	if err != nil || id == "" {
+		t.Fatalf("send without mid = %q, %v", id, err)
+	}
+	select {
+	case e := <-receipts:
+		if e.ID != id || e.State != DeliverySent {
+			t.Errorf("sent receipt = %+v, want id %q", e, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receipt")
+	}
+	if r, ok := hub.DirectRecordOf(id, "bob"); !ok || r.Content != "untagged" {
+		t.Errorf("record = %+v, %v", r, ok)
+	}
+}
+
+func TestRemoteDirectDedup(t *testing.T) {
+	hub := NewHub()
+	directs := make(chan *DirectMessageEvent, 4)
+	hub.Subscribe(EventMessageDirect, func(e Event) { directs <- e.(*DirectMessageEvent) })
+
+	hub.SendDirect("m1", "alice", []string{"bob"}, "hi")
+	<-directs
+	// 本节点发布的私信经总线回到本节点时不重复投递
+	hub.ReceiveRemoteDirect("m1", "alice", []string{"bob"}, "hi", time.Now())
+	hub.ReceiveRemoteDirect("r1", "carol", []string{"bob"}, "yo", time.Now())
+	select {
+	case e := <-directs:
+		if e.ID != "r1" || !e.Remote {
+			t.Fatalf("got %+v, want remote r1", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case e := <-directs:
+		t.Fatalf("duplicate delivery %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 其它节点的已读回执更新本节点记录
+	receipts := make(chan *ReceiptEvent, 4)
+	hub.Subscribe(EventReceipt, func(e Event) { receipts <- e.(*ReceiptEvent) })
+	hub.ApplyRemoteReceipt("m1", "alice", "bob", DeliveryRead)
+	select {
+	case e := <-receipts:
+		if !e.Remote || e.State != DeliveryRead {
+			t.Fatalf("got %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestConversationID(t *testing.T) {
+	a := ConversationID("alice", []string{"bob", "carol"})
+	if b := ConversationID("carol", []string{"alice", "bob", "alice"}); a != b {
+		t.Errorf("same participants: %s != %s", a, b)
+	}
+	if b := ConversationID("alice", []string{"bob"}); a == b {
+		t.Error("different participants share a conversation")
+	}
+	if got := Recipients([]string{" bob", "", "carol", "bob"}); len(got) != 2 || got[0] != "bob" || got[1] != "carol" {
+		t.Errorf("Recipients = %v", got)
+	}
+}
+
+func TestGroupDirect(t *testing.T) {
+	hub := NewHub()
+	directs := make(chan *DirectMessageEvent, 4)
+	hub.Subscribe(EventMessageDirect, func(e Event) { directs <- e.(*DirectMessageEvent) })
+	receipts := make(chan *ReceiptEvent, 16)
+	hub.Subscribe(EventReceipt, func(e Event) { receipts <- e.(*ReceiptEvent) })
+
+	hub.SendDirect("g1", "alice", []string{"bob", "carol", "bob"}, "hi all")
+	e := <-directs
+	if len(e.To) != 2 || e.Conversation != ConversationID("alice", []string{"bob", "carol"}) {
+		t.Fatalf("group event = %+v", e)
+	}
+	users := map[string]bool{}
+	for range 2 {
+		r := <-receipts
+		users[r.User] = r.State == DeliverySent
+	}
+	if !users["bob"] || !users["carol"] {
+		t.Fatalf("sent receipts per recipient: %v", users)
+	}
+
+	// 已读按会话累计：群组中的已读不影响一对一私信
+	hub.SendDirect("d1", "alice", []string{"bob"}, "just you")
+	<-directs
+	<-receipts
+	hub.SendDirect("g2", "alice", []string{"carol", "bob"}, "again")
+	<-directs
+	<-receipts
+	<-receipts
+	if !hub.MarkRead("bob", "alice", "g2") {
+		t.Fatal("mark read failed")
+	}
+	for id, want := range map[string]DeliveryState{"g1": DeliveryRead, "g2": DeliveryRead, "d1": DeliverySent} {
+		if r, _ := hub.DirectRecordOf(id, "bob"); r.State != want {
+			t.Errorf("bob %s = %s, want %s", id, r.State, want)
+		}
+	}
+	if r, _ := hub.DirectRecordOf("g2", "carol"); r.State != DeliverySent {
+		t.Errorf("carol g2 = %s, want sent", r.State)
+	}
+}
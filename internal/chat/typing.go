@@ -0,0 +1,119 @@
+package chat
+
+import (
+	"sync"
+	"time"
+)
+
+// 正在输入提示的默认节流间隔与过期时间
+const (
+	DefaultTypingThrottle = 3 * time.Second // 持续输入时最多每隔该时长转发一次开始输入
+	DefaultTypingExpiry   = 6 * time.Second // 超过该时长没有刷新时自动视为停止输入
+)
+
+// TypingEvent 正在输入提示：只转发给在线的相关接收者，不持久化、不计入消息指标
+type TypingEvent struct {
+	When   time.Time
+	From   string
+	To     string // 私聊对象，为空表示聊天室
+	Active bool   // true 开始输入，false 停止输入
+	Remote bool   // 来自其它节点，不再向总线转发
+}
+
+func (e *TypingEvent) Type() EventType { return EventTyping }
+func (e *TypingEvent) Time() time.Time { return e.When }
+
+// typingKey 输入状态按发送者与目标区分
+type typingKey struct{ from, to string }
+
+// typingEntry 一个进行中的输入状态
+type typingEntry struct {
+	sent    time.Time   // 最近一次转发开始输入的时间
+	expires time.Time   // 过期时间，每次开始输入时延长
+	timer   *time.Timer // 到期自动停止
+}
+
+// typingStore 进行中的输入状态
+type typingStore struct {
+	mu       sync.Mutex
+	active   map[typingKey]*typingEntry
+	synced   map[typingKey]time.Time // 其它节点同步来的最近一次变化在来源节点上的时间
+	throttle time.Duration
+	expiry   time.Duration
+}
+
+// Typing 记录 from 开始或停止向 to（为空表示聊天室）输入：
+// 开始输入在节流间隔内重复到达时只延长过期时间，不重复转发；过期未刷新时自动发出停止输入；
+// 没有进行中的输入时，停止输入被忽略
+func (h *Hub) Typing(from, to string, active bool) {
+	h.typing.update(h, from, to, active, false, time.Time{})
+}
+
+// ApplyRemoteTyping 应用其它节点同步的输入状态，when 为来源节点上的变化时间，同样按过期时间自动停止。
+// 事件异步发布，同一输入的开始与停止可能乱序到达，不晚于已应用变化的同步被忽略
+func (h *Hub) ApplyRemoteTyping(from, to string, active bool, when time.Time) {
+	h.typing.update(h, from, to, active, true, when)
+}
+
+func (s *typingStore) update(h *Hub, from, to string, active, remote bool, when time.Time) {
+	if from == "" {
+		return
+	}
+	key := typingKey{from: from, to: to}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 在锁内取时间，同一输入先后发出的事件时间递增，其它节点据此排序
+	now := time.Now()
+	if remote && !s.inOrder(key, when, now) {
+		return
+	}
+	e, ok := s.active[key]
+	if !active {
+		if !ok {
+			return
+		}
+		e.timer.Stop()
+		delete(s.active, key)
+		h.Emit(&TypingEvent{When: now, From: from, To: to, Remote: remote})
+		return
+	}
+	if ok {
+		e.expires = now.Add(s.expiry)
+		e.timer.Reset(s.expiry)
+		if now.Sub(e.sent) < s.throttle {
+			return
+		}
+	} else {
+		e = &typingEntry{expires: now.Add(s.expiry)}
+		e.timer = time.AfterFunc(s.expiry, func() { s.expire(h, key, e, remote) })
+		s.active[key] = e
+	}
+	e.sent = now
+	h.Emit(&TypingEvent{When: now, From: from, To: to, Active: true, Remote: remote})
+}
+
+// inOrder 记录其它节点同步的变化时间，早于或等于已应用的变化时返回 false；
+// 超过过期时间的记录不再影响排序，一并清理。调用方持有 s.mu
+func (s *typingStore) inOrder(key typingKey, when, now time.Time) bool {
+	if last, ok := s.synced[key]; ok && !when.After(last) {
+		return false
+	}
+	for k, t := range s.synced {
+		if now.Sub(t) > s.expiry {
+			delete(s.synced, k)
+		}
+	}
+	s.synced[key] = when
+	return true
+}
+
+// expire 输入状态过期：仍是同一条记录且未被延长时删除并发出停止输入
+func (s *typingStore) expire(h *Hub, key typingKey, e *typingEntry, remote bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[key] != e || time.Now().Before(e.expires) {
+		return
+	}
+	delete(s.active, key)
+	h.Emit(&TypingEvent{When: time.Now(), From: key.from, To: key.to, Remote: remote})
+}
@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTypingThrottleAndExpiry(t *testing.T) {
+	hub := NewHub()
+	hub.typing.throttle = 50 * time.Millisecond
+	hub.typing.expiry = 100 * time.Millisecond
+	events := make(chan *TypingEvent, 16)
+	hub.Subscribe(EventTyping, func(e Event) { events <- e.(*TypingEvent) })
+	next := func() *TypingEvent {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for typing event")
+			return nil
+		}
+	}
+
+	// 节流间隔内重复的开始输入只转发一次
+	hub.Typing("alice", "", true)
+	hub.Typing("alice", "", true)
+	if e := next(); !e.Active || e.From != "alice" || e.To != "" {
+		t.Fatalf("start: got %+v", e)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("throttled start forwarded: %+v", e)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	// 不再刷新时自动停止
+	if e := next(); e.Active {
+		t.Fatalf("expiry: got %+v, want stop", e)
+	}
+
+	// 私聊输入提示与聊天室相互独立，显式停止立即转发，重复停止被忽略
+	hub.Typing("alice", "bob", true)
+	if e := next(); !e.Active || e.To != "bob" {
+		t.Fatalf("direct start: got %+v", e)
+	}
+	hub.Typing("alice", "bob", false)
+	hub.Typing("alice", "bob", false)
+	if e := next(); e.Active || e.To != "bob" {
+		t.Fatalf("direct stop: got %+v", e)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event: %+v", e)
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestRemoteTyping(t *testing.T) {
+	hub := NewHub()
+	events := make(chan *TypingEvent, 4)
+	hub.Subscribe(EventTyping, func(e Event) { events <- e.(*TypingEvent) })
+	now := time.Now()
+	hub.ApplyRemoteTyping("carol", "", true, now)
+	select {
+	case e := <-events:
+		if !e.Remote || !e.Active {
+			t.Fatalf("got %+v, want remote start", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out")
+	}
+
+	// 乱序到达：停止先于更早的开始到达，开始被忽略，不会留下一直显示的输入提示
+	hub.ApplyRemoteTyping("dave", "", false, now.Add(time.Millisecond))
+	hub.ApplyRemoteTyping("dave", "", true, now)
+	select {
+	case e := <-events:
+		t.Fatalf("stale start applied: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
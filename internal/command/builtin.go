@@ -1,27 +1,76 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/i18n"
+	"github.com/hongjun500/chat-go/internal/protocol"
 )
 
+// usageError 参数错误，附带按调用者语言渲染的命令用法
+func usageError(ctx *Context, usage string) error {
+	return protocol.NewError(protocol.CodeBadArguments, ctx.T("cmd.usage", usage))
+}
+
+// statusLabel 按调用者语言渲染在线状态，附带自定义状态文本，如 "busy (meeting)"
+func statusLabel(ctx *Context, p chat.Presence) string {
+	label := ctx.T("presence." + string(p.Status))
+	if p.Text != "" {
+		label += " (" + p.Text + ")"
+	}
+	return label
+}
+
+// MessageError 将登录以及发送、编辑与删除消息的 chat 错误转为带错误码的协议错误
+func MessageError(err error) error {
+	switch {
+	case errors.Is(err, chat.ErrMessageNotFound), errors.Is(err, chat.ErrMessageDeleted):
+		return protocol.NewError(protocol.CodeMessageNotFound, err.Error())
+	case errors.Is(err, chat.ErrNotAuthor):
+		return protocol.NewError(protocol.CodePermissionDenied, err.Error())
+	case errors.Is(err, chat.ErrEditExpired):
+		return protocol.NewError(protocol.CodeEditExpired, err.Error())
+	case errors.Is(err, chat.ErrDuplicateID):
+		return protocol.NewError(protocol.CodeDuplicateID, err.Error())
+	case errors.Is(err, chat.ErrNoRecipients):
+		return protocol.NewError(protocol.CodeBadArguments, err.Error())
+	case errors.Is(err, chat.ErrNameInUse):
+		return protocol.NewError(protocol.CodeNameInUse, err.Error())
+	case errors.Is(err, chat.ErrNameReserved):
+		return protocol.NewError(protocol.CodeNameReserved, err.Error())
+	}
+	return err
+}
+
+// messageID 解析 /edit、/delete 的消息参数：last 表示调用者在本连接上最近发送的消息
+func messageID(ctx *Context, arg string) (string, error) {
+	if arg != "last" {
+		return arg, nil
+	}
+	if id, ok := ctx.Client.LastSent(); ok {
+		return id, nil
+	}
+	return "", protocol.NewError(protocol.CodeMessageNotFound, "no message to change")
+}
+
 // RegisterBuiltins 注册内置命令
 func RegisterBuiltins(r *Registry) (err error) {
 	if err := r.Register(&Command{
 		Name: "help",
-		Help: "查看帮助",
+		Help: "cmd.help.help",
 		Handler: func(ctx *Context) error {
 			list := r.List()
 			lines := make([]string, 0, len(list))
 			for _, c := range list {
 				aliases := ""
 				if len(c.Aliases) > 0 {
-					aliases = " (别名: " + strings.Join(c.Aliases, ", ") + ")"
+					aliases = ctx.T("cmd.help.aliases", strings.Join(c.Aliases, ", "))
 				}
-				lines = append(lines, fmt.Sprintf("/%s - %s%s", c.Name, c.Help, aliases))
+				lines = append(lines, fmt.Sprintf("/%s - %s%s", c.Name, ctx.T(c.Help), aliases))
 			}
 			ctx.Client.Send(strings.Join(lines, "\n"))
 			return nil
@@ -33,9 +82,9 @@ func RegisterBuiltins(r *Registry) (err error) {
 
 	if err := r.Register(&Command{
 		Name: "quit",
-		Help: "退出聊天室",
+		Help: "cmd.quit.help",
 		Handler: func(ctx *Context) error {
-			ctx.Client.Send("再见！")
+			ctx.Client.SendLocalized("cmd.quit.bye")
 			ctx.Hub.UnregisterClient(ctx.Client)
 			return nil
 		},
@@ -45,10 +94,26 @@ func RegisterBuiltins(r *Registry) (err error) {
 	}
 	if err := r.Register(&Command{
 		Name: "who",
-		Help: "查看在线用户",
+		Help: "cmd.who.help",
 		Handler: func(ctx *Context) error {
-			names := ctx.Hub.ListNames()
-			ctx.Client.Send("在线用户：" + strings.Join(names, ","))
+			// 非 online 状态附带状态，如 bob[busy]；有心跳时延的用户附带往返时延，如 alice(12ms)。
+			// 隐身用户只对本人列出
+			clients := ctx.Hub.ListClients()
+			names := make([]string, 0, len(clients))
+			for _, c := range clients {
+				name := c.Name()
+				if p, ok := ctx.Hub.PresenceOf(c.Name()); ok && p.Status != chat.StatusOnline {
+					if p.Status == chat.StatusInvisible && c.Name() != ctx.Client.Name() {
+						continue
+					}
+					name += "[" + string(p.Status) + "]"
+				}
+				if rtt := c.RTT(); rtt > 0 {
+					name += fmt.Sprintf("(%dms)", rtt.Milliseconds())
+				}
+				names = append(names, name)
+			}
+			ctx.Client.SendLocalized("cmd.who.online", strings.Join(names, ","))
 			return nil
 		},
 		MinLevel: levelUser,
@@ -58,19 +123,19 @@ func RegisterBuiltins(r *Registry) (err error) {
 	// 登录授权：仅示例，直接设置 level。实际可接入鉴权服务
 	if err := r.Register(&Command{
 		Name: "auth",
-		Help: "授权设置: /auth <level> (0 用户, 1 管理员)",
+		Help: "cmd.auth.help",
 		Handler: func(ctx *Context) error {
 			if len(ctx.Args) != 1 {
-				return fmt.Errorf("用法: /auth <0|1>")
+				return usageError(ctx, "/auth <0|1>")
 			}
 			if ctx.Client.Meta == nil {
 				ctx.Client.Meta = map[string]string{}
 			}
 			if ctx.Args[0] != "0" && ctx.Args[0] != "1" {
-				return fmt.Errorf("非法等级: %s", ctx.Args[0])
+				return protocol.NewError(protocol.CodeBadArguments, ctx.T("cmd.auth.bad_level", ctx.Args[0]))
 			}
 			ctx.Client.Meta["level"] = ctx.Args[0]
-			ctx.Client.Send("已设置权限等级为: " + ctx.Args[0])
+			ctx.Client.SendLocalized("cmd.auth.ok", ctx.Args[0])
 			return nil
 		},
 		MinLevel: levelUser,
@@ -81,16 +146,16 @@ func RegisterBuiltins(r *Registry) (err error) {
 	// 踢人（管理员）
 	if err := r.Register(&Command{
 		Name: "kick",
-		Help: "踢人: /kick <name>",
+		Help: "cmd.kick.help",
 		Handler: func(ctx *Context) error {
 			if len(ctx.Args) != 1 {
-				return fmt.Errorf("用法: /kick <name>")
+				return usageError(ctx, "/kick <name>")
 			}
 			name := ctx.Args[0]
 			if ok := ctx.Hub.KickByName(name); !ok {
-				ctx.Client.Send("用户不在线: " + name)
+				ctx.Client.SendLocalized("cmd.kick.offline", name)
 			} else {
-				ctx.Client.Send("已踢出: " + name)
+				ctx.Client.SendLocalized("cmd.kick.ok", name)
 			}
 			return nil
 		},
@@ -102,17 +167,17 @@ func RegisterBuiltins(r *Registry) (err error) {
 	// 封禁（管理员）：/ban <name> [minutes]，默认永久
 	if err := r.Register(&Command{
 		Name: "ban",
-		Help: "封禁: /ban <name> [minutes] (默认永久)",
+		Help: "cmd.ban.help",
 		Handler: func(ctx *Context) error {
 			if len(ctx.Args) < 1 {
-				return fmt.Errorf("用法: /ban <name> [minutes]")
+				return usageError(ctx, "/ban <name> [minutes]")
 			}
 			name := ctx.Args[0]
 			var d time.Duration
 			if len(ctx.Args) >= 2 {
 				var mins int
 				if _, err := fmt.Sscan(ctx.Args[1], &mins); err != nil || mins < 0 {
-					return fmt.Errorf("minutes 非法")
+					return protocol.NewError(protocol.CodeBadArguments, ctx.T("cmd.ban.bad_minutes"))
 				}
 				d = time.Duration(mins) * time.Minute
 			} else {
@@ -120,9 +185,9 @@ func RegisterBuiltins(r *Registry) (err error) {
 			}
 			ctx.Hub.BanFor(name, d)
 			if d == 0 {
-				ctx.Client.Send("已永久封禁: " + name)
+				ctx.Client.SendLocalized("cmd.ban.forever", name)
 			} else {
-				ctx.Client.Send(fmt.Sprintf("已封禁 %s %d 分钟", name, int(d.Minutes())))
+				ctx.Client.SendLocalized("cmd.ban.minutes", name, int(d.Minutes()))
 			}
 			return nil
 		},
@@ -130,17 +195,24 @@ func RegisterBuiltins(r *Registry) (err error) {
 	}); err != nil {
 		return err
 	}
-	// 私信: /msg <to> <text>
+	// 私信: /msg <to>[,<to>...] <text>，多个接收者以逗号分隔时为群组私信
 	if err := r.Register(&Command{
 		Name: "msg",
-		Help: "私信: /msg <to> <text>",
+		Help: "cmd.msg.help",
 		Handler: func(ctx *Context) error {
 			if len(ctx.Args) < 2 {
-				return fmt.Errorf("用法: /msg <to> <text>")
+				return usageError(ctx, "/msg <to>[,<to>...] <text>")
+			}
+			to := chat.Recipients(strings.Split(ctx.Args[0], ","))
+			if len(to) == 0 || len(to) > protocol.DefaultMaxRecipients {
+				return usageError(ctx, "/msg <to>[,<to>...] <text>")
 			}
-			to := ctx.Args[0]
 			text := strings.Join(ctx.Args[1:], " ")
-			ctx.Hub.Emit(&chat.DirectMessageEvent{When: time.Now(), From: ctx.Client.Name, To: to, Content: text})
+			id, err := ctx.Hub.SendDirect(ctx.Mid, ctx.Client.Name(), to, text)
+			if err != nil {
+				return MessageError(err)
+			}
+			ctx.Client.SetLastSent(id)
 			return nil
 		},
 		MinLevel: levelUser,
@@ -150,10 +222,10 @@ func RegisterBuiltins(r *Registry) (err error) {
 	// 新增：系统通知
 	if err := r.Register(&Command{
 		Name: "notice",
-		Help: "系统通知广播: /notice <level> <text>",
+		Help: "cmd.notice.help",
 		Handler: func(ctx *Context) error {
 			if len(ctx.Args) < 2 {
-				return fmt.Errorf("用法: /notice <info|warn|error> <text>")
+				return usageError(ctx, "/notice <info|warn|error> <text>")
 			}
 			level := ctx.Args[0]
 			text := strings.Join(ctx.Args[1:], " ")
@@ -167,7 +239,7 @@ func RegisterBuiltins(r *Registry) (err error) {
 	// 新增：心跳
 	if err := r.Register(&Command{
 		Name: "ping",
-		Help: "发送心跳: /ping [detail]",
+		Help: "cmd.ping.help",
 		Handler: func(ctx *Context) error {
 			detail := strings.Join(ctx.Args, " ")
 			ctx.Hub.Emit(&chat.HeartbeatEvent{When: time.Now(), FromID: ctx.Client.ID, Detail: detail})
@@ -181,10 +253,10 @@ func RegisterBuiltins(r *Registry) (err error) {
 	// 新增：文件传输事件（元数据）
 	if err := r.Register(&Command{
 		Name: "sendfile",
-		Help: "发送文件: /sendfile <to|*> <name> <size> [mime]",
+		Help: "cmd.sendfile.help",
 		Handler: func(ctx *Context) error {
 			if len(ctx.Args) < 3 {
-				return fmt.Errorf("用法: /sendfile <to|*> <name> <size> [mime]")
+				return usageError(ctx, "/sendfile <to|*> <name> <size> [mime]")
 			}
 			to := ctx.Args[0]
 			name := ctx.Args[1]
@@ -196,16 +268,148 @@ func RegisterBuiltins(r *Registry) (err error) {
 			var size int64
 			_, err := fmt.Sscan(sizeStr, &size)
 			if err != nil {
-				return fmt.Errorf("size 不是整数: %v", err)
+				return protocol.NewError(protocol.CodeBadArguments, ctx.T("cmd.sendfile.bad_size", err))
+			}
+			ctx.Hub.Emit(&chat.FileTransferEvent{When: time.Now(), From: ctx.Client.Name(), To: to, FileName: name, SizeBytes: size, MimeType: mime})
+			ctx.Client.SendLocalized("cmd.sendfile.ok", name)
+			return nil
+		},
+		MinLevel: levelUser,
+	}); err != nil {
+		return err
+	}
+	// 切换语言: /lang [language]，不带参数时显示当前语言
+	if err := r.Register(&Command{
+		Name: "lang",
+		Help: "cmd.lang.help",
+		Handler: func(ctx *Context) error {
+			supported := make([]string, 0)
+			for _, l := range i18n.Supported() {
+				supported = append(supported, string(l))
+			}
+			available := strings.Join(supported, ", ")
+			if len(ctx.Args) == 0 {
+				ctx.Client.SendLocalized("cmd.lang.current", ctx.Client.Locale(), available)
+				return nil
+			}
+			l, ok := i18n.Match(ctx.Args[0])
+			if !ok {
+				return protocol.NewError(protocol.CodeBadArguments, ctx.T("cmd.lang.unsupported", ctx.Args[0], available))
+			}
+			ctx.Client.SetLocale(l)
+			ctx.Client.SendLocalized("cmd.lang.ok", l)
+			return nil
+		},
+		MinLevel: levelUser,
+	}); err != nil {
+		return err
+	}
+	// 在线状态: /status [online|away|busy|invisible] [text]，不带参数时显示当前状态
+	if err := r.Register(&Command{
+		Name: "status",
+		Help: "cmd.status.help",
+		Handler: func(ctx *Context) error {
+			if len(ctx.Args) == 0 {
+				p, ok := ctx.Hub.PresenceOf(ctx.Client.Name())
+				if !ok {
+					p.Status = chat.StatusOffline
+				}
+				ctx.Client.SendLocalized("cmd.status.current", statusLabel(ctx, p))
+				return nil
+			}
+			status, ok := chat.ParseStatus(ctx.Args[0])
+			if !ok {
+				return protocol.NewError(protocol.CodeBadArguments, ctx.T("cmd.status.bad", ctx.Args[0]))
+			}
+			text := strings.Join(ctx.Args[1:], " ")
+			if !ctx.Hub.SetPresence(ctx.Client.Name(), status, text) {
+				return protocol.NewError(protocol.CodeNotLoggedIn, "")
 			}
-			ctx.Hub.Emit(&chat.FileTransferEvent{When: time.Now(), From: ctx.Client.Name, To: to, FileName: name, SizeBytes: size, MimeType: mime})
-			ctx.Client.Send("文件事件已提交: " + name)
+			ctx.Client.SendLocalized("cmd.status.ok", statusLabel(ctx, chat.Presence{Status: status, Text: text}))
 			return nil
 		},
 		MinLevel: levelUser,
 	}); err != nil {
 		return err
 	}
+	// 查看用户状态: /whois <name>，隐身用户对他人显示为离线
+	if err := r.Register(&Command{
+		Name: "whois",
+		Help: "cmd.whois.help",
+		Handler: func(ctx *Context) error {
+			if len(ctx.Args) != 1 {
+				return usageError(ctx, "/whois <name>")
+			}
+			name := ctx.Args[0]
+			p, ok := ctx.Hub.PresenceOf(name)
+			if !ok {
+				ctx.Client.SendLocalized("cmd.whois.unknown", name)
+				return nil
+			}
+			if name != ctx.Client.Name() {
+				p = p.Visible()
+			}
+			lines := []string{ctx.T("cmd.whois.status", name, ctx.T("presence."+string(p.Status)))}
+			if p.Text != "" {
+				lines = append(lines, ctx.T("cmd.whois.text", p.Text))
+			}
+			if p.Status == chat.StatusOffline {
+				if !p.LastSeen.IsZero() {
+					lines = append(lines, ctx.T("cmd.whois.last_seen", p.LastSeen.Format("2006-01-02 15:04:05")))
+				}
+			} else {
+				lines = append(lines, ctx.T("cmd.whois.since", p.Since.Format("2006-01-02 15:04:05")))
+				for _, c := range ctx.Hub.ListClients() {
+					if rtt := c.RTT(); c.Name() == name && rtt > 0 {
+						lines = append(lines, ctx.T("cmd.whois.rtt", rtt.Milliseconds()))
+						break
+					}
+				}
+			}
+			ctx.Client.Send(strings.Join(lines, "\n"))
+			return nil
+		},
+		MinLevel: levelUser,
+	}); err != nil {
+		return err
+	}
+	// 编辑消息: /edit <mid|last> <text>，作者在编辑时限内或管理员可以编辑
+	if err := r.Register(&Command{
+		Name: "edit",
+		Help: "cmd.edit.help",
+		Handler: func(ctx *Context) error {
+			if len(ctx.Args) < 2 {
+				return usageError(ctx, "/edit <mid|last> <text>")
+			}
+			id, err := messageID(ctx, ctx.Args[0])
+			if err != nil {
+				return err
+			}
+			return MessageError(ctx.Hub.EditMessage(id, ctx.Client.Name(), IsModerator(ctx.Client), strings.Join(ctx.Args[1:], " ")))
+		},
+		MinLevel: levelUser,
+	}); err != nil {
+		return err
+	}
+	// 删除消息: /delete <mid|last>，授权规则同 /edit
+	if err := r.Register(&Command{
+		Name:    "delete",
+		Aliases: []string{"del"},
+		Help:    "cmd.delete.help",
+		Handler: func(ctx *Context) error {
+			if len(ctx.Args) != 1 {
+				return usageError(ctx, "/delete <mid|last>")
+			}
+			id, err := messageID(ctx, ctx.Args[0])
+			if err != nil {
+				return err
+			}
+			return MessageError(ctx.Hub.DeleteMessage(id, ctx.Client.Name(), IsModerator(ctx.Client)))
+		},
+		MinLevel: levelUser,
+	}); err != nil {
+		return err
+	}
 	return nil
 
 }
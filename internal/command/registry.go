@@ -8,7 +8,9 @@ import (
 	"sync"
 
 	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/i18n"
 	"github.com/hongjun500/chat-go/internal/observe"
+	"github.com/hongjun500/chat-go/internal/protocol"
 )
 
 type Level int
@@ -23,6 +25,15 @@ type Context struct {
 	Client *chat.Client
 	Args   []string
 	Raw    string
+	Mid    string // 命令消息的 mid，/msg 的私信回执以此关联；为空时不跟踪投递状态
+}
+
+// T 按调用者语言渲染文案
+func (ctx *Context) T(key string, args ...any) string {
+	if ctx.Client == nil {
+		return i18n.T(i18n.Default(), key, args...)
+	}
+	return i18n.T(ctx.Client.Locale(), key, args...)
 }
 
 type HandlerFunc func(ctx *Context) error
@@ -30,7 +41,7 @@ type HandlerFunc func(ctx *Context) error
 type Command struct {
 	Name     string
 	Aliases  []string
-	Help     string
+	Help     string // 帮助文案的 i18n 键；未登记的键按原文显示
 	MinLevel Level
 	Handler  HandlerFunc
 }
@@ -108,18 +119,19 @@ func (r *Registry) Execute(raw string, ctx *Context) (handled bool, err error) {
 	cmd, ok := r.Get(cmdName)
 	if !ok {
 		observe.IncCommandError("not_found")
-		return true, fmt.Errorf("command %s not found", cmdName)
+		return true, protocol.Errorf(protocol.CodeCommandNotFound, "command %s not found", cmdName)
 	}
 
 	if !r.checkPermission(ctx.Client, cmd.MinLevel) {
 		observe.IncCommandError("permission")
-		return true, errors.New("permission denied")
+		return true, protocol.NewError(protocol.CodePermissionDenied, "")
 	}
 	ctx.Args = parts[1:]
 	observe.IncCommand(cmd.Name)
 	if err := cmd.Handler(ctx); err != nil {
 		observe.IncCommandError("handler")
-		return true, err
+		// 处理器未给出错误码时统一归为命令执行失败
+		return true, protocol.AsError(err, protocol.CodeCommandFailed)
 	}
 	return true, nil
 
@@ -133,13 +145,24 @@ func (r *Registry) checkPermission(c *chat.Client, need Level) bool {
 	if need <= levelUser {
 		return true
 	}
+	return clientLevel(c) >= need
+}
+
+// clientLevel 客户端通过 /auth 设置的权限等级，未设置时为普通用户
+func clientLevel(c *chat.Client) Level {
 	if c.Meta == nil {
-		return false
+		return levelUser
 	}
 	if s, ok := c.Meta["level"]; ok && s != "" {
 		if v, err := strconv.Atoi(s); err == nil {
-			return Level(v) >= need
+			return Level(v)
 		}
 	}
-	return false
+	return levelUser
+}
+
+// IsModerator 客户端是否为管理员：可以随时编辑或删除任何人的消息。
+// 管理员角色由服务端在登录时按 CHAT_MODERATORS 授予，/auth 设置的等级不影响
+func IsModerator(c *chat.Client) bool {
+	return c != nil && c.Moderator()
 }
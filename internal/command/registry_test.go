@@ -1,9 +1,11 @@
 package command
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/protocol"
 )
 
 func TestRegistryExecute_Basic(t *testing.T) {
@@ -38,3 +40,159 @@ func TestRegistryExecute_Basic(t *testing.T) {
 		t.Fatalf("no output queued")
 	}
 }
+
+// TestHelpLocalized 帮助按调用者语言渲染，/lang 切换语言
+func TestHelpLocalized(t *testing.T) {
+	hub := chat.NewHub()
+	reg := NewRegistry()
+	if err := RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	c := chat.NewClientWithBuffer("c1", 8)
+	ctx := &Context{Hub: hub, Client: c}
+
+	help := func() string {
+		if _, err := reg.Execute("/help", ctx); err != nil {
+			t.Fatalf("help: %v", err)
+		}
+		return <-c.Outgoing()
+	}
+	if s := help(); !strings.Contains(s, "/who - 查看在线用户") {
+		t.Errorf("zh-CN help missing localized line:\n%s", s)
+	}
+
+	if _, err := reg.Execute("/lang en-US", ctx); err != nil {
+		t.Fatalf("lang: %v", err)
+	}
+	if s := <-c.Outgoing(); s != "language switched to: en" {
+		t.Errorf("lang ack = %q", s)
+	}
+	if s := help(); !strings.Contains(s, "/who - list online users") {
+		t.Errorf("en help missing localized line:\n%s", s)
+	}
+
+	_, err := reg.Execute("/lang fr", ctx)
+	if e := protocol.AsError(err, protocol.CodeInternal); e.Code != protocol.CodeBadArguments || !strings.HasPrefix(e.Message, "unsupported language") {
+		t.Errorf("lang fr = %v", err)
+	}
+}
+
+// TestStatusWhois /status 设置并显示自身状态，/whois 对他人隐藏隐身状态
+func TestStatusWhois(t *testing.T) {
+	hub := chat.NewHub()
+	reg := NewRegistry()
+	if err := RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	alice := chat.NewClientWithBuffer("a", 8)
+	alice.SetName("alice")
+	alice.SetLocale("en")
+	bob := chat.NewClientWithBuffer("b", 8)
+	bob.SetName("bob")
+	bob.SetLocale("en")
+	hub.RegisterClient(alice)
+	hub.RegisterClient(bob)
+
+	run := func(c *chat.Client, line string) string {
+		t.Helper()
+		if _, err := reg.Execute(line, &Context{Hub: hub, Client: c}); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		return <-c.Outgoing()
+	}
+
+	if s := run(alice, "/status busy in a meeting"); s != "status set to: busy (in a meeting)" {
+		t.Errorf("/status busy = %q", s)
+	}
+	if s := run(alice, "/status"); s != "Status: busy (in a meeting)" {
+		t.Errorf("/status = %q", s)
+	}
+	if s := run(bob, "/whois alice"); !strings.HasPrefix(s, "alice: busy\n  status text: in a meeting") {
+		t.Errorf("/whois alice = %q", s)
+	}
+	if s := run(bob, "/who"); !strings.Contains(s, "alice[busy]") {
+		t.Errorf("/who = %q", s)
+	}
+
+	run(alice, "/status invisible")
+	if s := run(bob, "/whois alice"); !strings.HasPrefix(s, "alice: offline\n  last seen: ") {
+		t.Errorf("/whois invisible alice = %q", s)
+	}
+	if s := run(alice, "/whois alice"); !strings.HasPrefix(s, "alice: invisible") {
+		t.Errorf("own /whois = %q", s)
+	}
+	if s := run(bob, "/who"); strings.Contains(s, "alice") {
+		t.Errorf("/who lists invisible user: %q", s)
+	}
+	if s := run(bob, "/whois nobody"); s != "no such user: nobody" {
+		t.Errorf("/whois nobody = %q", s)
+	}
+
+	_, err := reg.Execute("/status sleeping", &Context{Hub: hub, Client: alice})
+	if e := protocol.AsError(err, protocol.CodeInternal); e.Code != protocol.CodeBadArguments {
+		t.Errorf("/status sleeping = %v", err)
+	}
+}
+
+func TestEditDelete(t *testing.T) {
+	hub := chat.NewHub()
+	reg := NewRegistry()
+	if err := RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	alice := chat.NewClientWithBuffer("a", 8)
+	alice.SetName("alice")
+	bob := chat.NewClientWithBuffer("b", 8)
+	bob.SetName("bob")
+	code := func(c *chat.Client, line string) protocol.ErrorCode {
+		t.Helper()
+		_, err := reg.Execute(line, &Context{Hub: hub, Client: c})
+		if err == nil {
+			return 0
+		}
+		return protocol.AsError(err, protocol.CodeInternal).Code
+	}
+
+	// 网关发送成功后记录为本连接最近发送的消息
+	id, _ := hub.SendRoom("m1", "alice", "helo")
+	alice.SetLastSent(id)
+	if c := code(alice, "/edit last hello world"); c != 0 {
+		t.Fatalf("/edit last: code %d", c)
+	}
+	if r, _ := hub.MessageOf("m1"); r.Content != "hello world" {
+		t.Errorf("content = %q", r.Content)
+	}
+	if c := code(bob, "/delete m1"); c != protocol.CodePermissionDenied {
+		t.Errorf("bob /delete: code %d", c)
+	}
+	if c := code(bob, "/edit last hi"); c != protocol.CodeMessageNotFound {
+		t.Errorf("bob /edit last without messages: code %d", c)
+	}
+	// last 按连接记录：同名的另一个连接没有最近发送的消息
+	alice2 := chat.NewClientWithBuffer("a2", 8)
+	alice2.SetName("alice")
+	if c := code(alice2, "/edit last hi"); c != protocol.CodeMessageNotFound {
+		t.Errorf("other alice connection /edit last: code %d", c)
+	}
+
+	// /auth 设置的等级不授予管理员角色
+	bob.Meta["level"] = "1"
+	if c := code(bob, "/del m1"); c != protocol.CodePermissionDenied {
+		t.Errorf("/auth 1 /del: code %d", c)
+	}
+	// 按配置授予管理员角色的已认证身份可以删除他人的消息
+	hub.SetModerators([]string{"root"})
+	root := chat.NewClientWithBuffer("r", 8)
+	if err := hub.Login(root, "root", true); err != nil {
+		t.Fatal(err)
+	}
+	if c := code(root, "/del m1"); c != 0 {
+		t.Errorf("moderator /del: code %d", c)
+	}
+	if c := code(alice, "/edit m1 again"); c != protocol.CodeMessageNotFound {
+		t.Errorf("edit deleted: code %d", c)
+	}
+	if c := code(alice, "/edit m1"); c != protocol.CodeBadArguments {
+		t.Errorf("/edit without text: code %d", c)
+	}
+}
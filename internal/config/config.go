@@ -9,19 +9,62 @@ type Config struct {
 	TCPAddr   string
 	OutBuffer int
 	WSAddr    string
+	SSEAddr   string // SSE + HTTP POST 传输地址，为空时不启用
+	PollAddr  string // 长轮询传输地址，为空时不启用
+	PollHold  int    // 长轮询无消息时挂起 GET 的时长（秒）
+	IRCAddr   string // IRC 网关地址，为空时不启用
+	IRCChan   string // 聊天室对应的 IRC 频道
+	LineAddr  string // 纯文本行协议地址（telnet/nc），为空时不启用
+	LineMax   int    // 行协议单行上限（字节）
 	HTTPAddr  string
 	LogLevel  string
+	Locale    string // 默认语言，会话未声明语言时使用
+	Drain     int    // 停机时等待会话排空的时长（秒）
+	AwayAfter int    // 无活动超过该时长（秒）自动设为 away，0 不启用
+	EditLimit int    // 作者可以编辑或删除自己消息的时长（秒），0 不限
+	// 逗号分隔的已认证身份（客户端证书、令牌或 Unix 对端用户），登录后拥有管理员角色
+	Moderators string
+	// Unix 域套接字（帧协议），路径为空时不启用
+	UnixSocket string
+	UnixMode   string // 套接字文件权限（八进制）
+	UnixOwner  string // 套接字文件属主 user[:group]
+	UnixPeerID bool   // 以对端进程用户名作为身份登录（SO_PEERCRED）
 	// TCP advanced
-	TCPCodec     int // 0:json| 1:protobuf
-	WSCodec      int // 0:json| 1:protobuf
-	ReadTimeout  int // seconds
-	WriteTimeout int // seconds
-	MaxFrameSize int // bytes
+	TCPCodec     int  // 0:json| 1:protobuf| 3:cbor
+	WSCodec      int  // 0:json| 1:protobuf| 3:cbor
+	TCPNegotiate bool // 按客户端首帧嗅探每个 TCP 会话的编解码器
+	ReadTimeout  int  // seconds
+	PingEvery    int  // 服务端心跳 ping 间隔（秒），TCP 与 WebSocket 共用
+	PingTimeout  int  // 超过该时长（秒）无任何入站数据时关闭会话
+	WriteTimeout int  // seconds
+	MaxFrameSize int  // bytes
+	// TLS（TCP 与 WebSocket 共用），证书与私钥均配置时启用
+	TLSCert     string
+	TLSKey      string
+	TLSClientCA string // 非空时开启双向 TLS
+	TLSMin      string // 1.2 | 1.3
+	TLSCiphers  string // default | modern | 逗号分隔的套件名
+	TLSReload   int    // 证书文件轮询间隔（秒）
+	// WebSocket 缓冲区与 permessage-deflate 压缩
+	WSReadBuf    int  // 读缓冲区（字节）
+	WSWriteBuf   int  // 写缓冲区（字节）
+	WSCompress   bool // 与客户端协商 permessage-deflate
+	WSCompressLv int  // 压缩级别 -2..9，0 为默认
+	// WebSocket 升级策略
+	WSOrigins  string // 逗号分隔的允许来源，为空时只允许同主机
+	WSTokens   string // 逗号分隔的 token[:identity]，非空时升级必须携带令牌
+	WSMaxPerIP int    // 单 IP 并发连接上限，0 不限制
+	// 连接准入（全部传输合计），0 不限制
+	MaxConns    int     // 全局并发连接上限
+	MaxPerIP    int     // 单 IP 并发连接上限
+	AcceptRate  float64 // 每秒允许接入的新连接数
+	AcceptBurst int     // 接入速率的突发容量
 	// Redis Stream
 	RedisAddr   string
 	RedisDB     int
 	RedisStream string
-	RedisGroup  string
+	RedisGroup  string // 消费组名前缀，每个节点使用自己的消费组 <group>:<node>
+	RedisNode   string // 节点 ID，集群内唯一；为空时使用主机名与进程号
 	RedisEnable bool
 }
 
@@ -41,37 +84,104 @@ func Load() *Config {
 	}
 	wsAddr := getEnv("CHAT_WS_ADDR", ":8081")
 	httpAddr := getEnv("CHAT_HTTP_ADDR", ":8082")
+	sseAddr := getEnv("CHAT_SSE_ADDR", "")
+	pollAddr := getEnv("CHAT_POLL_ADDR", "")
+	pollHold, _ := strconv.Atoi(getEnv("CHAT_POLL_HOLD", "25"))
+	ircAddr := getEnv("CHAT_IRC_ADDR", "")
+	ircChan := getEnv("CHAT_IRC_CHANNEL", "#chat")
+	lineAddr := getEnv("CHAT_LINE_ADDR", "")
+	lineMax, _ := strconv.Atoi(getEnv("CHAT_LINE_MAX", "4096"))
+	unixSocket := getEnv("CHAT_UNIX_SOCKET", "")
+	unixMode := getEnv("CHAT_UNIX_MODE", "0660")
+	unixOwner := getEnv("CHAT_UNIX_OWNER", "")
+	unixPeerID := getEnv("CHAT_UNIX_PEER_IDENTITY", "false") == "true"
 	logLevel := getEnv("CHAT_LOG_LEVEL", "info")
+	locale := getEnv("CHAT_LOCALE", "zh-CN")
+	drain, _ := strconv.Atoi(getEnv("CHAT_DRAIN_TIMEOUT", "10"))
+	awayAfter, _ := strconv.Atoi(getEnv("CHAT_AWAY_AFTER", "300"))
+	editLimit, _ := strconv.Atoi(getEnv("CHAT_EDIT_WINDOW", "900"))
+	moderators := getEnv("CHAT_MODERATORS", "")
 	tcpCodec, _ := strconv.Atoi(getEnv("CHAT_TCP_CODEC", "0"))
 	wsCodec, _ := strconv.Atoi(getEnv("CHAT_WS_CODEC", "0"))
+	tcpNegotiate := getEnv("CHAT_TCP_NEGOTIATE", "false") == "true"
 	rtStr := getEnv("CHAT_TCP_READ_TIMEOUT", "60")
 	wtStr := getEnv("CHAT_TCP_WRITE_TIMEOUT", "15")
 	mfsStr := getEnv("CHAT_TCP_MAX_FRAME", "1048576")
 	rt, _ := strconv.Atoi(rtStr)
+	pingEvery, _ := strconv.Atoi(getEnv("CHAT_HEARTBEAT_INTERVAL", "30"))
+	pingTimeout, _ := strconv.Atoi(getEnv("CHAT_HEARTBEAT_TIMEOUT", "60"))
 	wt, _ := strconv.Atoi(wtStr)
 	mfs, _ := strconv.Atoi(mfsStr)
+	tlsReload, _ := strconv.Atoi(getEnv("CHAT_TLS_RELOAD", "30"))
+	wsReadBuf, _ := strconv.Atoi(getEnv("CHAT_WS_READ_BUFFER", "1024"))
+	wsWriteBuf, _ := strconv.Atoi(getEnv("CHAT_WS_WRITE_BUFFER", "1024"))
+	wsCompress := getEnv("CHAT_WS_COMPRESSION", "false") == "true"
+	wsCompressLv, _ := strconv.Atoi(getEnv("CHAT_WS_COMPRESSION_LEVEL", "0"))
+	wsMaxPerIP, _ := strconv.Atoi(getEnv("CHAT_WS_MAX_PER_IP", "0"))
+	maxConns, _ := strconv.Atoi(getEnv("CHAT_MAX_CONNS", "0"))
+	maxPerIP, _ := strconv.Atoi(getEnv("CHAT_MAX_CONNS_PER_IP", "0"))
+	acceptRate, _ := strconv.ParseFloat(getEnv("CHAT_ACCEPT_RATE", "0"), 64)
+	acceptBurst, _ := strconv.Atoi(getEnv("CHAT_ACCEPT_BURST", "0"))
 	redisAddr := getEnv("CHAT_REDIS_ADDR", "localhost:6379")
 	redisDBStr := getEnv("CHAT_REDIS_DB", "0")
 	redisDB, _ := strconv.Atoi(redisDBStr)
 	redisStream := getEnv("CHAT_REDIS_STREAM", "chat_stream")
 	redisGroup := getEnv("CHAT_REDIS_GROUP", "chat_group")
+	redisNode := getEnv("CHAT_NODE_ID", "")
 	redisEnable := getEnv("CHAT_REDIS_ENABLE", "true") == "true"
 
 	return &Config{
 		TCPAddr:      addr,
 		OutBuffer:    outBuf,
 		WSAddr:       wsAddr,
+		SSEAddr:      sseAddr,
+		PollAddr:     pollAddr,
+		PollHold:     pollHold,
+		IRCAddr:      ircAddr,
+		IRCChan:      ircChan,
+		LineAddr:     lineAddr,
+		LineMax:      lineMax,
+		UnixSocket:   unixSocket,
+		UnixMode:     unixMode,
+		UnixOwner:    unixOwner,
+		UnixPeerID:   unixPeerID,
 		HTTPAddr:     httpAddr,
 		LogLevel:     logLevel,
+		Locale:       locale,
+		Drain:        drain,
+		AwayAfter:    awayAfter,
+		EditLimit:    editLimit,
+		Moderators:   moderators,
 		TCPCodec:     tcpCodec,
 		WSCodec:      wsCodec,
+		TCPNegotiate: tcpNegotiate,
 		ReadTimeout:  rt,
+		PingEvery:    pingEvery,
+		PingTimeout:  pingTimeout,
 		WriteTimeout: wt,
 		MaxFrameSize: mfs,
+		TLSCert:      getEnv("CHAT_TLS_CERT", ""),
+		TLSKey:       getEnv("CHAT_TLS_KEY", ""),
+		TLSClientCA:  getEnv("CHAT_TLS_CLIENT_CA", ""),
+		TLSMin:       getEnv("CHAT_TLS_MIN_VERSION", "1.2"),
+		TLSCiphers:   getEnv("CHAT_TLS_CIPHERS", "default"),
+		TLSReload:    tlsReload,
+		WSReadBuf:    wsReadBuf,
+		WSWriteBuf:   wsWriteBuf,
+		WSCompress:   wsCompress,
+		WSCompressLv: wsCompressLv,
+		WSOrigins:    getEnv("CHAT_WS_ORIGINS", ""),
+		WSTokens:     getEnv("CHAT_WS_TOKENS", ""),
+		WSMaxPerIP:   wsMaxPerIP,
+		MaxConns:     maxConns,
+		MaxPerIP:     maxPerIP,
+		AcceptRate:   acceptRate,
+		AcceptBurst:  acceptBurst,
 		RedisAddr:    redisAddr,
 		RedisDB:      redisDB,
 		RedisStream:  redisStream,
 		RedisGroup:   redisGroup,
+		RedisNode:    redisNode,
 		RedisEnable:  redisEnable,
 	}
 }
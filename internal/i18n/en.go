@@ -0,0 +1,71 @@
+package i18n
+
+var en = Bundle{
+	// session
+	"session.welcome":     "Welcome to Chat-Go!",
+	"session.prompt_nick": "Please enter your nickname:",
+	"session.shutdown":    "Server is shutting down, please reconnect later",
+
+	// system notices
+	"system.joined":       "[system] %s joined",
+	"system.left":         "[system] %s left",
+	"system.notice":       "[notice][%s] %s",
+	"system.user_offline": "[system] user is offline or does not exist: %s",
+	"direct.message":      "[direct] %s: %s",
+	"direct.group":        "[direct %s] %s: %s",
+	"message.edited":      "[edited] %s: %s",
+	"message.deleted":     "[deleted] a message from %s was deleted",
+	"file.to_all":         "[file] %s -> everyone: %s",
+	"file.to_user":        "[file] %s -> %s: %s",
+
+	// presence
+	"presence.online":    "online",
+	"presence.away":      "away",
+	"presence.busy":      "busy",
+	"presence.invisible": "invisible",
+	"presence.offline":   "offline",
+
+	// command help
+	"cmd.help.help":     "show help",
+	"cmd.quit.help":     "leave the chat",
+	"cmd.who.help":      "list online users",
+	"cmd.auth.help":     "set level: /auth <level> (0 user, 1 admin)",
+	"cmd.kick.help":     "kick a user: /kick <name>",
+	"cmd.ban.help":      "ban a user: /ban <name> [minutes] (forever by default)",
+	"cmd.msg.help":      "direct message: /msg <to>[,<to>...] <text>",
+	"cmd.notice.help":   "broadcast a notice: /notice <level> <text>",
+	"cmd.ping.help":     "send a heartbeat: /ping [detail]",
+	"cmd.sendfile.help": "send a file: /sendfile <to|*> <name> <size> [mime]",
+	"cmd.lang.help":     "switch language: /lang [language]",
+	"cmd.status.help":   "set status: /status [online|away|busy|invisible] [text]",
+	"cmd.whois.help":    "show a user's status: /whois <name>",
+	"cmd.edit.help":     "edit your message: /edit <mid|last> <text>",
+	"cmd.delete.help":   "delete your message: /delete <mid|last>",
+	"cmd.help.aliases":  " (aliases: %s)",
+
+	// command output
+	"cmd.usage":             "usage: %s",
+	"cmd.quit.bye":          "Bye!",
+	"cmd.who.online":        "Online: %s",
+	"cmd.auth.bad_level":    "invalid level: %s",
+	"cmd.auth.ok":           "level set to: %s",
+	"cmd.kick.offline":      "user is offline: %s",
+	"cmd.kick.ok":           "kicked: %s",
+	"cmd.ban.bad_minutes":   "invalid minutes",
+	"cmd.ban.forever":       "banned forever: %s",
+	"cmd.ban.minutes":       "banned %s for %d minutes",
+	"cmd.sendfile.bad_size": "size is not an integer: %v",
+	"cmd.sendfile.ok":       "file event submitted: %s",
+	"cmd.lang.current":      "Language: %s, available: %s",
+	"cmd.lang.unsupported":  "unsupported language: %s, available: %s",
+	"cmd.lang.ok":           "language switched to: %s",
+	"cmd.status.current":    "Status: %s",
+	"cmd.status.bad":        "invalid status: %s, available: online, away, busy, invisible",
+	"cmd.status.ok":         "status set to: %s",
+	"cmd.whois.unknown":     "no such user: %s",
+	"cmd.whois.status":      "%s: %s",
+	"cmd.whois.text":        "  status text: %s",
+	"cmd.whois.since":       "  since: %s",
+	"cmd.whois.last_seen":   "  last seen: %s",
+	"cmd.whois.rtt":         "  latency: %dms",
+}
@@ -0,0 +1,117 @@
+// Package i18n 面向用户的服务端文案目录。
+// 文案按键存放在各语言的 bundle 中，渲染时按会话语言选择，缺失时回退到默认语言，再回退到键本身。
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Locale 语言标识，采用 BCP 47 形式
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN"
+	En   Locale = "en"
+)
+
+// Bundle 某个语言的文案：键 -> fmt 格式串
+type Bundle map[string]string
+
+var (
+	mu            sync.RWMutex
+	bundles       = map[Locale]Bundle{ZhCN: zhCN, En: en}
+	defaultLocale = ZhCN
+)
+
+// Register 注册或覆盖某个语言的文案，已有键被同名键覆盖
+func Register(l Locale, b Bundle) {
+	mu.Lock()
+	defer mu.Unlock()
+	dst, ok := bundles[l]
+	if !ok {
+		dst = make(Bundle, len(b))
+		bundles[l] = dst
+	}
+	for k, v := range b {
+		dst[k] = v
+	}
+}
+
+// SetDefault 设置默认语言，未注册的语言返回 false
+func SetDefault(l Locale) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := bundles[l]; !ok {
+		return false
+	}
+	defaultLocale = l
+	return true
+}
+
+// Default 默认语言
+func Default() Locale {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocale
+}
+
+// Supported 返回已注册的语言，按字母序
+func Supported() []Locale {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]Locale, 0, len(bundles))
+	for l := range bundles {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Match 将客户端给出的语言标签匹配到已注册语言：先精确匹配（忽略大小写与 _/- 差异），再按主语言匹配，
+// 如 "en-US" -> en、"zh" -> zh-CN
+func Match(tag string) (Locale, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return "", false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for l := range bundles {
+		if strings.EqualFold(string(l), tag) {
+			return l, true
+		}
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	var candidates []Locale
+	for l := range bundles {
+		p, _, _ := strings.Cut(string(l), "-")
+		if strings.EqualFold(p, primary) {
+			candidates = append(candidates, l)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+	return candidates[0], true
+}
+
+// T 按语言渲染文案；语言为空或缺少该键时回退到默认语言，仍缺失时返回键本身
+func T(l Locale, key string, args ...any) string {
+	mu.RLock()
+	format, ok := bundles[l][key]
+	if !ok {
+		format, ok = bundles[defaultLocale][key]
+	}
+	mu.RUnlock()
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
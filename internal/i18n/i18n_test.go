@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+var verbRe = regexp.MustCompile(`%[-+# 0]*[0-9]*[vsdqxXf]`)
+
+// TestBundlesParity 每个语言都必须包含全部键，且格式参数个数一致
+func TestBundlesParity(t *testing.T) {
+	for key, zh := range zhCN {
+		e, ok := en[key]
+		if !ok {
+			t.Errorf("en is missing key %q", key)
+			continue
+		}
+		if a, b := len(verbRe.FindAllString(zh, -1)), len(verbRe.FindAllString(e, -1)); a != b {
+			t.Errorf("key %q: zh-CN has %d verbs, en has %d", key, a, b)
+		}
+	}
+	for key := range en {
+		if _, ok := zhCN[key]; !ok {
+			t.Errorf("zh-CN is missing key %q", key)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]Locale{
+		"zh-CN": ZhCN,
+		"zh_cn": ZhCN,
+		"zh":    ZhCN,
+		"en":    En,
+		"EN-us": En,
+	}
+	for tag, want := range cases {
+		if got, ok := Match(tag); !ok || got != want {
+			t.Errorf("Match(%q) = %q, %v; want %q", tag, got, ok, want)
+		}
+	}
+	for _, tag := range []string{"", "fr", "de-DE"} {
+		if got, ok := Match(tag); ok {
+			t.Errorf("Match(%q) = %q, want no match", tag, got)
+		}
+	}
+}
+
+// TestTFallback 缺失的语言回退到默认语言，缺失的键原样返回
+func TestTFallback(t *testing.T) {
+	if got := T(En, "cmd.who.online", "a,b"); got != "Online: a,b" {
+		t.Errorf("T(en) = %q", got)
+	}
+	if got, want := T("fr", "cmd.quit.bye"), zhCN["cmd.quit.bye"]; got != want {
+		t.Errorf("T(fr) = %q, want default %q", got, want)
+	}
+	if got := T(En, "自定义帮助"); got != "自定义帮助" {
+		t.Errorf("T(unknown key) = %q", got)
+	}
+}
@@ -0,0 +1,71 @@
+package i18n
+
+var zhCN = Bundle{
+	// 会话
+	"session.welcome":     "欢迎来到 Chat-Go！",
+	"session.prompt_nick": "请输入昵称并回车：",
+	"session.shutdown":    "服务器即将关闭，请稍后重连",
+
+	// 系统通知
+	"system.joined":       "[系统] %s 加入",
+	"system.left":         "[系统] %s 离开",
+	"system.notice":       "[系统通知][%s] %s",
+	"system.user_offline": "[系统] 用户不在线或不存在: %s",
+	"direct.message":      "[私信] %s: %s",
+	"direct.group":        "[私信 %s] %s: %s",
+	"message.edited":      "[已编辑] %s: %s",
+	"message.deleted":     "[已删除] %s 的一条消息已被删除",
+	"file.to_all":         "[文件] %s -> 所有人: %s",
+	"file.to_user":        "[文件] %s -> %s: %s",
+
+	// 在线状态
+	"presence.online":    "在线",
+	"presence.away":      "离开",
+	"presence.busy":      "忙碌",
+	"presence.invisible": "隐身",
+	"presence.offline":   "离线",
+
+	// 命令帮助
+	"cmd.help.help":     "查看帮助",
+	"cmd.quit.help":     "退出聊天室",
+	"cmd.who.help":      "查看在线用户",
+	"cmd.auth.help":     "授权设置: /auth <level> (0 用户, 1 管理员)",
+	"cmd.kick.help":     "踢人: /kick <name>",
+	"cmd.ban.help":      "封禁: /ban <name> [minutes] (默认永久)",
+	"cmd.msg.help":      "私信: /msg <to>[,<to>...] <text>",
+	"cmd.notice.help":   "系统通知广播: /notice <level> <text>",
+	"cmd.ping.help":     "发送心跳: /ping [detail]",
+	"cmd.sendfile.help": "发送文件: /sendfile <to|*> <name> <size> [mime]",
+	"cmd.lang.help":     "切换语言: /lang [language]",
+	"cmd.status.help":   "设置状态: /status [online|away|busy|invisible] [text]",
+	"cmd.whois.help":    "查看用户状态: /whois <name>",
+	"cmd.edit.help":     "编辑消息: /edit <mid|last> <text>",
+	"cmd.delete.help":   "删除消息: /delete <mid|last>",
+	"cmd.help.aliases":  " (别名: %s)",
+
+	// 命令输出
+	"cmd.usage":             "用法: %s",
+	"cmd.quit.bye":          "再见！",
+	"cmd.who.online":        "在线用户：%s",
+	"cmd.auth.bad_level":    "非法等级: %s",
+	"cmd.auth.ok":           "已设置权限等级为: %s",
+	"cmd.kick.offline":      "用户不在线: %s",
+	"cmd.kick.ok":           "已踢出: %s",
+	"cmd.ban.bad_minutes":   "minutes 非法",
+	"cmd.ban.forever":       "已永久封禁: %s",
+	"cmd.ban.minutes":       "已封禁 %s %d 分钟",
+	"cmd.sendfile.bad_size": "size 不是整数: %v",
+	"cmd.sendfile.ok":       "文件事件已提交: %s",
+	"cmd.lang.current":      "当前语言: %s，可选: %s",
+	"cmd.lang.unsupported":  "不支持的语言: %s，可选: %s",
+	"cmd.lang.ok":           "语言已切换为: %s",
+	"cmd.status.current":    "当前状态: %s",
+	"cmd.status.bad":        "非法状态: %s，可选: online, away, busy, invisible",
+	"cmd.status.ok":         "状态已设置为: %s",
+	"cmd.whois.unknown":     "用户不存在: %s",
+	"cmd.whois.status":      "%s: %s",
+	"cmd.whois.text":        "  状态文本: %s",
+	"cmd.whois.since":       "  开始于: %s",
+	"cmd.whois.last_seen":   "  最后在线: %s",
+	"cmd.whois.rtt":         "  时延: %dms",
+}
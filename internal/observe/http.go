@@ -1,10 +1,14 @@
 package observe
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -12,15 +16,38 @@ import (
 //go:embed static
 var embeddedStatic embed.FS
 
-// StartHTTP 启动一个最简 HTTP 服务，提供 /healthz，并托管 / 静态页面
-func StartHTTP(addr string) error {
+// draining 服务进入停机排空后为 true，/healthz 返回 503 让负载均衡摘除本实例
+var draining atomic.Bool
+
+// SetDraining 标记服务是否处于停机排空
+func SetDraining(v bool) {
+	draining.Store(v)
+}
+
+// StartHTTP 启动一个最简 HTTP 服务，提供 /healthz，并托管 / 静态页面；ctx 取消后关闭
+func StartHTTP(ctx context.Context, addr string) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if draining.Load() {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
+		}
 		_, _ = fmt.Fprintln(w, "ok")
 	})
 	mux.Handle("/metrics", promhttp.Handler())
 	// 静态资源（用于 WS 测试页面）
 	sub, _ := fs.Sub(embeddedStatic, "static")
 	mux.Handle("/", http.FileServer(http.FS(sub)))
-	return http.ListenAndServe(addr, mux)
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
 }
@@ -1,6 +1,8 @@
 package observe
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -48,6 +50,39 @@ var (
 		},
 		[]string{"reason"}, // not_found|permission|handler|parse
 	)
+
+	connectionsActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "chat_connections_active",
+			Help: "Admitted connections by transport",
+		},
+		[]string{"transport"},
+	)
+
+	connectionsRejectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chat_connections_rejected_total",
+			Help: "Connections rejected by admission control",
+		},
+		[]string{"transport", "reason"}, // server_full|ip_limit|rate_limited
+	)
+
+	heartbeatRTT = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "chat_heartbeat_rtt_seconds",
+			Help:    "Round-trip time of server-initiated pings",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"transport"},
+	)
+
+	heartbeatTimeoutsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chat_heartbeat_timeouts_total",
+			Help: "Sessions closed for missing heartbeats",
+		},
+		[]string{"transport"},
+	)
 )
 
 func init() {
@@ -59,6 +94,10 @@ func init() {
 		heartbeatsTotal,
 		commandsTotal,
 		commandErrorsTotal,
+		connectionsActive,
+		heartbeatRTT,
+		heartbeatTimeoutsTotal,
+		connectionsRejectedTotal,
 	)
 }
 
@@ -69,3 +108,13 @@ func IncHeartbeat()                 { heartbeatsTotal.Inc() }
 func AddOnline(delta float64)       { onlineUsers.Add(delta) }
 func IncCommand(name string)        { commandsTotal.WithLabelValues(name).Inc() }
 func IncCommandError(reason string) { commandErrorsTotal.WithLabelValues(reason).Inc() }
+func AddConnections(transport string, delta float64) {
+	connectionsActive.WithLabelValues(transport).Add(delta)
+}
+func IncRejectedConnection(transport, reason string) {
+	connectionsRejectedTotal.WithLabelValues(transport, reason).Inc()
+}
+func ObserveHeartbeatRTT(transport string, rtt time.Duration) {
+	heartbeatRTT.WithLabelValues(transport).Observe(rtt.Seconds())
+}
+func IncHeartbeatTimeout(transport string) { heartbeatTimeoutsTotal.WithLabelValues(transport).Inc() }
@@ -0,0 +1,84 @@
+package protocol
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	// cborMaxNestedLevels Envelope 本身只有一层，留少量余量即可
+	cborMaxNestedLevels = 4
+	// cborMaxMapPairs Envelope 字段数量上限
+	cborMaxMapPairs = 32
+	// cborMaxArrayElements 数组元素上限（如接收者列表）
+	cborMaxArrayElements = 1024
+)
+
+var (
+	// cborEncMode 使用 RFC 8949 Core Deterministic Encoding，相同 Envelope 总是得到相同字节
+	cborEncMode cbor.EncMode
+	// cborDecMode 严格解码：拒绝重复键、不定长编码和任意 tag，限制嵌套与集合大小
+	cborDecMode cbor.DecMode
+)
+
+func init() {
+	var err error
+	if cborEncMode, err = cbor.CoreDetEncOptions().EncMode(); err != nil {
+		panic(fmt.Sprintf("cbor enc mode: %v", err))
+	}
+	cborDecMode, err = cbor.DecOptions{
+		DupMapKey:        cbor.DupMapKeyEnforcedAPF,
+		IndefLength:      cbor.IndefLengthForbidden,
+		TagsMd:           cbor.TagsForbidden,
+		MaxNestedLevels:  cborMaxNestedLevels,
+		MaxMapPairs:      cborMaxMapPairs,
+		MaxArrayElements: cborMaxArrayElements,
+	}.DecMode()
+	if err != nil {
+		panic(fmt.Sprintf("cbor dec mode: %v", err))
+	}
+}
+
+// CBORCodec 将 Envelope 编码为 CBOR (RFC 8949)，面向嵌入式/IoT 等受限客户端
+type CBORCodec struct{}
+
+func (CBORCodec) Name() string { return Cbor }
+
+// Encode 以确定性编码写出 Envelope
+func (CBORCodec) Encode(w io.Writer, e *Envelope) error {
+	data, err := cborEncMode.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cbor encode: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Decode 从 r 中读取一个完整的 CBOR Envelope。
+// maxSize > 0 时，超过 maxSize 字节的输入直接拒绝而不是截断；
+// 数据项之后的多余字节同样视为错误。
+func (CBORCodec) Decode(r io.Reader, e *Envelope, maxSize int) error {
+	if maxSize > 0 {
+		// 多读 1 字节用于判断是否超限
+		r = io.LimitReader(r, int64(maxSize)+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("cbor read: %w", err)
+	}
+	if maxSize > 0 && len(data) > maxSize {
+		return fmt.Errorf("cbor payload exceeds max size %d", maxSize)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("cbor decode: %w", io.ErrUnexpectedEOF)
+	}
+	if err := cborDecMode.Unmarshal(data, e); err != nil {
+		return fmt.Errorf("cbor decode: %w", err)
+	}
+	if e.Type == "" {
+		return fmt.Errorf("missing field: type")
+	}
+	return nil
+}
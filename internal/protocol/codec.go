@@ -3,23 +3,34 @@ package protocol
 import (
 	"fmt"
 	"io"
+	"sort"
 )
 
 const (
 	CodecJson = iota
 	CodecProtobuf
 	CodecMsgpack
+	CodecCbor
 )
 
 const (
 	Json     = "json"
 	Protobuf = "protobuf"
 	Msgpack  = "msgpack"
+	Cbor     = "cbor"
 )
 
 var CodecFactories = map[int]func() MessageCodec{
 	CodecJson:     func() MessageCodec { return &JSONCodec{} },
 	CodecProtobuf: func() MessageCodec { return &ProtobufCodec{} },
+	CodecCbor:     func() MessageCodec { return &CBORCodec{} },
+}
+
+// CodecNames 编解码器名称到类型的映射，用于按名称协商
+var CodecNames = map[string]int{
+	Json:     CodecJson,
+	Protobuf: CodecProtobuf,
+	Cbor:     CodecCbor,
 }
 
 // MessageCodec 消息体数据编码解码器
@@ -36,3 +47,47 @@ func NewCodec(c int) (MessageCodec, error) {
 	}
 	return nil, fmt.Errorf("unsupported codec type: %d", c)
 }
+
+// NewCodecByName 根据编解码器名称创建相应的编解码器
+func NewCodecByName(name string) (MessageCodec, error) {
+	if c, ok := CodecNames[name]; ok {
+		return NewCodec(c)
+	}
+	return nil, fmt.Errorf("unsupported codec: %s", name)
+}
+
+// SupportedCodecs 返回服务端支持的编解码器名称，按类型编号排序
+func SupportedCodecs() []string {
+	ids := make([]int, 0, len(CodecFactories))
+	for c := range CodecFactories {
+		ids = append(ids, c)
+	}
+	sort.Ints(ids)
+	names := make([]string, 0, len(ids))
+	for _, c := range ids {
+		names = append(names, CodecFactories[c]().Name())
+	}
+	return names
+}
+
+// DetectCodec 根据首帧的首字节嗅探编解码器类型。
+// 三种编码的首字节互不重叠：
+//   - JSON Envelope 是对象，以 '{' 开头（JSONCodec 同样不接受前导空白）
+//   - CBOR Envelope 是 map，主类型 5，首字节位于 0xa0-0xbf
+//   - Protobuf Envelope 首字节是字段 1-15 的 tag，取值 <= 0x7f
+func DetectCodec(frame []byte) (int, bool) {
+	if len(frame) == 0 {
+		return 0, false
+	}
+	b := frame[0]
+	switch {
+	case b == '{':
+		return CodecJson, true
+	case b >= 0xa0 && b <= 0xbf:
+		return CodecCbor, true
+	case b <= 0x7f && b>>3 >= 1 && b&0x07 <= 5:
+		return CodecProtobuf, true
+	default:
+		return 0, false
+	}
+}
@@ -18,6 +18,7 @@ func TestCodecFactory(t *testing.T) {
 		{"JSON Codec", 0, false, "json"},
 		{"Protobuf Codec", 1, false, "protobuf"},
 		{"Unknown Codec", 2, true, ""},
+		{"CBOR Codec", 3, false, "cbor"},
 	}
 
 	for _, tt := range tests {
@@ -184,3 +185,119 @@ func TestPayloadTypes(t *testing.T) {
 		})
 	}
 }
+
+// TestCBORCodec 测试 CBOR 编解码：往返、确定性编码与严格的大小限制
+func TestCBORCodec(t *testing.T) {
+	codec := &CBORCodec{}
+	envelope := &Envelope{
+		Version:     "1.0",
+		Type:        MsgText,
+		Encoding:    EncodingCBOR,
+		Mid:         "test-msg-003",
+		From:        "sensor-01",
+		Correlation: "req-1",
+		Ts:          time.Now().UnixMilli(),
+		Data:        []byte{0xa1, 0x64, 't', 'e', 'x', 't', 0x62, 'h', 'i'},
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := codec.Encode(&buf, envelope); err != nil {
+			t.Fatalf("CBOR encode failed: %v", err)
+		}
+		var decoded Envelope
+		if err := codec.Decode(&buf, &decoded, 1024); err != nil {
+			t.Fatalf("CBOR decode failed: %v", err)
+		}
+		if decoded.Type != envelope.Type || decoded.Mid != envelope.Mid || decoded.From != envelope.From {
+			t.Errorf("Envelope mismatch: got %+v, want %+v", decoded, *envelope)
+		}
+		if decoded.Encoding != EncodingCBOR {
+			t.Errorf("Encoding mismatch: got %s, want %s", decoded.Encoding, EncodingCBOR)
+		}
+		if !bytes.Equal(decoded.Data, envelope.Data) {
+			t.Errorf("Data mismatch: got %x, want %x", decoded.Data, envelope.Data)
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		var a, b bytes.Buffer
+		if err := codec.Encode(&a, envelope); err != nil {
+			t.Fatalf("CBOR encode failed: %v", err)
+		}
+		clone := *envelope
+		if err := codec.Encode(&b, &clone); err != nil {
+			t.Fatalf("CBOR encode failed: %v", err)
+		}
+		if !bytes.Equal(a.Bytes(), b.Bytes()) {
+			t.Errorf("encoding is not deterministic:\n%x\n%x", a.Bytes(), b.Bytes())
+		}
+	})
+
+	t.Run("MaxSize", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := codec.Encode(&buf, envelope); err != nil {
+			t.Fatalf("CBOR encode failed: %v", err)
+		}
+		var decoded Envelope
+		if err := codec.Decode(bytes.NewReader(buf.Bytes()), &decoded, buf.Len()-1); err == nil {
+			t.Errorf("expected error when payload exceeds max size")
+		}
+		if err := codec.Decode(bytes.NewReader(buf.Bytes()), &decoded, buf.Len()); err != nil {
+			t.Errorf("payload of exactly max size should decode: %v", err)
+		}
+	})
+
+	t.Run("RejectMalformed", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := codec.Encode(&buf, envelope); err != nil {
+			t.Fatalf("CBOR encode failed: %v", err)
+		}
+		inputs := map[string][]byte{
+			"empty":         {},
+			"trailing data": append(append([]byte{}, buf.Bytes()...), 0x00),
+			"duplicate key": {0xa2, 0x64, 't', 'y', 'p', 'e', 0x61, 'a', 0x64, 't', 'y', 'p', 'e', 0x61, 'b'},
+			"indefinite":    {0xbf, 0x64, 't', 'y', 'p', 'e', 0x61, 'a', 0xff},
+			"missing type":  {0xa1, 0x63, 'm', 'i', 'd', 0x61, 'x'},
+		}
+		for name, in := range inputs {
+			var decoded Envelope
+			if err := codec.Decode(bytes.NewReader(in), &decoded, 1024); err == nil {
+				t.Errorf("%s: expected decode error", name)
+			}
+		}
+	})
+}
+
+// TestDetectCodec 测试根据首帧嗅探编解码器
+func TestDetectCodec(t *testing.T) {
+	envelope := &Envelope{
+		Version:  "1.0",
+		Type:     MsgHello,
+		Encoding: EncodingJSON,
+		Mid:      "hello-001",
+		Ts:       time.Now().UnixMilli(),
+		Data:     []byte(`{"codecs":["json"]}`),
+	}
+	for _, c := range []int{CodecJson, CodecProtobuf, CodecCbor} {
+		codec, err := NewCodec(c)
+		if err != nil {
+			t.Fatalf("create codec %d: %v", c, err)
+		}
+		var buf bytes.Buffer
+		if err := codec.Encode(&buf, envelope); err != nil {
+			t.Fatalf("%s encode failed: %v", codec.Name(), err)
+		}
+		got, ok := DetectCodec(buf.Bytes())
+		if !ok || got != c {
+			t.Errorf("%s: detected %d (ok=%v), want %d", codec.Name(), got, ok, c)
+		}
+	}
+
+	// 空帧、字段号 0、CBOR 数组/tag 等均无法识别
+	for _, in := range [][]byte{nil, {0x00}, {0x80}, {0xc0}, {0xff}} {
+		if c, ok := DetectCodec(in); ok {
+			t.Errorf("unexpected detection %d for %x", c, in)
+		}
+	}
+}
@@ -7,12 +7,19 @@ type Envelope struct {
 	Encoding Encoding    `json:"encoding"` // payload 编码方式
 
 	// ---- 路由与可靠性 ----
-	From        string `json:"from"`
-	Mid         string `json:"mid"`            // 消息唯一ID
-	Correlation string `json:"correlation_id"` // 相关请求ID
-	Ts          int64  `json:"ts"`             // 毫秒时间戳
+	From        string   `json:"from"`
+	To          string   `json:"to,omitempty"`         // 单一接收者
+	Recipients  []string `json:"recipients,omitempty"` // 多接收者列表
+	Room        string   `json:"room,omitempty"`       // 所属房间
+	Mid         string   `json:"mid"`                  // 消息唯一ID
+	Correlation string   `json:"correlation_id"`       // 相关请求ID
+	Seq         int64    `json:"seq,omitempty"`        // 会话/房间内序号
+	Ts          int64    `json:"ts"`                   // 毫秒时间戳
 
 	Data []byte `json:"data,omitempty"` // 原始数据
+
+	// ---- 扩展 ----
+	Ext map[string]string `json:"ext,omitempty"` // v2 扩展头，v1 会话出站时剥离
 }
 
 // TextPayload 纯文本消息负载
@@ -22,7 +29,13 @@ type TextPayload struct {
 
 // SetNickPayload 设置昵称消息负载
 type SetNickPayload struct {
-	Nick string `json:"nick"`
+	Nick   string `json:"nick"`
+	Locale string `json:"locale,omitempty"` // 会话语言，如 zh-CN、en；为空时使用服务端默认语言
+}
+
+// SetNamePayload 设置用户名消息负载（旧版客户端的 set_name）
+type SetNamePayload struct {
+	Name string `json:"name"`
 }
 
 // ChatPayload 聊天消息负载
@@ -38,12 +51,61 @@ type CommandPayload struct {
 // AckPayload 确认消息负载
 type AckPayload struct {
 	Status string `json:"status"`
+	User   string `json:"user,omitempty"` // 私信回执：送达或已读的接收者
 }
 
-// DirectPayload 私聊消息负载
+// 私信投递状态，以 ack 消息的 status 推送给发送者，correlation_id 为私信的 mid
+const (
+	AckSent      = "sent"      // 服务端已接收
+	AckDelivered = "delivered" // 已投递到接收者的会话
+	AckRead      = "read"      // 接收者已读（累计：该消息及之前发给同一接收者的私信均已读）
+)
+
+// DirectPayload 私聊消息负载：to 有多个接收者时为群组私信。
+// 服务端推送时 to 为全部接收者，conversation 为由参与者集合确定的会话 ID，客户端发送时不需要填写
 type DirectPayload struct {
-	To      []string `json:"to"`
-	Content string   `json:"content"`
+	To           []string `json:"to"`
+	Content      string   `json:"content"`
+	Conversation string   `json:"conversation,omitempty"`
+}
+
+// PresencePayload 在线状态消息负载：服务端推送用户状态变化，客户端发送时用于设置自身状态
+type PresencePayload struct {
+	User     string `json:"user"`                // 用户昵称，客户端设置自身状态时可为空
+	Status   string `json:"status"`              // online、away、busy、invisible 或 offline
+	Text     string `json:"text,omitempty"`      // 自定义状态文本
+	LastSeen int64  `json:"last_seen,omitempty"` // 离线用户最后在线时间（Unix 毫秒）
+}
+
+// TypingPayload 正在输入提示负载：客户端发送开始/停止输入，服务端转发给相关接收者，不落盘
+type TypingPayload struct {
+	User   string `json:"user"`         // 正在输入的用户，客户端发送时可为空
+	To     string `json:"to,omitempty"` // 私聊对象，为空表示聊天室
+	Active bool   `json:"active"`       // true 开始输入，false 停止输入
+}
+
+// ReadPayload 已读标记负载：接收者标记 peer 发来的私信已读到 up_to（含）为止
+type ReadPayload struct {
+	Peer string `json:"peer"`  // 私信发送者
+	UpTo string `json:"up_to"` // 已读到的私信 mid
+}
+
+// EditPayload 编辑消息负载：客户端发送被编辑消息的 mid 与新内容；
+// 服务端推送给原消息的所有接收者时带 edited 标记、编辑者、编辑时间与之前的各个版本
+type EditPayload struct {
+	Mid      string   `json:"mid"` // 被编辑消息的 mid
+	Content  string   `json:"content"`
+	Edited   bool     `json:"edited,omitempty"`
+	By       string   `json:"by,omitempty"`        // 编辑者，管理员编辑他人消息时与作者不同
+	EditedAt int64    `json:"edited_at,omitempty"` // 编辑时间（Unix 毫秒）
+	History  []string `json:"history,omitempty"`   // 之前的各个版本，最早的在前
+}
+
+// DeletePayload 删除消息负载：客户端发送被删除消息的 mid；服务端推送墓碑，只有删除者与删除时间，不含原内容
+type DeletePayload struct {
+	Mid       string `json:"mid"` // 被删除消息的 mid
+	By        string `json:"by,omitempty"`
+	DeletedAt int64  `json:"deleted_at,omitempty"` // 删除时间（Unix 毫秒）
 }
 
 // PingPayload 心跳 ping 消息负载
@@ -74,3 +136,27 @@ type FileChunkPayload struct {
 	IsLast   bool   `json:"is_last"`
 	Checksum string `json:"checksum"`
 }
+
+// HelloPayload 握手消息负载
+// 客户端发送时声明自身支持的编解码器与协议版本；
+// 服务端回复时附带全部支持项以及本会话选定的 Codec/Version。
+type HelloPayload struct {
+	Codecs   []string `json:"codecs"`
+	Versions []string `json:"versions"`
+	Codec    string   `json:"codec,omitempty"`
+	Version  string   `json:"version,omitempty"`
+}
+
+// ErrorPayload 错误消息负载
+type ErrorPayload struct {
+	Code    ErrorCode `json:"code"`            // 稳定的数值错误码，见 error_code.go
+	Reason  string    `json:"reason"`          // 机器可读的错误原因
+	Message string    `json:"message"`         // 面向用户的描述
+	Rule    string    `json:"rule,omitempty"`  // 校验失败时违反的规则
+	Field   string    `json:"field,omitempty"` // 校验失败的字段
+}
+
+// Err 还原为 *Error，便于客户端用 errors.Is 匹配错误码
+func (p *ErrorPayload) Err() *Error {
+	return &Error{Code: p.Code, Message: p.Message}
+}
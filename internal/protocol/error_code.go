@@ -0,0 +1,161 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrorCode 稳定的数值错误码，随 error 消息返回给客户端，客户端 SDK 按错误码分支处理。
+// 错误码一经发布不得修改含义或复用；新增错误码必须登记到 errorCatalogue。
+type ErrorCode int
+
+// 1xxx 传输层
+const (
+	CodeSessionContextClosed ErrorCode = 1001
+	CodeSessionClosed        ErrorCode = 1002
+	CodeSessionNotFound      ErrorCode = 1003
+	CodeInvalidFrame         ErrorCode = 1004
+	CodeFrameTooLarge        ErrorCode = 1005
+	CodeConnectionLost       ErrorCode = 1006
+	CodeUnknownCodec         ErrorCode = 1007
+	CodeServerShutdown       ErrorCode = 1008
+	CodeServerFull           ErrorCode = 1009
+	CodeIPLimit              ErrorCode = 1010
+	CodeRateLimited          ErrorCode = 1011
+)
+
+// 2xxx 协议与网关
+const (
+	CodeUnsupportedVersion ErrorCode = 2001
+	CodeBadHello           ErrorCode = 2002
+	CodeInvalidMessage     ErrorCode = 2003
+	CodeUnknownType        ErrorCode = 2004
+	CodeNotLoggedIn        ErrorCode = 2005
+)
+
+// 3xxx 命令
+const (
+	CodeCommandNotFound  ErrorCode = 3001
+	CodePermissionDenied ErrorCode = 3002
+	CodeBadArguments     ErrorCode = 3003
+	CodeCommandFailed    ErrorCode = 3004
+)
+
+// 4xxx 聊天业务
+const (
+	CodeBanned          ErrorCode = 4001
+	CodeMessageNotFound ErrorCode = 4002
+	CodeEditExpired     ErrorCode = 4003
+	CodeDuplicateID     ErrorCode = 4004
+	CodeNameInUse       ErrorCode = 4005
+	CodeNameReserved    ErrorCode = 4006
+)
+
+// 5xxx 服务端内部
+const (
+	CodeInternal ErrorCode = 5000
+)
+
+// errorCatalogue 错误码目录：机器可读原因与默认描述
+var errorCatalogue = map[ErrorCode]struct {
+	reason  string
+	message string
+}{
+	CodeSessionContextClosed: {"session_context_closed", "Session context is closed"},
+	CodeSessionClosed:        {"session_closed", "session is closed"},
+	CodeSessionNotFound:      {"session_not_found", "session not found"},
+	CodeInvalidFrame:         {"invalid_frame", "invalid frame format"},
+	CodeFrameTooLarge:        {"frame_too_large", "frame size exceeds maximum allowed"},
+	CodeConnectionLost:       {"connection_lost", "connection lost"},
+	CodeUnknownCodec:         {"unknown_codec", "unable to detect codec from first frame"},
+	CodeServerShutdown:       {"server_shutdown", "server is shutting down"},
+	CodeServerFull:           {"server_full", "server connection limit reached"},
+	CodeIPLimit:              {"ip_limit", "too many connections from your address"},
+	CodeRateLimited:          {"rate_limited", "too many new connections, retry later"},
+
+	CodeUnsupportedVersion: {"unsupported_version", "unsupported protocol version"},
+	CodeBadHello:           {"bad_hello", "malformed hello"},
+	CodeInvalidMessage:     {"invalid_message", "message failed validation"},
+	CodeUnknownType:        {"unknown_type", "no handler for message type"},
+	CodeNotLoggedIn:        {"not_logged_in", "set a nickname first"},
+
+	CodeCommandNotFound:  {"command_not_found", "command not found"},
+	CodePermissionDenied: {"permission_denied", "permission denied"},
+	CodeBadArguments:     {"bad_arguments", "bad command arguments"},
+	CodeCommandFailed:    {"command_failed", "command failed"},
+
+	CodeBanned:          {"banned", "user is banned"},
+	CodeMessageNotFound: {"message_not_found", "message not found or no longer editable"},
+	CodeEditExpired:     {"edit_expired", "edit window has expired"},
+	CodeDuplicateID:     {"duplicate_message_id", "message id is already used by another sender"},
+	CodeNameInUse:       {"name_in_use", "nickname is already in use"},
+	CodeNameReserved:    {"name_reserved", "nickname is reserved for an authenticated user"},
+
+	CodeInternal: {"internal", "internal server error"},
+}
+
+// Reason 错误码对应的机器可读原因，未登记的错误码返回 "unknown"
+func (c ErrorCode) Reason() string {
+	if info, ok := errorCatalogue[c]; ok {
+		return info.reason
+	}
+	return "unknown"
+}
+
+func (c ErrorCode) String() string {
+	return fmt.Sprintf("%d(%s)", int(c), c.Reason())
+}
+
+// ErrorCodes 返回目录中的全部错误码，升序
+func ErrorCodes() []ErrorCode {
+	out := make([]ErrorCode, 0, len(errorCatalogue))
+	for c := range errorCatalogue {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Error 带错误码的错误，在传输层、命令与网关之间传递，最终由网关转换为 error 消息
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+// NewError 创建带错误码的错误，message 为空时使用目录中的默认描述
+func NewError(code ErrorCode, message string) *Error {
+	if message == "" {
+		message = errorCatalogue[code].message
+	}
+	return &Error{Code: code, Message: message}
+}
+
+// Errorf 按格式创建带错误码的错误
+func Errorf(code ErrorCode, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Error %d: %s", int(e.Code), e.Message)
+}
+
+// Is 错误码相同即视为同一错误，便于 errors.Is 匹配 ErrUnsupportedVersion 等哨兵错误
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Reason 错误的机器可读原因
+func (e *Error) Reason() string {
+	return e.Code.Reason()
+}
+
+// AsError 从错误链中取出 *Error；不带错误码的错误归为 fallback，描述取 err.Error()
+func AsError(err error, fallback ErrorCode) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Code: fallback, Message: err.Error()}
+}
@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// publishedErrorCodes 已发布的错误码及原因，一经发布不得修改或复用
+var publishedErrorCodes = map[ErrorCode]string{
+	1001: "session_context_closed",
+	1002: "session_closed",
+	1003: "session_not_found",
+	1004: "invalid_frame",
+	1005: "frame_too_large",
+	1006: "connection_lost",
+	1007: "unknown_codec",
+	1008: "server_shutdown",
+	1009: "server_full",
+	1010: "ip_limit",
+	1011: "rate_limited",
+	2001: "unsupported_version",
+	2002: "bad_hello",
+	2003: "invalid_message",
+	2004: "unknown_type",
+	2005: "not_logged_in",
+	3001: "command_not_found",
+	3002: "permission_denied",
+	3003: "bad_arguments",
+	3004: "command_failed",
+	4001: "banned",
+	4002: "message_not_found",
+	4003: "edit_expired",
+	4004: "duplicate_message_id",
+	4005: "name_in_use",
+	4006: "name_reserved",
+	5000: "internal",
+}
+
+// TestErrorCatalogueStable 错误码与原因稳定且一一对应，新增错误码必须登记
+func TestErrorCatalogueStable(t *testing.T) {
+	reasons := make(map[string]ErrorCode)
+	for _, c := range ErrorCodes() {
+		want, ok := publishedErrorCodes[c]
+		if !ok {
+			t.Errorf("new error code %d (%s) must be registered in publishedErrorCodes", c, c.Reason())
+			continue
+		}
+		if c.Reason() != want {
+			t.Errorf("error code %d reason changed: got %q, want %q", c, c.Reason(), want)
+		}
+		if prev, dup := reasons[c.Reason()]; dup {
+			t.Errorf("error codes %d and %d share reason %q", prev, c, c.Reason())
+		}
+		reasons[c.Reason()] = c
+	}
+	if len(ErrorCodes()) < len(publishedErrorCodes) {
+		t.Errorf("error codes removed: got %d, want at least %d", len(ErrorCodes()), len(publishedErrorCodes))
+	}
+}
+
+// TestErrorMatching 相同错误码的错误可被 errors.Is 匹配，AsError 对无错误码的错误使用回退码
+func TestErrorMatching(t *testing.T) {
+	_, err := AdapterFor("9.0")
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("errors.Is(%v, ErrUnsupportedVersion) = false", err)
+	}
+	wrapped := fmt.Errorf("negotiate: %w", err)
+	if e := AsError(wrapped, CodeInternal); e.Code != CodeUnsupportedVersion {
+		t.Errorf("AsError(wrapped) code = %d, want %d", e.Code, CodeUnsupportedVersion)
+	}
+	if e := AsError(errors.New("boom"), CodeCommandFailed); e.Code != CodeCommandFailed || e.Message != "boom" {
+		t.Errorf("AsError(plain) = %+v", e)
+	}
+	if e := NewError(CodeBanned, ""); e.Message == "" || e.Reason() != "banned" {
+		t.Errorf("NewError default message/reason = %+v", e)
+	}
+}
+
+// TestErrorMessage 错误消息负载携带错误码、原因与关联 ID
+func TestErrorMessage(t *testing.T) {
+	for _, enc := range []Encoding{EncodingJSON, EncodingProtobuf, EncodingCBOR} {
+		env := NewMessageFactoryWithEncoding(enc).CreateErrorMessage(NewError(CodePermissionDenied, ""), "req-1")
+		p, err := DecodePayload[ErrorPayload](env)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", enc, err)
+		}
+		if p.Code != CodePermissionDenied || p.Reason != "permission_denied" || env.Correlation != "req-1" {
+			t.Errorf("%s: got %+v correlation=%q", enc, p, env.Correlation)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package protocol
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,157 +8,221 @@ import (
 
 // MessageFactory 负责创建各种类型的消息，统一消息创建逻辑
 type MessageFactory struct {
-	version string
+	version  string
+	encoding Encoding // 负载编码方式
 }
 
-// NewMessageFactory 创建消息工厂
+// NewMessageFactory 创建消息工厂，负载使用 JSON 编码
 func NewMessageFactory() *MessageFactory {
+	return NewMessageFactoryWithEncoding(EncodingJSON)
+}
+
+// NewMessageFactoryWithEncoding 创建以指定编码序列化负载的消息工厂
+func NewMessageFactoryWithEncoding(encoding Encoding) *MessageFactory {
 	return &MessageFactory{
-		version: "1.0",
+		version:  Version,
+		encoding: encoding,
 	}
 }
 
-// CreateTextMessage 创建文本消息
-func (f *MessageFactory) CreateTextMessage(text string) *Envelope {
-	payload := &TextPayload{Text: text}
-	data, _ := json.Marshal(payload)
-	return &Envelope{
+// newEnvelope 创建信封并按工厂的负载编码写入负载
+func (f *MessageFactory) newEnvelope(t MessageType, payload any) *Envelope {
+	e := &Envelope{
 		Version:  f.version,
-		Type:     MsgText,
-		Encoding: EncodingJSON,
+		Type:     t,
+		Encoding: f.encoding,
 		Mid:      uuid.New().String(),
 		Ts:       time.Now().UnixMilli(),
-		Data:     data,
 	}
+	// 负载类型均已在 init 中注册，编码失败只可能是编程错误
+	if err := EncodePayload(e, payload); err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// CreateTextMessage 创建文本消息
+func (f *MessageFactory) CreateTextMessage(text string) *Envelope {
+	return f.newEnvelope(MsgText, &TextPayload{Text: text})
 }
 
 // CreateSetNickMessage 创建设置昵称消息
 func (f *MessageFactory) CreateSetNickMessage(nick string) *Envelope {
-	payload := SetNickPayload{Nick: nick}
-	data, _ := json.Marshal(payload)
+	return f.newEnvelope(MsgNick, &SetNickPayload{Nick: nick})
+}
 
-	return &Envelope{
-		Version:  f.version,
-		Type:     MsgText,
-		Encoding: EncodingJSON,
-		Mid:      uuid.New().String(),
-		Ts:       time.Now().UnixMilli(),
-		Data:     data,
-	}
+// CreateLoginMessage 创建设置昵称并声明会话语言的消息
+func (f *MessageFactory) CreateLoginMessage(nick string, locale string) *Envelope {
+	return f.newEnvelope(MsgNick, &SetNickPayload{Nick: nick, Locale: locale})
 }
 
 // CreateCommandMessage 创建命令消息
 func (f *MessageFactory) CreateCommandMessage(command string) *Envelope {
-	payload := CommandPayload{Raw: command}
-	data, _ := json.Marshal(payload)
-
-	return &Envelope{
-		Version:  f.version,
-		Type:     MsgCommand,
-		Encoding: EncodingJSON,
-		Mid:      uuid.New().String(),
-		Ts:       time.Now().UnixMilli(),
-		Data:     data,
-	}
+	return f.newEnvelope(MsgCommand, &CommandPayload{Raw: command})
 }
 
 // CreateAckMessage 创建确认消息
 func (f *MessageFactory) CreateAckMessage(status string, correlationID string) *Envelope {
-	payload := AckPayload{Status: status}
-	data, _ := json.Marshal(payload)
-
-	return &Envelope{
-		Version:     f.version,
-		Type:        MsgAck,
-		Encoding:    EncodingJSON,
-		Mid:         uuid.New().String(),
-		Correlation: correlationID,
-		Ts:          time.Now().UnixMilli(),
-		Data:        data,
-	}
+	e := f.newEnvelope(MsgAck, &AckPayload{Status: status})
+	e.Correlation = correlationID
+	return e
+}
+
+// CreateReceiptMessage 创建私信投递回执：status 为 AckSent、AckDelivered 或 AckRead，user 为接收者，
+// correlationID 为私信的 mid
+func (f *MessageFactory) CreateReceiptMessage(status, user, correlationID string) *Envelope {
+	e := f.newEnvelope(MsgAck, &AckPayload{Status: status, User: user})
+	e.Correlation = correlationID
+	return e
+}
+
+// CreateReadMessage 创建已读标记消息：peer 发来的私信已读到 upTo（含）为止
+func (f *MessageFactory) CreateReadMessage(peer, upTo string) *Envelope {
+	return f.newEnvelope(MsgRead, &ReadPayload{Peer: peer, UpTo: upTo})
+}
+
+// CreateEditMessage 创建编辑消息：将 mid 对应消息的内容改为 content
+func (f *MessageFactory) CreateEditMessage(mid, content string) *Envelope {
+	return f.newEnvelope(MsgEdit, &EditPayload{Mid: mid, Content: content})
+}
+
+// CreateEditedMessage 创建推送给接收者的编辑通知：from 为原消息作者，by 为编辑者，
+// editedAt 为编辑时间（Unix 毫秒），history 为之前的各个版本
+func (f *MessageFactory) CreateEditedMessage(mid, from, by, content string, editedAt int64, history []string) *Envelope {
+	e := f.newEnvelope(MsgEdit, &EditPayload{
+		Mid:      mid,
+		Content:  content,
+		Edited:   true,
+		By:       by,
+		EditedAt: editedAt,
+		History:  history,
+	})
+	e.From = from
+	return e
+}
+
+// CreateDeleteMessage 创建删除消息：删除 mid 对应的消息
+func (f *MessageFactory) CreateDeleteMessage(mid string) *Envelope {
+	return f.newEnvelope(MsgDelete, &DeletePayload{Mid: mid})
+}
+
+// CreateDeletedMessage 创建推送给接收者的删除墓碑：from 为原消息作者，by 为删除者，deletedAt 为删除时间（Unix 毫秒）
+func (f *MessageFactory) CreateDeletedMessage(mid, from, by string, deletedAt int64) *Envelope {
+	e := f.newEnvelope(MsgDelete, &DeletePayload{Mid: mid, By: by, DeletedAt: deletedAt})
+	e.From = from
+	return e
 }
 
 // CreatePingMessage 创建心跳ping消息
 func (f *MessageFactory) CreatePingMessage(seq int64) *Envelope {
-	payload := PingPayload{
+	return f.newEnvelope(MsgPing, &PingPayload{
 		Seq:       seq,
 		Timestamp: time.Now().UnixMilli(),
-	}
-	data, _ := json.Marshal(payload)
-
-	return &Envelope{
-		Version:  f.version,
-		Type:     MsgPing,
-		Encoding: EncodingJSON,
-		Mid:      uuid.New().String(),
-		Ts:       time.Now().UnixMilli(),
-		Data:     data,
-	}
+	})
 }
 
 // CreatePongMessage 创建心跳pong消息
 func (f *MessageFactory) CreatePongMessage(seq int64, correlationID string) *Envelope {
-	payload := PongPayload{
+	e := f.newEnvelope(MsgPong, &PongPayload{
 		Seq:       seq,
 		Timestamp: time.Now().UnixMilli(),
-	}
-	data, _ := json.Marshal(payload)
-
-	return &Envelope{
-		Version:     f.version,
-		Type:        MsgPong,
-		Encoding:    EncodingJSON,
-		Mid:         uuid.New().String(),
-		Correlation: correlationID,
-		Ts:          time.Now().UnixMilli(),
-		Data:        data,
-	}
+	})
+	e.Correlation = correlationID
+	return e
 }
 
 // CreateDirectMessage 创建私聊消息
 func (f *MessageFactory) CreateDirectMessage(from string, to []string, content string) *Envelope {
-	payload := DirectPayload{
+	e := f.newEnvelope(MsgDirect, &DirectPayload{
 		To:      to,
 		Content: content,
-	}
-	data, _ := json.Marshal(payload)
+	})
+	e.From = from
+	e.Recipients = to
+	return e
+}
 
-	return &Envelope{
-		Version:  f.version,
-		Type:     MsgText,
-		Encoding: EncodingJSON,
-		From:     from,
-		Mid:      uuid.New().String(),
-		Ts:       time.Now().UnixMilli(),
-		Data:     data,
-	}
+// CreateConversationMessage 创建服务端推送的私信：to 为全部接收者，conversation 为会话 ID
+func (f *MessageFactory) CreateConversationMessage(conversation, from string, to []string, content string) *Envelope {
+	e := f.newEnvelope(MsgDirect, &DirectPayload{
+		To:           to,
+		Content:      content,
+		Conversation: conversation,
+	})
+	e.From = from
+	e.Recipients = to
+	return e
+}
+
+// CreatePresenceMessage 创建在线状态消息，lastSeen 为离线用户最后在线时间（Unix 毫秒），在线时为 0
+func (f *MessageFactory) CreatePresenceMessage(user, status, text string, lastSeen int64) *Envelope {
+	e := f.newEnvelope(MsgPresence, &PresencePayload{
+		User:     user,
+		Status:   status,
+		Text:     text,
+		LastSeen: lastSeen,
+	})
+	e.From = user
+	return e
+}
+
+// CreateTypingMessage 创建正在输入提示消息，to 为空表示聊天室
+func (f *MessageFactory) CreateTypingMessage(user, to string, active bool) *Envelope {
+	e := f.newEnvelope(MsgTyping, &TypingPayload{
+		User:   user,
+		To:     to,
+		Active: active,
+	})
+	e.From = user
+	return e
 }
 
 // CreateFileMetaMessage 创建文件元数据消息
 func (f *MessageFactory) CreateFileMetaMessage(from string, meta FileMetaPayload) *Envelope {
-	data, _ := json.Marshal(meta)
-
-	return &Envelope{
-		Version:  f.version,
-		Type:     MsgFileMeta,
-		Encoding: EncodingJSON,
-		Mid:      uuid.New().String(),
-		From:     from,
-		Ts:       time.Now().UnixMilli(),
-		Data:     data,
-	}
+	e := f.newEnvelope(MsgFileMeta, &meta)
+	e.From = from
+	return e
 }
 
 // CreateFileChunkMessage 创建文件分片消息
 func (f *MessageFactory) CreateFileChunkMessage(from string, chunk FileChunkPayload) *Envelope {
-	data, _ := json.Marshal(chunk)
-	return &Envelope{
-		Version:  f.version,
-		Type:     MsgFileChunk,
-		Encoding: EncodingJSON,
-		Mid:      uuid.New().String(),
-		From:     from,
-		Ts:       time.Now().UnixMilli(),
-		Data:     data,
-	}
+	e := f.newEnvelope(MsgFileChunk, &chunk)
+	e.From = from
+	return e
+}
+
+// CreateHelloMessage 创建服务端握手消息，通告支持的编解码器与协议版本，并告知本会话选定的编解码器与版本
+func (f *MessageFactory) CreateHelloMessage(codec string, version string, correlationID string) *Envelope {
+	e := f.newEnvelope(MsgHello, &HelloPayload{
+		Codecs:   SupportedCodecs(),
+		Versions: SupportedVersions(),
+		Codec:    codec,
+		Version:  version,
+	})
+	e.Correlation = correlationID
+	return e
+}
+
+// CreateErrorMessage 创建错误消息，correlationID 指向出错的请求
+func (f *MessageFactory) CreateErrorMessage(err *Error, correlationID string) *Envelope {
+	e := f.newEnvelope(MsgError, &ErrorPayload{
+		Code:    err.Code,
+		Reason:  err.Reason(),
+		Message: err.Message,
+	})
+	e.Correlation = correlationID
+	return e
+}
+
+// CreateValidationErrorMessage 创建入站消息校验失败的错误消息，负载中给出违反的规则与字段
+func (f *MessageFactory) CreateValidationErrorMessage(verr *ValidationError, correlationID string) *Envelope {
+	e := f.newEnvelope(MsgError, &ErrorPayload{
+		Code:    CodeInvalidMessage,
+		Reason:  CodeInvalidMessage.Reason(),
+		Message: verr.Message,
+		Rule:    verr.Rule,
+		Field:   verr.Field,
+	})
+	e.Correlation = correlationID
+	return e
 }
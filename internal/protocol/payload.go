@@ -0,0 +1,272 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/hongjun500/chat-go/internal/protocol/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// 负载编解码错误
+var (
+	ErrUnknownPayload      = errors.New("no payload registered for message type")
+	ErrPayloadTypeMismatch = errors.New("payload type does not match message type")
+	ErrUnsupportedEncoding = errors.New("unsupported payload encoding")
+)
+
+// payloadEntry 消息类型对应的负载 Go 类型与 protobuf 消息类型
+type payloadEntry struct {
+	goType reflect.Type
+	pbType protoreflect.MessageType
+}
+
+var (
+	payloadMu       sync.RWMutex
+	payloadRegistry = make(map[MessageType]payloadEntry)
+)
+
+func init() {
+	RegisterPayload[SetNickPayload](MsgNick, &pb.SetNickPayload{})
+	RegisterPayload[TextPayload](MsgText, &pb.TextPayload{})
+	RegisterPayload[CommandPayload](MsgCommand, &pb.CommandPayload{})
+	RegisterPayload[DirectPayload](MsgDirect, &pb.DirectPayload{})
+	RegisterPayload[FileMetaPayload](MsgFileMeta, &pb.FileMetaPayload{})
+	RegisterPayload[FileChunkPayload](MsgFileChunk, &pb.FileChunkPayload{})
+	RegisterPayload[AckPayload](MsgAck, &pb.AckPayload{})
+	RegisterPayload[PingPayload](MsgPing, &pb.PingPayload{})
+	RegisterPayload[PongPayload](MsgPong, &pb.PongPayload{})
+	RegisterPayload[HelloPayload](MsgHello, &pb.HelloPayload{})
+	RegisterPayload[ErrorPayload](MsgError, &pb.ErrorPayload{})
+	RegisterPayload[PresencePayload](MsgPresence, &pb.PresencePayload{})
+	RegisterPayload[TypingPayload](MsgTyping, &pb.TypingPayload{})
+	RegisterPayload[ReadPayload](MsgRead, &pb.ReadPayload{})
+	RegisterPayload[EditPayload](MsgEdit, &pb.EditPayload{})
+	RegisterPayload[DeletePayload](MsgDelete, &pb.DeletePayload{})
+}
+
+// RegisterPayload 为消息类型注册负载结构体 T 及其 protobuf 消息。
+// T 的每个字段按 JSON tag 名称对应 protobuf 字段，映射不完整时 panic，便于在启动时发现问题。
+func RegisterPayload[T any](t MessageType, pbMsg proto.Message) {
+	goType := reflect.TypeOf((*T)(nil)).Elem()
+	pbType := pbMsg.ProtoReflect().Type()
+	if err := checkPayloadMapping(goType, pbType.Descriptor()); err != nil {
+		panic(fmt.Sprintf("register payload %s: %v", t, err))
+	}
+	payloadMu.Lock()
+	defer payloadMu.Unlock()
+	payloadRegistry[t] = payloadEntry{goType: goType, pbType: pbType}
+}
+
+func lookupPayload(t MessageType) (payloadEntry, bool) {
+	payloadMu.RLock()
+	defer payloadMu.RUnlock()
+	entry, ok := payloadRegistry[t]
+	return entry, ok
+}
+
+// PayloadType 返回消息类型注册的负载 Go 类型
+func PayloadType(t MessageType) (reflect.Type, bool) {
+	entry, ok := lookupPayload(t)
+	return entry.goType, ok
+}
+
+// EncodePayload 按 e.Encoding 序列化负载并写入 e.Data，Encoding 为空时使用 JSON。
+// 负载类型必须与 e.Type 注册的类型一致。
+func EncodePayload(e *Envelope, v any) error {
+	entry, ok := lookupPayload(e.Type)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPayload, e.Type)
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Type() != entry.goType {
+		return fmt.Errorf("%w: %s expects %s, got %s", ErrPayloadTypeMismatch, e.Type, entry.goType, rv.Type())
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	switch e.Encoding {
+	case "", EncodingJSON:
+		e.Encoding = EncodingJSON
+		data, err = json.Marshal(rv.Interface())
+	case EncodingProtobuf:
+		m := entry.pbType.New()
+		goToProto(rv, m)
+		data, err = proto.Marshal(m.Interface())
+	case EncodingCBOR:
+		data, err = cborEncMode.Marshal(rv.Interface())
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, e.Encoding)
+	}
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", e.Type, err)
+	}
+	e.Data = data
+	return nil
+}
+
+// DecodePayload 按 e.Encoding 将 e.Data 解码为 T，T 必须是 e.Type 注册的负载类型
+func DecodePayload[T any](e *Envelope) (*T, error) {
+	entry, ok := lookupPayload(e.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayload, e.Type)
+	}
+	out := new(T)
+	if goType := reflect.TypeOf(out).Elem(); goType != entry.goType {
+		return nil, fmt.Errorf("%w: %s expects %s, got %s", ErrPayloadTypeMismatch, e.Type, entry.goType, goType)
+	}
+	if err := decodePayloadInto(e, entry, reflect.ValueOf(out).Elem()); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DecodePayloadAny 解码为 e.Type 注册的负载类型，返回指向该类型的指针
+func DecodePayloadAny(e *Envelope) (any, error) {
+	entry, ok := lookupPayload(e.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayload, e.Type)
+	}
+	out := reflect.New(entry.goType)
+	if err := decodePayloadInto(e, entry, out.Elem()); err != nil {
+		return nil, err
+	}
+	return out.Interface(), nil
+}
+
+func decodePayloadInto(e *Envelope, entry payloadEntry, rv reflect.Value) error {
+	var err error
+	switch e.Encoding {
+	case "", EncodingJSON:
+		err = json.Unmarshal(e.Data, rv.Addr().Interface())
+	case EncodingProtobuf:
+		m := entry.pbType.New()
+		if err = proto.Unmarshal(e.Data, m.Interface()); err == nil {
+			protoToGo(m, rv)
+		}
+	case EncodingCBOR:
+		err = cborDecMode.Unmarshal(e.Data, rv.Addr().Interface())
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, e.Encoding)
+	}
+	if err != nil {
+		return fmt.Errorf("decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// --- Go 结构体与 protobuf 消息之间的转换 ---
+
+// payloadFieldName 负载字段的线上名称，取 JSON tag
+func payloadFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// checkPayloadMapping 校验 Go 结构体与 protobuf 消息字段一一对应且类型兼容
+func checkPayloadMapping(goType reflect.Type, desc protoreflect.MessageDescriptor) error {
+	if goType.Kind() != reflect.Struct {
+		return fmt.Errorf("%s is not a struct", goType)
+	}
+	if goType.NumField() != desc.Fields().Len() {
+		return fmt.Errorf("%s has %d fields, %s has %d", goType, goType.NumField(), desc.FullName(), desc.Fields().Len())
+	}
+	for i := 0; i < goType.NumField(); i++ {
+		f := goType.Field(i)
+		fd := desc.Fields().ByName(protoreflect.Name(payloadFieldName(f)))
+		if fd == nil {
+			return fmt.Errorf("%s.%s has no protobuf field %q", goType, f.Name, payloadFieldName(f))
+		}
+		if !compatibleKind(f.Type, fd) {
+			return fmt.Errorf("%s.%s (%s) is incompatible with %s (%s)", goType, f.Name, f.Type, fd.FullName(), fd.Kind())
+		}
+	}
+	return nil
+}
+
+func compatibleKind(t reflect.Type, fd protoreflect.FieldDescriptor) bool {
+	if fd.IsList() {
+		return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String && fd.Kind() == protoreflect.StringKind
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return fd.Kind() == protoreflect.StringKind
+	case reflect.Bool:
+		return fd.Kind() == protoreflect.BoolKind
+	case reflect.Int, reflect.Int64:
+		return fd.Kind() == protoreflect.Int64Kind
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8 && fd.Kind() == protoreflect.BytesKind
+	default:
+		return false
+	}
+}
+
+func goToProto(rv reflect.Value, m protoreflect.Message) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		fd := fields.ByName(protoreflect.Name(payloadFieldName(rv.Type().Field(i))))
+		if fv.IsZero() {
+			continue
+		}
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for j := 0; j < fv.Len(); j++ {
+				list.Append(protoreflect.ValueOfString(fv.Index(j).String()))
+			}
+			continue
+		}
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			m.Set(fd, protoreflect.ValueOfString(fv.String()))
+		case protoreflect.BoolKind:
+			m.Set(fd, protoreflect.ValueOfBool(fv.Bool()))
+		case protoreflect.Int64Kind:
+			m.Set(fd, protoreflect.ValueOfInt64(fv.Int()))
+		case protoreflect.BytesKind:
+			m.Set(fd, protoreflect.ValueOfBytes(fv.Bytes()))
+		}
+	}
+}
+
+func protoToGo(m protoreflect.Message, rv reflect.Value) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < rv.NumField(); i++ {
+		fv := rv.Field(i)
+		fd := fields.ByName(protoreflect.Name(payloadFieldName(rv.Type().Field(i))))
+		if !m.Has(fd) {
+			continue
+		}
+		v := m.Get(fd)
+		if fd.IsList() {
+			list := v.List()
+			out := reflect.MakeSlice(fv.Type(), list.Len(), list.Len())
+			for j := 0; j < list.Len(); j++ {
+				out.Index(j).SetString(list.Get(j).String())
+			}
+			fv.Set(out)
+			continue
+		}
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			fv.SetString(v.String())
+		case protoreflect.BoolKind:
+			fv.SetBool(v.Bool())
+		case protoreflect.Int64Kind:
+			fv.SetInt(v.Int())
+		case protoreflect.BytesKind:
+			fv.SetBytes(v.Bytes())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package protocol
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// samplePayloads 每个注册类型的样例负载
+func samplePayloads() map[MessageType]any {
+	return map[MessageType]any{
+		MsgNick:      &SetNickPayload{Nick: "alice"},
+		MsgText:      &TextPayload{Text: "你好"},
+		MsgCommand:   &CommandPayload{Raw: "/help"},
+		MsgDirect:    &DirectPayload{To: []string{"bob", "carol"}, Content: "hi", Conversation: "dm-1"},
+		MsgFileMeta:  &FileMetaPayload{Name: "a.txt", Size: 42, MimeType: "text/plain", Checksum: "abc"},
+		MsgFileChunk: &FileChunkPayload{FileID: "f1", ChunkID: 3, Data: []byte{0, 1, 2}, IsLast: true, Checksum: "c"},
+		MsgAck:       &AckPayload{Status: "read", User: "bob"},
+		MsgPing:      &PingPayload{Seq: 7, Timestamp: 1700000000000},
+		MsgPong:      &PongPayload{Seq: 7, Timestamp: 1700000000001},
+		MsgHello:     &HelloPayload{Codecs: []string{Json}, Versions: []string{"1.0"}, Codec: Json, Version: "1.0"},
+		MsgError:     &ErrorPayload{Reason: "bad", Message: "boom"},
+		MsgPresence:  &PresencePayload{User: "alice", Status: "away", Text: "lunch", LastSeen: 1700000000000},
+		MsgTyping:    &TypingPayload{User: "alice", To: "bob", Active: true},
+		MsgRead:      &ReadPayload{Peer: "alice", UpTo: "m-42"},
+		MsgEdit:      &EditPayload{Mid: "m-42", Content: "fixed", Edited: true, By: "alice", EditedAt: 1700000000000, History: []string{"fxied"}},
+		MsgDelete:    &DeletePayload{Mid: "m-42", By: "mod", DeletedAt: 1700000000000},
+	}
+}
+
+// TestPayloadRoundTrip 每种注册负载在每种负载编码下都能无损往返
+func TestPayloadRoundTrip(t *testing.T) {
+	for _, enc := range []Encoding{EncodingJSON, EncodingProtobuf, EncodingCBOR} {
+		for mt, payload := range samplePayloads() {
+			e := &Envelope{Type: mt, Encoding: enc}
+			if err := EncodePayload(e, payload); err != nil {
+				t.Fatalf("%s/%s encode: %v", enc, mt, err)
+			}
+			got, err := DecodePayloadAny(e)
+			if err != nil {
+				t.Fatalf("%s/%s decode: %v", enc, mt, err)
+			}
+			if !reflect.DeepEqual(got, payload) {
+				t.Errorf("%s/%s mismatch:\n got: %+v\nwant: %+v", enc, mt, got, payload)
+			}
+		}
+	}
+}
+
+// TestPayloadRegistryCoverage 除 heartbeat 外，每个消息类型都有注册负载
+func TestPayloadRegistryCoverage(t *testing.T) {
+	samples := samplePayloads()
+	for _, mt := range AllMessageTypes() {
+		if mt == MsgHeartbeat {
+			continue
+		}
+		goType, ok := PayloadType(mt)
+		if !ok {
+			t.Errorf("message type %q has no registered payload", mt)
+			continue
+		}
+		if s, ok := samples[mt]; !ok || reflect.TypeOf(s).Elem() != goType {
+			t.Errorf("message type %q: missing or mismatched sample payload", mt)
+		}
+	}
+}
+
+// TestPayloadTypeChecks 负载类型必须与消息类型注册的类型一致
+func TestPayloadTypeChecks(t *testing.T) {
+	e := &Envelope{Type: MsgText}
+	if err := EncodePayload(e, &PingPayload{}); !errors.Is(err, ErrPayloadTypeMismatch) {
+		t.Errorf("EncodePayload mismatch: got %v", err)
+	}
+	if err := EncodePayload(&Envelope{Type: "unknown"}, &TextPayload{}); !errors.Is(err, ErrUnknownPayload) {
+		t.Errorf("EncodePayload unknown type: got %v", err)
+	}
+	if err := EncodePayload(&Envelope{Type: MsgText, Encoding: "xml"}, &TextPayload{}); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("EncodePayload unsupported encoding: got %v", err)
+	}
+
+	_ = EncodePayload(e, &TextPayload{Text: "hi"})
+	if _, err := DecodePayload[PingPayload](e); !errors.Is(err, ErrPayloadTypeMismatch) {
+		t.Errorf("DecodePayload mismatch: got %v", err)
+	}
+	p, err := DecodePayload[TextPayload](e)
+	if err != nil || p.Text != "hi" {
+		t.Errorf("DecodePayload = %+v, %v", p, err)
+	}
+}
+
+// TestFactoryPayloadEncoding 协议管理器的消息工厂按信封编解码器选择负载编码
+func TestFactoryPayloadEncoding(t *testing.T) {
+	for _, c := range []int{CodecJson, CodecProtobuf, CodecCbor} {
+		m := NewProtocolManager(c)
+		msg := m.GetMessageFactory().CreateTextMessage("hello")
+		if want := EncodingForCodec(m.GetCodec().Name()); msg.Encoding != want {
+			t.Errorf("%s: payload encoding %s, want %s", m.GetCodec().Name(), msg.Encoding, want)
+		}
+		p, err := DecodePayload[TextPayload](msg)
+		if err != nil || p.Text != "hello" {
+			t.Errorf("%s: decode text payload = %+v, %v", m.GetCodec().Name(), p, err)
+		}
+	}
+}
@@ -29,6 +29,7 @@ const (
 	Encoding_ENCODING_JSON        Encoding = 1
 	Encoding_ENCODING_PROTOBUF    Encoding = 2
 	Encoding_ENCODING_BINARY      Encoding = 3 // 保留扩展
+	Encoding_ENCODING_CBOR        Encoding = 4
 )
 
 // Enum value maps for Encoding.
@@ -38,12 +39,14 @@ var (
 		1: "ENCODING_JSON",
 		2: "ENCODING_PROTOBUF",
 		3: "ENCODING_BINARY",
+		4: "ENCODING_CBOR",
 	}
 	Encoding_value = map[string]int32{
 		"ENCODING_UNSPECIFIED": 0,
 		"ENCODING_JSON":        1,
 		"ENCODING_PROTOBUF":    2,
 		"ENCODING_BINARY":      3,
+		"ENCODING_CBOR":        4,
 	}
 )
 
@@ -86,19 +89,39 @@ const (
 	MessageType_MSG_TYPE_ACK         MessageType = 5
 	MessageType_MSG_TYPE_PING        MessageType = 6
 	MessageType_MSG_TYPE_PONG        MessageType = 7
+	MessageType_MSG_TYPE_HELLO       MessageType = 8
+	MessageType_MSG_TYPE_ERROR       MessageType = 9
+	MessageType_MSG_TYPE_NICK        MessageType = 10
+	MessageType_MSG_TYPE_HEARTBEAT   MessageType = 11
+	MessageType_MSG_TYPE_DIRECT      MessageType = 12
+	MessageType_MSG_TYPE_PRESENCE    MessageType = 13
+	MessageType_MSG_TYPE_TYPING      MessageType = 14
+	MessageType_MSG_TYPE_READ        MessageType = 15
+	MessageType_MSG_TYPE_EDIT        MessageType = 16
+	MessageType_MSG_TYPE_DELETE      MessageType = 17
 )
 
 // Enum value maps for MessageType.
 var (
 	MessageType_name = map[int32]string{
-		0: "MSG_TYPE_UNSPECIFIED",
-		1: "MSG_TYPE_TEXT",
-		2: "MSG_TYPE_COMMAND",
-		3: "MSG_TYPE_FILE_META",
-		4: "MSG_TYPE_FILE_CHUNK",
-		5: "MSG_TYPE_ACK",
-		6: "MSG_TYPE_PING",
-		7: "MSG_TYPE_PONG",
+		0:  "MSG_TYPE_UNSPECIFIED",
+		1:  "MSG_TYPE_TEXT",
+		2:  "MSG_TYPE_COMMAND",
+		3:  "MSG_TYPE_FILE_META",
+		4:  "MSG_TYPE_FILE_CHUNK",
+		5:  "MSG_TYPE_ACK",
+		6:  "MSG_TYPE_PING",
+		7:  "MSG_TYPE_PONG",
+		8:  "MSG_TYPE_HELLO",
+		9:  "MSG_TYPE_ERROR",
+		10: "MSG_TYPE_NICK",
+		11: "MSG_TYPE_HEARTBEAT",
+		12: "MSG_TYPE_DIRECT",
+		13: "MSG_TYPE_PRESENCE",
+		14: "MSG_TYPE_TYPING",
+		15: "MSG_TYPE_READ",
+		16: "MSG_TYPE_EDIT",
+		17: "MSG_TYPE_DELETE",
 	}
 	MessageType_value = map[string]int32{
 		"MSG_TYPE_UNSPECIFIED": 0,
@@ -109,6 +132,16 @@ var (
 		"MSG_TYPE_ACK":         5,
 		"MSG_TYPE_PING":        6,
 		"MSG_TYPE_PONG":        7,
+		"MSG_TYPE_HELLO":       8,
+		"MSG_TYPE_ERROR":       9,
+		"MSG_TYPE_NICK":        10,
+		"MSG_TYPE_HEARTBEAT":   11,
+		"MSG_TYPE_DIRECT":      12,
+		"MSG_TYPE_PRESENCE":    13,
+		"MSG_TYPE_TYPING":      14,
+		"MSG_TYPE_READ":        15,
+		"MSG_TYPE_EDIT":        16,
+		"MSG_TYPE_DELETE":      17,
 	}
 )
 
@@ -153,7 +186,13 @@ type Envelope struct {
 	To            string `protobuf:"bytes,7,opt,name=to,proto3" json:"to,omitempty"`                                            // 接收者
 	Timestamp     int64  `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                             // 毫秒时间戳
 	// ---- 负载 ----
-	Data          []byte `protobuf:"bytes,9,opt,name=data,proto3" json:"data,omitempty"` // Protobuf 二进制
+	Data []byte `protobuf:"bytes,9,opt,name=data,proto3" json:"data,omitempty"` // Protobuf 二进制
+	// ---- 扩展 ----
+	Ext map[string]string `protobuf:"bytes,10,rep,name=ext,proto3" json:"ext,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // v2 扩展头
+	// ---- 路由（续） ----
+	Recipients    []string `protobuf:"bytes,11,rep,name=recipients,proto3" json:"recipients,omitempty"` // 多接收者列表
+	Room          string   `protobuf:"bytes,12,opt,name=room,proto3" json:"room,omitempty"`             // 所属房间
+	Seq           int64    `protobuf:"varint,13,opt,name=seq,proto3" json:"seq,omitempty"`              // 会话/房间内序号
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -251,11 +290,39 @@ func (x *Envelope) GetData() []byte {
 	return nil
 }
 
+func (x *Envelope) GetExt() map[string]string {
+	if x != nil {
+		return x.Ext
+	}
+	return nil
+}
+
+func (x *Envelope) GetRecipients() []string {
+	if x != nil {
+		return x.Recipients
+	}
+	return nil
+}
+
+func (x *Envelope) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Envelope) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 var File_envelope_proto protoreflect.FileDescriptor
 
 const file_envelope_proto_rawDesc = "" +
 	"\n" +
-	"\x0eenvelope.proto\x12\x02pb\"\x8f\x02\n" +
+	"\x0eenvelope.proto\x12\x02pb\"\xb6\x03\n" +
 	"\bEnvelope\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\tR\aversion\x12#\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x0f.pb.MessageTypeR\x04type\x12(\n" +
@@ -266,12 +333,23 @@ const file_envelope_proto_rawDesc = "" +
 	"\x04from\x18\x06 \x01(\tR\x04from\x12\x0e\n" +
 	"\x02to\x18\a \x01(\tR\x02to\x12\x1c\n" +
 	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x12\x12\n" +
-	"\x04data\x18\t \x01(\fR\x04data*c\n" +
+	"\x04data\x18\t \x01(\fR\x04data\x12'\n" +
+	"\x03ext\x18\n" +
+	" \x03(\v2\x15.pb.Envelope.ExtEntryR\x03ext\x12\x1e\n" +
+	"\n" +
+	"recipients\x18\v \x03(\tR\n" +
+	"recipients\x12\x12\n" +
+	"\x04room\x18\f \x01(\tR\x04room\x12\x10\n" +
+	"\x03seq\x18\r \x01(\x03R\x03seq\x1a6\n" +
+	"\bExtEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*v\n" +
 	"\bEncoding\x12\x18\n" +
 	"\x14ENCODING_UNSPECIFIED\x10\x00\x12\x11\n" +
 	"\rENCODING_JSON\x10\x01\x12\x15\n" +
 	"\x11ENCODING_PROTOBUF\x10\x02\x12\x13\n" +
-	"\x0fENCODING_BINARY\x10\x03*\xb9\x01\n" +
+	"\x0fENCODING_BINARY\x10\x03\x12\x11\n" +
+	"\rENCODING_CBOR\x10\x04*\x88\x03\n" +
 	"\vMessageType\x12\x18\n" +
 	"\x14MSG_TYPE_UNSPECIFIED\x10\x00\x12\x11\n" +
 	"\rMSG_TYPE_TEXT\x10\x01\x12\x14\n" +
@@ -280,7 +358,18 @@ const file_envelope_proto_rawDesc = "" +
 	"\x13MSG_TYPE_FILE_CHUNK\x10\x04\x12\x10\n" +
 	"\fMSG_TYPE_ACK\x10\x05\x12\x11\n" +
 	"\rMSG_TYPE_PING\x10\x06\x12\x11\n" +
-	"\rMSG_TYPE_PONG\x10\aB\x19Z\x17internal/protocol/pb;pbb\x06proto3"
+	"\rMSG_TYPE_PONG\x10\a\x12\x12\n" +
+	"\x0eMSG_TYPE_HELLO\x10\b\x12\x12\n" +
+	"\x0eMSG_TYPE_ERROR\x10\t\x12\x11\n" +
+	"\rMSG_TYPE_NICK\x10\n" +
+	"\x12\x16\n" +
+	"\x12MSG_TYPE_HEARTBEAT\x10\v\x12\x13\n" +
+	"\x0fMSG_TYPE_DIRECT\x10\f\x12\x15\n" +
+	"\x11MSG_TYPE_PRESENCE\x10\r\x12\x13\n" +
+	"\x0fMSG_TYPE_TYPING\x10\x0e\x12\x11\n" +
+	"\rMSG_TYPE_READ\x10\x0f\x12\x11\n" +
+	"\rMSG_TYPE_EDIT\x10\x10\x12\x13\n" +
+	"\x0fMSG_TYPE_DELETE\x10\x11B\x19Z\x17internal/protocol/pb;pbb\x06proto3"
 
 var (
 	file_envelope_proto_rawDescOnce sync.Once
@@ -295,20 +384,22 @@ func file_envelope_proto_rawDescGZIP() []byte {
 }
 
 var file_envelope_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_envelope_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_envelope_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_envelope_proto_goTypes = []any{
 	(Encoding)(0),    // 0: pb.Encoding
 	(MessageType)(0), // 1: pb.MessageType
 	(*Envelope)(nil), // 2: pb.Envelope
+	nil,              // 3: pb.Envelope.ExtEntry
 }
 var file_envelope_proto_depIdxs = []int32{
 	1, // 0: pb.Envelope.type:type_name -> pb.MessageType
 	0, // 1: pb.Envelope.encoding:type_name -> pb.Encoding
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	3, // 2: pb.Envelope.ext:type_name -> pb.Envelope.ExtEntry
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_envelope_proto_init() }
@@ -322,7 +413,7 @@ func file_envelope_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_envelope_proto_rawDesc), len(file_envelope_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,1142 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v6.32.0
+// source: payload.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// TextPayload 纯文本消息负载
+type TextPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextPayload) Reset() {
+	*x = TextPayload{}
+	mi := &file_payload_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextPayload) ProtoMessage() {}
+
+func (x *TextPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextPayload.ProtoReflect.Descriptor instead.
+func (*TextPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TextPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// SetNickPayload 设置昵称消息负载
+type SetNickPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nick          string                 `protobuf:"bytes,1,opt,name=nick,proto3" json:"nick,omitempty"`
+	Locale        string                 `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetNickPayload) Reset() {
+	*x = SetNickPayload{}
+	mi := &file_payload_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNickPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNickPayload) ProtoMessage() {}
+
+func (x *SetNickPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNickPayload.ProtoReflect.Descriptor instead.
+func (*SetNickPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SetNickPayload) GetNick() string {
+	if x != nil {
+		return x.Nick
+	}
+	return ""
+}
+
+func (x *SetNickPayload) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+// CommandPayload 命令消息负载
+type CommandPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Raw           string                 `protobuf:"bytes,1,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandPayload) Reset() {
+	*x = CommandPayload{}
+	mi := &file_payload_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandPayload) ProtoMessage() {}
+
+func (x *CommandPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandPayload.ProtoReflect.Descriptor instead.
+func (*CommandPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CommandPayload) GetRaw() string {
+	if x != nil {
+		return x.Raw
+	}
+	return ""
+}
+
+// AckPayload 确认消息负载
+type AckPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckPayload) Reset() {
+	*x = AckPayload{}
+	mi := &file_payload_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckPayload) ProtoMessage() {}
+
+func (x *AckPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckPayload.ProtoReflect.Descriptor instead.
+func (*AckPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AckPayload) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AckPayload) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+// DirectPayload 私聊消息负载
+type DirectPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	To            []string               `protobuf:"bytes,1,rep,name=to,proto3" json:"to,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Conversation  string                 `protobuf:"bytes,3,opt,name=conversation,proto3" json:"conversation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DirectPayload) Reset() {
+	*x = DirectPayload{}
+	mi := &file_payload_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirectPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirectPayload) ProtoMessage() {}
+
+func (x *DirectPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirectPayload.ProtoReflect.Descriptor instead.
+func (*DirectPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DirectPayload) GetTo() []string {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *DirectPayload) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *DirectPayload) GetConversation() string {
+	if x != nil {
+		return x.Conversation
+	}
+	return ""
+}
+
+// PingPayload 心跳 ping 消息负载
+type PingPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingPayload) Reset() {
+	*x = PingPayload{}
+	mi := &file_payload_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingPayload) ProtoMessage() {}
+
+func (x *PingPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingPayload.ProtoReflect.Descriptor instead.
+func (*PingPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PingPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PingPayload) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// PongPayload 心跳 pong 消息负载
+type PongPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PongPayload) Reset() {
+	*x = PongPayload{}
+	mi := &file_payload_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PongPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PongPayload) ProtoMessage() {}
+
+func (x *PongPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PongPayload.ProtoReflect.Descriptor instead.
+func (*PongPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PongPayload) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PongPayload) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// FileMetaPayload 文件元数据消息负载
+type FileMetaPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	MimeType      string                 `protobuf:"bytes,3,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Checksum      string                 `protobuf:"bytes,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileMetaPayload) Reset() {
+	*x = FileMetaPayload{}
+	mi := &file_payload_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileMetaPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileMetaPayload) ProtoMessage() {}
+
+func (x *FileMetaPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileMetaPayload.ProtoReflect.Descriptor instead.
+func (*FileMetaPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FileMetaPayload) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FileMetaPayload) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileMetaPayload) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *FileMetaPayload) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+// FileChunkPayload 文件分片消息负载
+type FileChunkPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	ChunkId       int64                  `protobuf:"varint,2,opt,name=chunk_id,json=chunkId,proto3" json:"chunk_id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	IsLast        bool                   `protobuf:"varint,4,opt,name=is_last,json=isLast,proto3" json:"is_last,omitempty"`
+	Checksum      string                 `protobuf:"bytes,5,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunkPayload) Reset() {
+	*x = FileChunkPayload{}
+	mi := &file_payload_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunkPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunkPayload) ProtoMessage() {}
+
+func (x *FileChunkPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunkPayload.ProtoReflect.Descriptor instead.
+func (*FileChunkPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FileChunkPayload) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *FileChunkPayload) GetChunkId() int64 {
+	if x != nil {
+		return x.ChunkId
+	}
+	return 0
+}
+
+func (x *FileChunkPayload) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *FileChunkPayload) GetIsLast() bool {
+	if x != nil {
+		return x.IsLast
+	}
+	return false
+}
+
+func (x *FileChunkPayload) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+// HelloPayload 握手消息负载
+type HelloPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Codecs        []string               `protobuf:"bytes,1,rep,name=codecs,proto3" json:"codecs,omitempty"`
+	Versions      []string               `protobuf:"bytes,2,rep,name=versions,proto3" json:"versions,omitempty"`
+	Codec         string                 `protobuf:"bytes,3,opt,name=codec,proto3" json:"codec,omitempty"`
+	Version       string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloPayload) Reset() {
+	*x = HelloPayload{}
+	mi := &file_payload_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloPayload) ProtoMessage() {}
+
+func (x *HelloPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloPayload.ProtoReflect.Descriptor instead.
+func (*HelloPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *HelloPayload) GetCodecs() []string {
+	if x != nil {
+		return x.Codecs
+	}
+	return nil
+}
+
+func (x *HelloPayload) GetVersions() []string {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+func (x *HelloPayload) GetCodec() string {
+	if x != nil {
+		return x.Codec
+	}
+	return ""
+}
+
+func (x *HelloPayload) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+// ErrorPayload 错误消息负载
+type ErrorPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Rule          string                 `protobuf:"bytes,3,opt,name=rule,proto3" json:"rule,omitempty"`
+	Field         string                 `protobuf:"bytes,4,opt,name=field,proto3" json:"field,omitempty"`
+	Code          int64                  `protobuf:"varint,5,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorPayload) Reset() {
+	*x = ErrorPayload{}
+	mi := &file_payload_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorPayload) ProtoMessage() {}
+
+func (x *ErrorPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorPayload.ProtoReflect.Descriptor instead.
+func (*ErrorPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ErrorPayload) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ErrorPayload) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ErrorPayload) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *ErrorPayload) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *ErrorPayload) GetCode() int64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// PresencePayload 在线状态消息负载
+type PresencePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	LastSeen      int64                  `protobuf:"varint,4,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresencePayload) Reset() {
+	*x = PresencePayload{}
+	mi := &file_payload_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresencePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresencePayload) ProtoMessage() {}
+
+func (x *PresencePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresencePayload.ProtoReflect.Descriptor instead.
+func (*PresencePayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PresencePayload) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *PresencePayload) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PresencePayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *PresencePayload) GetLastSeen() int64 {
+	if x != nil {
+		return x.LastSeen
+	}
+	return 0
+}
+
+// TypingPayload 正在输入提示负载
+type TypingPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Active        bool                   `protobuf:"varint,3,opt,name=active,proto3" json:"active,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypingPayload) Reset() {
+	*x = TypingPayload{}
+	mi := &file_payload_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypingPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypingPayload) ProtoMessage() {}
+
+func (x *TypingPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypingPayload.ProtoReflect.Descriptor instead.
+func (*TypingPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TypingPayload) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *TypingPayload) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *TypingPayload) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+// ReadPayload 已读标记负载
+type ReadPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peer          string                 `protobuf:"bytes,1,opt,name=peer,proto3" json:"peer,omitempty"`
+	UpTo          string                 `protobuf:"bytes,2,opt,name=up_to,json=upTo,proto3" json:"up_to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadPayload) Reset() {
+	*x = ReadPayload{}
+	mi := &file_payload_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadPayload) ProtoMessage() {}
+
+func (x *ReadPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadPayload.ProtoReflect.Descriptor instead.
+func (*ReadPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReadPayload) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *ReadPayload) GetUpTo() string {
+	if x != nil {
+		return x.UpTo
+	}
+	return ""
+}
+
+// EditPayload 编辑消息负载
+type EditPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mid           string                 `protobuf:"bytes,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Edited        bool                   `protobuf:"varint,3,opt,name=edited,proto3" json:"edited,omitempty"`
+	By            string                 `protobuf:"bytes,4,opt,name=by,proto3" json:"by,omitempty"`
+	EditedAt      int64                  `protobuf:"varint,5,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+	History       []string               `protobuf:"bytes,6,rep,name=history,proto3" json:"history,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EditPayload) Reset() {
+	*x = EditPayload{}
+	mi := &file_payload_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EditPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditPayload) ProtoMessage() {}
+
+func (x *EditPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditPayload.ProtoReflect.Descriptor instead.
+func (*EditPayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *EditPayload) GetMid() string {
+	if x != nil {
+		return x.Mid
+	}
+	return ""
+}
+
+func (x *EditPayload) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *EditPayload) GetEdited() bool {
+	if x != nil {
+		return x.Edited
+	}
+	return false
+}
+
+func (x *EditPayload) GetBy() string {
+	if x != nil {
+		return x.By
+	}
+	return ""
+}
+
+func (x *EditPayload) GetEditedAt() int64 {
+	if x != nil {
+		return x.EditedAt
+	}
+	return 0
+}
+
+func (x *EditPayload) GetHistory() []string {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+// DeletePayload 删除消息负载
+type DeletePayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mid           string                 `protobuf:"bytes,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	By            string                 `protobuf:"bytes,2,opt,name=by,proto3" json:"by,omitempty"`
+	DeletedAt     int64                  `protobuf:"varint,3,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePayload) Reset() {
+	*x = DeletePayload{}
+	mi := &file_payload_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePayload) ProtoMessage() {}
+
+func (x *DeletePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_payload_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePayload.ProtoReflect.Descriptor instead.
+func (*DeletePayload) Descriptor() ([]byte, []int) {
+	return file_payload_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DeletePayload) GetMid() string {
+	if x != nil {
+		return x.Mid
+	}
+	return ""
+}
+
+func (x *DeletePayload) GetBy() string {
+	if x != nil {
+		return x.By
+	}
+	return ""
+}
+
+func (x *DeletePayload) GetDeletedAt() int64 {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return 0
+}
+
+var File_payload_proto protoreflect.FileDescriptor
+
+const file_payload_proto_rawDesc = "" +
+	"\n" +
+	"\rpayload.proto\x12\x02pb\"!\n" +
+	"\vTextPayload\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"<\n" +
+	"\x0eSetNickPayload\x12\x12\n" +
+	"\x04nick\x18\x01 \x01(\tR\x04nick\x12\x16\n" +
+	"\x06locale\x18\x02 \x01(\tR\x06locale\"\"\n" +
+	"\x0eCommandPayload\x12\x10\n" +
+	"\x03raw\x18\x01 \x01(\tR\x03raw\"8\n" +
+	"\n" +
+	"AckPayload\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\"]\n" +
+	"\rDirectPayload\x12\x0e\n" +
+	"\x02to\x18\x01 \x03(\tR\x02to\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\"\n" +
+	"\fconversation\x18\x03 \x01(\tR\fconversation\"=\n" +
+	"\vPingPayload\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"=\n" +
+	"\vPongPayload\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"r\n" +
+	"\x0fFileMetaPayload\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x1b\n" +
+	"\tmime_type\x18\x03 \x01(\tR\bmimeType\x12\x1a\n" +
+	"\bchecksum\x18\x04 \x01(\tR\bchecksum\"\x8f\x01\n" +
+	"\x10FileChunkPayload\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x19\n" +
+	"\bchunk_id\x18\x02 \x01(\x03R\achunkId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x17\n" +
+	"\ais_last\x18\x04 \x01(\bR\x06isLast\x12\x1a\n" +
+	"\bchecksum\x18\x05 \x01(\tR\bchecksum\"r\n" +
+	"\fHelloPayload\x12\x16\n" +
+	"\x06codecs\x18\x01 \x03(\tR\x06codecs\x12\x1a\n" +
+	"\bversions\x18\x02 \x03(\tR\bversions\x12\x14\n" +
+	"\x05codec\x18\x03 \x01(\tR\x05codec\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\"~\n" +
+	"\fErrorPayload\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x12\n" +
+	"\x04rule\x18\x03 \x01(\tR\x04rule\x12\x14\n" +
+	"\x05field\x18\x04 \x01(\tR\x05field\x12\x12\n" +
+	"\x04code\x18\x05 \x01(\x03R\x04code\"n\n" +
+	"\x0fPresencePayload\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12\x1b\n" +
+	"\tlast_seen\x18\x04 \x01(\x03R\blastSeen\"K\n" +
+	"\rTypingPayload\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x16\n" +
+	"\x06active\x18\x03 \x01(\bR\x06active\"6\n" +
+	"\vReadPayload\x12\x12\n" +
+	"\x04peer\x18\x01 \x01(\tR\x04peer\x12\x13\n" +
+	"\x05up_to\x18\x02 \x01(\tR\x04upTo\"\x98\x01\n" +
+	"\vEditPayload\x12\x10\n" +
+	"\x03mid\x18\x01 \x01(\tR\x03mid\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x16\n" +
+	"\x06edited\x18\x03 \x01(\bR\x06edited\x12\x0e\n" +
+	"\x02by\x18\x04 \x01(\tR\x02by\x12\x1b\n" +
+	"\tedited_at\x18\x05 \x01(\x03R\beditedAt\x12\x18\n" +
+	"\ahistory\x18\x06 \x03(\tR\ahistory\"P\n" +
+	"\rDeletePayload\x12\x10\n" +
+	"\x03mid\x18\x01 \x01(\tR\x03mid\x12\x0e\n" +
+	"\x02by\x18\x02 \x01(\tR\x02by\x12\x1d\n" +
+	"\n" +
+	"deleted_at\x18\x03 \x01(\x03R\tdeletedAtB\x19Z\x17internal/protocol/pb;pbb\x06proto3"
+
+var (
+	file_payload_proto_rawDescOnce sync.Once
+	file_payload_proto_rawDescData []byte
+)
+
+func file_payload_proto_rawDescGZIP() []byte {
+	file_payload_proto_rawDescOnce.Do(func() {
+		file_payload_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_payload_proto_rawDesc), len(file_payload_proto_rawDesc)))
+	})
+	return file_payload_proto_rawDescData
+}
+
+var file_payload_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_payload_proto_goTypes = []any{
+	(*TextPayload)(nil),      // 0: pb.TextPayload
+	(*SetNickPayload)(nil),   // 1: pb.SetNickPayload
+	(*CommandPayload)(nil),   // 2: pb.CommandPayload
+	(*AckPayload)(nil),       // 3: pb.AckPayload
+	(*DirectPayload)(nil),    // 4: pb.DirectPayload
+	(*PingPayload)(nil),      // 5: pb.PingPayload
+	(*PongPayload)(nil),      // 6: pb.PongPayload
+	(*FileMetaPayload)(nil),  // 7: pb.FileMetaPayload
+	(*FileChunkPayload)(nil), // 8: pb.FileChunkPayload
+	(*HelloPayload)(nil),     // 9: pb.HelloPayload
+	(*ErrorPayload)(nil),     // 10: pb.ErrorPayload
+	(*PresencePayload)(nil),  // 11: pb.PresencePayload
+	(*TypingPayload)(nil),    // 12: pb.TypingPayload
+	(*ReadPayload)(nil),      // 13: pb.ReadPayload
+	(*EditPayload)(nil),      // 14: pb.EditPayload
+	(*DeletePayload)(nil),    // 15: pb.DeletePayload
+}
+var file_payload_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_payload_proto_init() }
+func file_payload_proto_init() {
+	if File_payload_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payload_proto_rawDesc), len(file_payload_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_payload_proto_goTypes,
+		DependencyIndexes: file_payload_proto_depIdxs,
+		MessageInfos:      file_payload_proto_msgTypes,
+	}.Build()
+	File_payload_proto = out.File
+	file_payload_proto_goTypes = nil
+	file_payload_proto_depIdxs = nil
+}
@@ -1,14 +1,35 @@
 package protocol
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
 	"io"
+	"sync"
 
 	"github.com/hongjun500/chat-go/internal/protocol/pb"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
+const (
+	// MaxDelimitedSize 长度前缀模式下未指定 maxSize 时的安全上限
+	MaxDelimitedSize = 16 * 1024 * 1024
+	// pbBufMaxRetain 超过该容量的缓冲区不放回池中，避免偶发大消息长期占用内存
+	pbBufMaxRetain = 64 * 1024
+)
+
+var pbBufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
 // ProtobufCodec 将 Envelope 编码为 Protocol Buffers 格式
-type ProtobufCodec struct{}
+//
+// 默认假设 reader 中恰好是一条完整消息（例如 FrameCodec 已切好的一帧），读到 EOF 为止；
+// Delimited 为 true 时使用 varint 长度前缀分隔（与 protodelim 兼容），适合脱离 FrameCodec 直接在流上使用。
+type ProtobufCodec struct {
+	Delimited bool
+}
 
 func (p *ProtobufCodec) Name() string {
 	return Protobuf
@@ -17,38 +38,54 @@ func (p *ProtobufCodec) Name() string {
 func (p *ProtobufCodec) Encode(w io.Writer, e *Envelope) error {
 	protoMessage := &pb.Envelope{
 		Version:       e.Version,
-		Type:          toPBMsgType(e.Type),
+		Type:          toPBMsgType(CanonicalType(e.Type)),
 		Encoding:      toPBEncoding(e.Encoding),
 		MessageId:     e.Mid,
 		CorrelationId: e.Correlation,
+		From:          e.From,
+		To:            e.To,
+		Recipients:    e.Recipients,
+		Room:          e.Room,
+		Seq:           e.Seq,
 		Timestamp:     e.Ts,
 		Data:          e.Data,
+		Ext:           e.Ext,
 	}
 
 	data, err := proto.Marshal(protoMessage)
 	if err != nil {
 		return err
 	}
+	if p.Delimited {
+		data = append(protowire.AppendVarint(make([]byte, 0, binary.MaxVarintLen64+len(data)), uint64(len(data))), data...)
+	}
 	_, err = w.Write(data)
 	return err
 }
 
 // Decode ProtobufCodec 实现 Codec 接口
+// maxSize > 0 时超限消息直接拒绝，不会截断；缓冲区按实际长度增长并复用，不会按 maxSize 预分配。
 func (p *ProtobufCodec) Decode(r io.Reader, e *Envelope, maxSize int) error {
-	reader := r
-	if maxSize > 0 {
-		reader = io.LimitReader(r, int64(maxSize))
-	}
+	buf := pbBufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= pbBufMaxRetain {
+			pbBufPool.Put(buf)
+		}
+	}()
 
-	buf := make([]byte, maxSize)
-	n, err := reader.Read(buf)
-	if err != nil && err != io.EOF {
+	var err error
+	if p.Delimited {
+		err = readDelimited(r, buf, maxSize)
+	} else {
+		err = readAll(r, buf, maxSize)
+	}
+	if err != nil {
 		return err
 	}
-	data := buf[:n]
 
 	protoMessage := &pb.Envelope{}
-	if err := proto.Unmarshal(data, protoMessage); err != nil {
+	if err := proto.Unmarshal(buf.Bytes(), protoMessage); err != nil {
 		return err
 	}
 
@@ -57,15 +94,75 @@ func (p *ProtobufCodec) Decode(r io.Reader, e *Envelope, maxSize int) error {
 		Version:     protoMessage.GetVersion(),
 		Type:        fromPBMsgType(protoMessage.GetType()),
 		Encoding:    fromPBEncoding(protoMessage.GetEncoding()),
+		From:        protoMessage.GetFrom(),
+		To:          protoMessage.GetTo(),
+		Recipients:  protoMessage.GetRecipients(),
+		Room:        protoMessage.GetRoom(),
 		Mid:         protoMessage.GetMessageId(),
 		Correlation: protoMessage.GetCorrelationId(),
+		Seq:         protoMessage.GetSeq(),
 		Ts:          protoMessage.GetTimestamp(),
 		Data:        protoMessage.GetData(),
+		Ext:         protoMessage.GetExt(),
 	}
 	*e = result
 	return nil
 }
 
+// readAll 读取 r 直到 EOF，超过 maxSize 时返回错误
+func readAll(r io.Reader, buf *bytes.Buffer, maxSize int) error {
+	if maxSize > 0 {
+		// 多读 1 字节用于判断是否超限
+		r = io.LimitReader(r, int64(maxSize)+1)
+	}
+	if _, err := buf.ReadFrom(r); err != nil {
+		return fmt.Errorf("protobuf read: %w", err)
+	}
+	if maxSize > 0 && buf.Len() > maxSize {
+		return fmt.Errorf("protobuf payload exceeds max size %d", maxSize)
+	}
+	return nil
+}
+
+// readDelimited 读取 varint 长度前缀及恰好该长度的消息体，不会多读后续数据
+func readDelimited(r io.Reader, buf *bytes.Buffer, maxSize int) error {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = &singleByteReader{r: r}
+	}
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return fmt.Errorf("protobuf read length: %w", err)
+	}
+	if maxSize > 0 && size > uint64(maxSize) {
+		return fmt.Errorf("protobuf payload size %d exceeds max size %d", size, maxSize)
+	}
+	if size > MaxDelimitedSize {
+		return fmt.Errorf("protobuf payload size %d exceeds hard limit %d", size, MaxDelimitedSize)
+	}
+	n, err := buf.ReadFrom(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return fmt.Errorf("protobuf read: %w", err)
+	}
+	if n != int64(size) {
+		return fmt.Errorf("protobuf read: %w", io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// singleByteReader 逐字节读取，保证读取长度前缀时不越过消息边界
+type singleByteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (s *singleByteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(s.r, s.buf[:]); err != nil {
+		return 0, err
+	}
+	return s.buf[0], nil
+}
+
 // --- 辅助方法 ---
 
 func toPBEncoding(enc Encoding) pb.Encoding {
@@ -74,6 +171,8 @@ func toPBEncoding(enc Encoding) pb.Encoding {
 		return pb.Encoding_ENCODING_JSON
 	case EncodingProtobuf:
 		return pb.Encoding_ENCODING_PROTOBUF
+	case EncodingCBOR:
+		return pb.Encoding_ENCODING_CBOR
 	default:
 		return pb.Encoding_ENCODING_UNSPECIFIED
 	}
@@ -85,6 +184,8 @@ func fromPBEncoding(enc pb.Encoding) Encoding {
 		return EncodingJSON
 	case pb.Encoding_ENCODING_PROTOBUF:
 		return EncodingProtobuf
+	case pb.Encoding_ENCODING_CBOR:
+		return EncodingCBOR
 	default:
 		return ""
 	}
@@ -92,6 +193,8 @@ func fromPBEncoding(enc pb.Encoding) Encoding {
 
 func toPBMsgType(t MessageType) pb.MessageType {
 	switch t {
+	case MsgNick:
+		return pb.MessageType_MSG_TYPE_NICK
 	case MsgText:
 		return pb.MessageType_MSG_TYPE_TEXT
 	case MsgCommand:
@@ -106,6 +209,24 @@ func toPBMsgType(t MessageType) pb.MessageType {
 		return pb.MessageType_MSG_TYPE_PING
 	case MsgPong:
 		return pb.MessageType_MSG_TYPE_PONG
+	case MsgHello:
+		return pb.MessageType_MSG_TYPE_HELLO
+	case MsgError:
+		return pb.MessageType_MSG_TYPE_ERROR
+	case MsgHeartbeat:
+		return pb.MessageType_MSG_TYPE_HEARTBEAT
+	case MsgDirect:
+		return pb.MessageType_MSG_TYPE_DIRECT
+	case MsgPresence:
+		return pb.MessageType_MSG_TYPE_PRESENCE
+	case MsgTyping:
+		return pb.MessageType_MSG_TYPE_TYPING
+	case MsgRead:
+		return pb.MessageType_MSG_TYPE_READ
+	case MsgEdit:
+		return pb.MessageType_MSG_TYPE_EDIT
+	case MsgDelete:
+		return pb.MessageType_MSG_TYPE_DELETE
 	default:
 		return pb.MessageType_MSG_TYPE_UNSPECIFIED
 	}
@@ -113,6 +234,8 @@ func toPBMsgType(t MessageType) pb.MessageType {
 
 func fromPBMsgType(t pb.MessageType) MessageType {
 	switch t {
+	case pb.MessageType_MSG_TYPE_NICK:
+		return MsgNick
 	case pb.MessageType_MSG_TYPE_TEXT:
 		return MsgText
 	case pb.MessageType_MSG_TYPE_COMMAND:
@@ -127,6 +250,24 @@ func fromPBMsgType(t pb.MessageType) MessageType {
 		return MsgPing
 	case pb.MessageType_MSG_TYPE_PONG:
 		return MsgPong
+	case pb.MessageType_MSG_TYPE_HELLO:
+		return MsgHello
+	case pb.MessageType_MSG_TYPE_ERROR:
+		return MsgError
+	case pb.MessageType_MSG_TYPE_HEARTBEAT:
+		return MsgHeartbeat
+	case pb.MessageType_MSG_TYPE_DIRECT:
+		return MsgDirect
+	case pb.MessageType_MSG_TYPE_PRESENCE:
+		return MsgPresence
+	case pb.MessageType_MSG_TYPE_TYPING:
+		return MsgTyping
+	case pb.MessageType_MSG_TYPE_READ:
+		return MsgRead
+	case pb.MessageType_MSG_TYPE_EDIT:
+		return MsgEdit
+	case pb.MessageType_MSG_TYPE_DELETE:
+		return MsgDelete
 	default:
 		return ""
 	}
@@ -0,0 +1,166 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func sampleProtobufEnvelope() *Envelope {
+	return &Envelope{
+		Version:    "1.0",
+		Type:       MsgText,
+		Encoding:   EncodingProtobuf,
+		Mid:        "pb-001",
+		From:       "alice",
+		To:         "bob",
+		Recipients: []string{"bob", "carol"},
+		Ts:         1700000000000,
+		Data:       bytes.Repeat([]byte("x"), 4096),
+	}
+}
+
+// TestProtobufDecodePartialReads 底层 reader 每次只返回部分数据时也必须完整解码
+func TestProtobufDecodePartialReads(t *testing.T) {
+	for _, delimited := range []bool{false, true} {
+		codec := &ProtobufCodec{Delimited: delimited}
+		var buf bytes.Buffer
+		if err := codec.Encode(&buf, sampleProtobufEnvelope()); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		var decoded Envelope
+		if err := codec.Decode(iotest.OneByteReader(&buf), &decoded, 1<<20); err != nil {
+			t.Fatalf("delimited=%v: decode: %v", delimited, err)
+		}
+		if !bytes.Equal(decoded.Data, sampleProtobufEnvelope().Data) || decoded.To != "bob" {
+			t.Errorf("delimited=%v: envelope truncated: data=%d bytes to=%q", delimited, len(decoded.Data), decoded.To)
+		}
+	}
+}
+
+// TestProtobufDecodeDelimitedStream 长度前缀模式下可在同一流上连续解码多条消息
+func TestProtobufDecodeDelimitedStream(t *testing.T) {
+	codec := &ProtobufCodec{Delimited: true}
+	var stream bytes.Buffer
+	for _, mid := range []string{"a", "b", "c"} {
+		e := sampleProtobufEnvelope()
+		e.Mid = mid
+		if err := codec.Encode(&stream, e); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+	r := iotest.HalfReader(&stream)
+	for _, want := range []string{"a", "b", "c"} {
+		var decoded Envelope
+		if err := codec.Decode(r, &decoded, 1<<20); err != nil {
+			t.Fatalf("decode %s: %v", want, err)
+		}
+		if decoded.Mid != want {
+			t.Errorf("got mid %q, want %q", decoded.Mid, want)
+		}
+	}
+}
+
+// countingReader 统计被读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+// infiniteReader 永不结束的输入
+type infiniteReader struct{}
+
+func (infiniteReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0x0a
+	}
+	return len(p), nil
+}
+
+// TestProtobufDecodeLimits 超限输入被拒绝，且读取量与内存分配不随声明长度增长
+func TestProtobufDecodeLimits(t *testing.T) {
+	const maxSize = 1024
+
+	t.Run("unbounded stream", func(t *testing.T) {
+		cr := &countingReader{r: infiniteReader{}}
+		var e Envelope
+		if err := (&ProtobufCodec{}).Decode(cr, &e, maxSize); err == nil {
+			t.Fatalf("expected error for oversized payload")
+		}
+		if cr.n > maxSize+1 {
+			t.Errorf("read %d bytes, want at most %d", cr.n, maxSize+1)
+		}
+	})
+
+	t.Run("hostile length prefix", func(t *testing.T) {
+		header := binary.AppendUvarint(nil, 1<<40)
+		cr := &countingReader{r: io.MultiReader(bytes.NewReader(header), infiniteReader{})}
+		var e Envelope
+		if err := (&ProtobufCodec{Delimited: true}).Decode(cr, &e, maxSize); err == nil {
+			t.Fatalf("expected error for hostile length prefix")
+		}
+		if cr.n > len(header) {
+			t.Errorf("read %d bytes past the length prefix", cr.n-len(header))
+		}
+	})
+
+	t.Run("no maxSize preallocation", func(t *testing.T) {
+		var buf bytes.Buffer
+		small := &Envelope{Type: MsgPing, Mid: "p"}
+		if err := (&ProtobufCodec{}).Encode(&buf, small); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		frame := buf.Bytes()
+		codec := &ProtobufCodec{}
+		var e Envelope
+		_ = codec.Decode(bytes.NewReader(frame), &e, 1<<20) // 预热缓冲池
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = codec.Decode(bytes.NewReader(frame), &e, 1<<20)
+		})
+		if allocs > 20 {
+			t.Errorf("decode of a tiny frame allocated %.0f times", allocs)
+		}
+	})
+}
+
+// FuzzProtobufDecode 任意输入都不得导致 panic，超限输入必须被拒绝
+func FuzzProtobufDecode(f *testing.F) {
+	for _, delimited := range []bool{false, true} {
+		var buf bytes.Buffer
+		_ = (&ProtobufCodec{Delimited: delimited}).Encode(&buf, sampleProtobufEnvelope())
+		f.Add(buf.Bytes(), delimited)
+	}
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, true)
+	f.Add([]byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0x0f}, false)
+	f.Add([]byte{}, false)
+
+	const maxSize = 4096
+	f.Fuzz(func(t *testing.T, data []byte, delimited bool) {
+		codec := &ProtobufCodec{Delimited: delimited}
+		cr := &countingReader{r: bytes.NewReader(data)}
+		var e Envelope
+		err := codec.Decode(cr, &e, maxSize)
+		if cr.n > maxSize+binary.MaxVarintLen64+1 {
+			t.Fatalf("read %d bytes with maxSize %d", cr.n, maxSize)
+		}
+		if err == nil && !delimited && len(data) > maxSize {
+			t.Fatalf("accepted %d bytes with maxSize %d", len(data), maxSize)
+		}
+		if err != nil {
+			return
+		}
+		// 成功解码的消息必须能重新编码
+		var out bytes.Buffer
+		if err := codec.Encode(&out, &e); err != nil {
+			t.Fatalf("re-encode decoded envelope: %v", err)
+		}
+	})
+}
@@ -10,8 +10,21 @@ type Encoding string
 const (
 	EncodingJSON     Encoding = Json
 	EncodingProtobuf Encoding = Protobuf
+	EncodingCBOR     Encoding = Cbor
 )
 
+// EncodingForCodec 返回与信封编解码器匹配的负载编码，使整条消息使用同一种格式
+func EncodingForCodec(codecName string) Encoding {
+	switch codecName {
+	case Protobuf:
+		return EncodingProtobuf
+	case Cbor:
+		return EncodingCBOR
+	default:
+		return EncodingJSON
+	}
+}
+
 // MessageType 表示系统支持的业务消息类型
 type MessageType string
 
@@ -19,14 +32,35 @@ const (
 	MsgNick      MessageType = "nick"
 	MsgText      MessageType = "text"
 	MsgCommand   MessageType = "command"
+	MsgDirect    MessageType = "direct"
 	MsgFileMeta  MessageType = "file_meta"
 	MsgFileChunk MessageType = "file_chunk"
 	MsgAck       MessageType = "ack"
 	MsgPing      MessageType = "ping"
 	MsgPong      MessageType = "pong"
 	MsgHeartbeat MessageType = "heartbeat"
+	MsgHello     MessageType = "hello"
+	MsgError     MessageType = "error"
+	MsgPresence  MessageType = "presence"
+	MsgTyping    MessageType = "typing"
+	MsgRead      MessageType = "read"
+	MsgEdit      MessageType = "edit"
+	MsgDelete    MessageType = "delete"
 )
 
+// AllMessageTypes 返回全部规范消息类型，新增类型必须加入此列表，
+// 由测试保证每个类型都能无损通过所有编解码器
+func AllMessageTypes() []MessageType {
+	return []MessageType{
+		MsgNick, MsgText, MsgCommand, MsgDirect, MsgFileMeta, MsgFileChunk,
+		MsgAck, MsgPing, MsgPong, MsgHeartbeat, MsgHello, MsgError, MsgPresence, MsgTyping, MsgRead,
+		MsgEdit, MsgDelete,
+	}
+}
+
+// Version 内部规范形态的协议版本，其它版本经 VersionAdapter 转换
+const Version = "1.0"
+
 // Manager 协议管理器，负责协议层的核心功能
 type Manager struct {
 	codec   MessageCodec
@@ -42,7 +76,16 @@ func NewProtocolManager(codecType int) *Manager {
 	}
 	return &Manager{
 		codec:   codec,
-		factory: NewMessageFactory(),
+		factory: NewMessageFactoryWithEncoding(EncodingForCodec(codec.Name())),
+	}
+}
+
+// WithCodec 返回使用指定编解码器的协议管理器，消息工厂按新编解码器选择负载编码。
+// 用于按会话协商编解码器，不影响监听器级别的默认配置。
+func (p *Manager) WithCodec(codec MessageCodec) *Manager {
+	return &Manager{
+		codec:   codec,
+		factory: NewMessageFactoryWithEncoding(EncodingForCodec(codec.Name())),
 	}
 }
 
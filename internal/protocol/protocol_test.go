@@ -1 +1,148 @@
 package protocol
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// v1Envelope 用于固定 v1 线上格式的样例消息
+func v1Envelope() *Envelope {
+	return &Envelope{
+		Version:     "1.0",
+		Type:        MsgText,
+		Encoding:    EncodingJSON,
+		From:        "alice",
+		Mid:         "m-1",
+		Correlation: "c-1",
+		Ts:          1700000000000,
+		Data:        []byte(`{"text":"hi"}`),
+	}
+}
+
+// TestV1WireFormat 固定 v1 的线上格式：任何字段/类型调整都不得改变 v1 客户端看到的字节
+func TestV1WireFormat(t *testing.T) {
+	golden := map[int]string{
+		CodecJson:     `{"version":"1.0","type":"text","encoding":"json","from":"alice","mid":"m-1","correlation_id":"c-1","ts":1700000000000,"data":"eyJ0ZXh0IjoiaGkifQ=="}` + "\n",
+		CodecProtobuf: "0a03312e301001180122036d2d312a03632d313205616c6963654080d095ffbc314a0d7b2274657874223a226869227d",
+		CodecCbor:     "a86274731b0000018bcfe56800636d6964636d2d3164646174614d7b2274657874223a226869227d6466726f6d65616c696365647479706564746578746776657273696f6e63312e3068656e636f64696e67646a736f6e6e636f7272656c6174696f6e5f696463632d31",
+	}
+
+	// 规范形态携带 v2 扩展头，经 v1 适配器出站后应与纯 v1 消息字节一致
+	canonical := v1Envelope()
+	canonical.Ext = map[string]string{"room": "lobby"}
+	v1, err := AdapterFor("1.0")
+	if err != nil {
+		t.Fatalf("AdapterFor(1.0): %v", err)
+	}
+	out := v1.Downgrade(canonical)
+	if canonical.Ext == nil {
+		t.Fatalf("Downgrade must not modify the original envelope")
+	}
+
+	for c, want := range golden {
+		codec, _ := NewCodec(c)
+		var buf bytes.Buffer
+		if err := codec.Encode(&buf, out); err != nil {
+			t.Fatalf("%s encode: %v", codec.Name(), err)
+		}
+		got := buf.String()
+		if c != CodecJson {
+			got = hex.EncodeToString(buf.Bytes())
+		}
+		if got != want {
+			t.Errorf("%s v1 wire format changed:\n got: %s\nwant: %s", codec.Name(), got, want)
+		}
+	}
+}
+
+// TestVersionNegotiation 测试版本解析与协商
+func TestVersionNegotiation(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  []string
+		want    string
+		wantErr bool
+	}{
+		{"only v1", []string{"1.0"}, "1.0", false},
+		{"prefer highest common", []string{"1.0", "2.0", "3.0"}, "2.0", false},
+		{"minor versions", []string{"2.7"}, "2.0", false},
+		{"empty means v1", []string{""}, "1.0", false},
+		{"no common major", []string{"3.0", "9.1"}, "", true},
+		{"garbage", []string{"v-next"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NegotiateVersion(tt.client)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedVersion) {
+					t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("NegotiateVersion(%v) = %q, %v; want %q", tt.client, got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := AdapterFor("3.0"); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("AdapterFor(3.0) should be unsupported, got %v", err)
+	}
+}
+
+// TestV2Adapter 测试 v2 类型重命名与扩展字段在 v1/v2 之间的转换
+func TestV2Adapter(t *testing.T) {
+	v2, err := AdapterFor("2.0")
+	if err != nil {
+		t.Fatalf("AdapterFor(2.0): %v", err)
+	}
+
+	// 入站：v2 类型名转换为规范名，扩展头保留
+	in := &Envelope{Version: "2.0", Type: "chat.text", Ext: map[string]string{"room": "lobby"}}
+	v2.Upgrade(in)
+	if in.Type != MsgText {
+		t.Errorf("Upgrade type: got %s, want %s", in.Type, MsgText)
+	}
+	if in.Ext["room"] != "lobby" {
+		t.Errorf("Upgrade must keep ext fields")
+	}
+
+	// 出站：规范名转换为 v2 名称，未重命名的类型保持不变
+	out := v2.Downgrade(v1Envelope())
+	if out.Type != "chat.text" || out.Version != "2.0" {
+		t.Errorf("Downgrade: got type=%s version=%s", out.Type, out.Version)
+	}
+	if ping := v2.Downgrade(&Envelope{Type: MsgPing}); ping.Type != MsgPing {
+		t.Errorf("unrenamed type changed: %s", ping.Type)
+	}
+
+	// Protobuf 的类型是枚举，v2 名称应映射到相同枚举值
+	var buf bytes.Buffer
+	if err := (&ProtobufCodec{}).Encode(&buf, out); err != nil {
+		t.Fatalf("protobuf encode: %v", err)
+	}
+	var decoded Envelope
+	if err := (&ProtobufCodec{}).Decode(&buf, &decoded, 1024); err != nil {
+		t.Fatalf("protobuf decode: %v", err)
+	}
+	if decoded.Type != MsgText {
+		t.Errorf("protobuf type: got %s, want %s", decoded.Type, MsgText)
+	}
+}
+
+// TestNegotiateHello 测试从 hello 负载协商版本
+func TestNegotiateHello(t *testing.T) {
+	hello := &Envelope{Version: "2.0", Type: MsgHello, Data: []byte(`{"codecs":["json"],"versions":["1.0","2.0"]}`)}
+	if v, err := NegotiateHello(hello); err != nil || v != "2.0" {
+		t.Errorf("NegotiateHello = %q, %v; want 2.0", v, err)
+	}
+	// 未声明版本列表时使用信封版本
+	if v, err := NegotiateHello(&Envelope{Version: "1.0", Type: MsgHello}); err != nil || v != "1.0" {
+		t.Errorf("NegotiateHello without payload = %q, %v; want 1.0", v, err)
+	}
+	if _, err := NegotiateHello(&Envelope{Type: MsgHello, Data: []byte("not json")}); err == nil {
+		t.Errorf("expected error for malformed hello payload")
+	}
+}
@@ -0,0 +1,243 @@
+package protocol
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/hongjun500/chat-go/internal/protocol/pb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// 以下测试落实 docs/protocol-schema-evolution.md 中的规则。
+// 修改 Envelope 或 envelope.proto 时，需要同步更新本文件中的映射表。
+
+// envelopeProtoFields Go Envelope 字段到 pb.Envelope 字段名的映射
+var envelopeProtoFields = map[string]protoreflect.Name{
+	"Version":     "version",
+	"Type":        "type",
+	"Encoding":    "encoding",
+	"From":        "from",
+	"To":          "to",
+	"Recipients":  "recipients",
+	"Room":        "room",
+	"Mid":         "message_id",
+	"Correlation": "correlation_id",
+	"Seq":         "seq",
+	"Ts":          "timestamp",
+	"Data":        "data",
+	"Ext":         "ext",
+}
+
+// pbEnvelopeFieldNumbers 已发布的 pb.Envelope 字段编号，一经发布不得修改或复用
+var pbEnvelopeFieldNumbers = map[protoreflect.Name]protoreflect.FieldNumber{
+	"version":        1,
+	"type":           2,
+	"encoding":       3,
+	"message_id":     4,
+	"correlation_id": 5,
+	"from":           6,
+	"to":             7,
+	"timestamp":      8,
+	"data":           9,
+	"ext":            10,
+	"recipients":     11,
+	"room":           12,
+	"seq":            13,
+}
+
+// pbEnumNumbers 已发布的枚举值编号，一经发布不得修改或复用
+var pbEnumNumbers = map[protoreflect.Name]protoreflect.EnumNumber{
+	"ENCODING_UNSPECIFIED": 0,
+	"ENCODING_JSON":        1,
+	"ENCODING_PROTOBUF":    2,
+	"ENCODING_BINARY":      3,
+	"ENCODING_CBOR":        4,
+	"MSG_TYPE_UNSPECIFIED": 0,
+	"MSG_TYPE_TEXT":        1,
+	"MSG_TYPE_COMMAND":     2,
+	"MSG_TYPE_FILE_META":   3,
+	"MSG_TYPE_FILE_CHUNK":  4,
+	"MSG_TYPE_ACK":         5,
+	"MSG_TYPE_PING":        6,
+	"MSG_TYPE_PONG":        7,
+	"MSG_TYPE_HELLO":       8,
+	"MSG_TYPE_ERROR":       9,
+	"MSG_TYPE_NICK":        10,
+	"MSG_TYPE_HEARTBEAT":   11,
+	"MSG_TYPE_DIRECT":      12,
+	"MSG_TYPE_PRESENCE":    13,
+	"MSG_TYPE_TYPING":      14,
+	"MSG_TYPE_READ":        15,
+	"MSG_TYPE_EDIT":        16,
+	"MSG_TYPE_DELETE":      17,
+}
+
+// TestEnvelopeSchemaParity Go Envelope 的每个字段都必须在 pb.Envelope 中有对应字段，反之亦然
+func TestEnvelopeSchemaParity(t *testing.T) {
+	desc := (&pb.Envelope{}).ProtoReflect().Descriptor()
+	typ := reflect.TypeOf(Envelope{})
+	mapped := make(map[protoreflect.Name]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		pbName, ok := envelopeProtoFields[name]
+		if !ok {
+			t.Errorf("Envelope.%s has no protobuf mapping; add it to envelope.proto and envelopeProtoFields", name)
+			continue
+		}
+		if desc.Fields().ByName(pbName) == nil {
+			t.Errorf("Envelope.%s maps to missing pb field %q", name, pbName)
+		}
+		mapped[pbName] = true
+	}
+	for i := 0; i < desc.Fields().Len(); i++ {
+		if f := desc.Fields().Get(i); !mapped[f.Name()] {
+			t.Errorf("pb.Envelope.%s has no Go Envelope counterpart", f.Name())
+		}
+	}
+}
+
+// TestProtoNumbersStable 已发布的字段与枚举编号不得变更；新增项必须登记
+func TestProtoNumbersStable(t *testing.T) {
+	fields := (&pb.Envelope{}).ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		want, ok := pbEnvelopeFieldNumbers[f.Name()]
+		if !ok {
+			t.Errorf("new pb.Envelope field %q (=%d) must be registered in pbEnvelopeFieldNumbers", f.Name(), f.Number())
+			continue
+		}
+		if f.Number() != want {
+			t.Errorf("pb.Envelope.%s renumbered: got %d, want %d", f.Name(), f.Number(), want)
+		}
+	}
+	if fields.Len() < len(pbEnvelopeFieldNumbers) {
+		t.Errorf("pb.Envelope fields removed: got %d, want at least %d (use reserved instead)", fields.Len(), len(pbEnvelopeFieldNumbers))
+	}
+
+	for _, enum := range []protoreflect.EnumDescriptor{
+		pb.Encoding(0).Descriptor(),
+		pb.MessageType(0).Descriptor(),
+	} {
+		values := enum.Values()
+		for i := 0; i < values.Len(); i++ {
+			v := values.Get(i)
+			want, ok := pbEnumNumbers[v.Name()]
+			if !ok {
+				t.Errorf("new enum value %s (=%d) must be registered in pbEnumNumbers", v.Name(), v.Number())
+				continue
+			}
+			if v.Number() != want {
+				t.Errorf("enum value %s renumbered: got %d, want %d", v.Name(), v.Number(), want)
+			}
+		}
+	}
+}
+
+// TestMessageTypesParity 每个规范消息类型都必须有独立的 protobuf 枚举值，反之亦然
+func TestMessageTypesParity(t *testing.T) {
+	seen := make(map[pb.MessageType]MessageType)
+	for _, mt := range AllMessageTypes() {
+		p := toPBMsgType(mt)
+		if p == pb.MessageType_MSG_TYPE_UNSPECIFIED {
+			t.Errorf("message type %q has no protobuf enum value", mt)
+			continue
+		}
+		if prev, dup := seen[p]; dup {
+			t.Errorf("message types %q and %q share enum value %s", prev, mt, p)
+		}
+		seen[p] = mt
+		if back := fromPBMsgType(p); back != mt {
+			t.Errorf("enum %s decodes to %q, want %q", p, back, mt)
+		}
+	}
+	values := pb.MessageType(0).Descriptor().Values()
+	for i := 0; i < values.Len(); i++ {
+		p := pb.MessageType(values.Get(i).Number())
+		if p == pb.MessageType_MSG_TYPE_UNSPECIFIED {
+			continue
+		}
+		if _, ok := seen[p]; !ok {
+			t.Errorf("enum value %s is not listed in AllMessageTypes", p)
+		}
+	}
+}
+
+// randomString 生成合法 UTF-8 字符串（proto3 string 字段要求 UTF-8）
+func randomString(r *rand.Rand) string {
+	alphabet := []rune("abcXYZ019 _-./:你好世界🙂")
+	n := r.Intn(12)
+	out := make([]rune, n)
+	for i := range out {
+		out[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(out)
+}
+
+// randomEnvelope 随机生成一个字段全部可能被填充的 Envelope
+func randomEnvelope(r *rand.Rand) *Envelope {
+	types := AllMessageTypes()
+	encodings := []Encoding{"", EncodingJSON, EncodingProtobuf, EncodingCBOR}
+	e := &Envelope{
+		Version:     randomString(r),
+		Type:        types[r.Intn(len(types))],
+		Encoding:    encodings[r.Intn(len(encodings))],
+		From:        randomString(r),
+		To:          randomString(r),
+		Room:        randomString(r),
+		Mid:         randomString(r),
+		Correlation: randomString(r),
+		Seq:         r.Int63() - r.Int63(),
+		Ts:          r.Int63(),
+	}
+	for i := r.Intn(4); i > 0; i-- {
+		e.Recipients = append(e.Recipients, randomString(r))
+	}
+	if n := r.Intn(64); n > 0 {
+		e.Data = make([]byte, n)
+		r.Read(e.Data)
+	}
+	if r.Intn(2) == 0 {
+		e.Ext = map[string]string{randomString(r): randomString(r)}
+	}
+	return e
+}
+
+// normalize 抹平 nil 与空值的差异，便于比较
+func normalize(e Envelope) Envelope {
+	if len(e.Recipients) == 0 {
+		e.Recipients = nil
+	}
+	if len(e.Data) == 0 {
+		e.Data = nil
+	}
+	if len(e.Ext) == 0 {
+		e.Ext = nil
+	}
+	return e
+}
+
+// TestCodecRoundTripProperty 任意 Envelope 经任一编解码器往返后必须完全一致
+func TestCodecRoundTripProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(20240601))
+	for _, c := range []int{CodecJson, CodecProtobuf, CodecCbor} {
+		codec, _ := NewCodec(c)
+		t.Run(codec.Name(), func(t *testing.T) {
+			for i := 0; i < 500; i++ {
+				in := randomEnvelope(r)
+				var buf bytes.Buffer
+				if err := codec.Encode(&buf, in); err != nil {
+					t.Fatalf("encode %+v: %v", in, err)
+				}
+				var out Envelope
+				if err := codec.Decode(&buf, &out, 1<<20); err != nil {
+					t.Fatalf("decode %+v: %v", in, err)
+				}
+				if got, want := normalize(out), normalize(*in); !reflect.DeepEqual(got, want) {
+					t.Fatalf("round trip mismatch:\n got: %+v\nwant: %+v", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+	"unicode/utf8"
+)
+
+// 校验规则名称，随错误消息的 rule 字段返回给客户端
+const (
+	RuleRequired      = "required"       // 必填字段为空
+	RuleMaxLength     = "max_length"     // 字符串或字节字段超长
+	RuleMaxItems      = "max_items"      // 列表字段元素过多
+	RuleMaxRecipients = "max_recipients" // 信封接收者过多
+	RuleMaxPayload    = "max_payload"    // 负载字节数超限
+	RuleUTF8          = "utf8"           // 字符串不是合法 UTF-8
+	RuleTimestampSkew = "timestamp_skew" // 时间戳与服务端时钟偏差过大
+	RulePayload       = "payload"        // 负载无法按声明的编码解码
+)
+
+// 校验默认值
+const (
+	DefaultMaxPayload    = 64 << 10
+	DefaultMaxRecipients = 100
+	DefaultMaxSkew       = 5 * time.Minute
+)
+
+// ValidationError 入站消息校验失败，指明违反的规则与字段
+type ValidationError struct {
+	Rule    string
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Rule, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Rule, e.Field, e.Message)
+}
+
+// FieldRule 负载字段的校验规则，字段按 JSON tag 名称引用
+type FieldRule struct {
+	Required bool // 不得为零值
+	MaxLen   int  // 字符串/字节切片的最大字节数，0 表示不限
+	MaxItems int  // 列表的最大元素数，0 表示不限
+}
+
+// TypeRules 某个消息类型的校验规则
+type TypeRules struct {
+	MaxPayload    int                  // Data 最大字节数，0 表示使用 DefaultMaxPayload
+	MaxRecipients int                  // Envelope.Recipients 最大数量，0 表示使用 DefaultMaxRecipients
+	Fields        map[string]FieldRule // 负载字段规则
+}
+
+// DefaultRules 内置消息类型的默认校验规则
+func DefaultRules() map[MessageType]TypeRules {
+	return map[MessageType]TypeRules{
+		MsgNick: {Fields: map[string]FieldRule{
+			"nick":   {Required: true, MaxLen: 32},
+			"locale": {MaxLen: 35},
+		}},
+		MsgText: {MaxPayload: 16 << 10, Fields: map[string]FieldRule{
+			"text": {Required: true, MaxLen: 4096},
+		}},
+		MsgCommand: {Fields: map[string]FieldRule{
+			"raw": {Required: true, MaxLen: 1024},
+		}},
+		MsgDirect: {MaxPayload: 16 << 10, Fields: map[string]FieldRule{
+			"to":      {Required: true, MaxItems: DefaultMaxRecipients},
+			"content": {Required: true, MaxLen: 4096},
+		}},
+		MsgFileMeta: {Fields: map[string]FieldRule{
+			"name":      {Required: true, MaxLen: 255},
+			"mime_type": {MaxLen: 127},
+			"checksum":  {MaxLen: 128},
+		}},
+		MsgFileChunk: {MaxPayload: 512 << 10, Fields: map[string]FieldRule{
+			"file_id":  {Required: true, MaxLen: 64},
+			"data":     {MaxLen: 256 << 10},
+			"checksum": {MaxLen: 128},
+		}},
+		MsgAck: {Fields: map[string]FieldRule{
+			"status": {MaxLen: 64},
+		}},
+		MsgPresence: {Fields: map[string]FieldRule{
+			"user":   {MaxLen: 32},
+			"status": {Required: true, MaxLen: 16},
+			"text":   {MaxLen: 128},
+		}},
+		MsgTyping: {Fields: map[string]FieldRule{
+			"user": {MaxLen: 32},
+			"to":   {MaxLen: 32},
+		}},
+		MsgRead: {Fields: map[string]FieldRule{
+			"peer":  {Required: true, MaxLen: 32},
+			"up_to": {Required: true, MaxLen: 64},
+		}},
+		MsgEdit: {MaxPayload: 16 << 10, Fields: map[string]FieldRule{
+			"mid":     {Required: true, MaxLen: 64},
+			"content": {Required: true, MaxLen: 4096},
+			"by":      {MaxLen: 32},
+		}},
+		MsgDelete: {Fields: map[string]FieldRule{
+			"mid": {Required: true, MaxLen: 64},
+			"by":  {MaxLen: 32},
+		}},
+		MsgHello: {Fields: map[string]FieldRule{
+			"codecs":   {MaxItems: 16},
+			"versions": {MaxItems: 16},
+		}},
+	}
+}
+
+// Validator 入站消息校验器，在分发到业务处理器之前执行
+type Validator struct {
+	Rules   map[MessageType]TypeRules
+	MaxSkew time.Duration // Ts 与服务端时钟的最大偏差，0 表示不校验；Ts 为 0 时视为未设置
+
+	now func() time.Time
+}
+
+// NewValidator 创建使用默认规则的校验器
+func NewValidator() *Validator {
+	return &Validator{
+		Rules:   DefaultRules(),
+		MaxSkew: DefaultMaxSkew,
+		now:     time.Now,
+	}
+}
+
+// Validate 校验信封与负载，失败时返回 *ValidationError
+func (v *Validator) Validate(e *Envelope) error {
+	if e.Type == "" {
+		return &ValidationError{Rule: RuleRequired, Field: "type", Message: "message type is required"}
+	}
+	if err := validateEnvelopeStrings(e); err != nil {
+		return err
+	}
+	if v.MaxSkew > 0 && e.Ts != 0 {
+		skew := time.Duration(v.now().UnixMilli()-e.Ts) * time.Millisecond
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > v.MaxSkew {
+			return &ValidationError{Rule: RuleTimestampSkew, Field: "ts", Message: fmt.Sprintf("timestamp is off by %s, max %s", skew.Round(time.Second), v.MaxSkew)}
+		}
+	}
+
+	rules := v.Rules[e.Type]
+	maxRecipients := rules.MaxRecipients
+	if maxRecipients == 0 {
+		maxRecipients = DefaultMaxRecipients
+	}
+	if len(e.Recipients) > maxRecipients {
+		return &ValidationError{Rule: RuleMaxRecipients, Field: "recipients", Message: fmt.Sprintf("%d recipients, max %d", len(e.Recipients), maxRecipients)}
+	}
+	maxPayload := rules.MaxPayload
+	if maxPayload == 0 {
+		maxPayload = DefaultMaxPayload
+	}
+	if len(e.Data) > maxPayload {
+		return &ValidationError{Rule: RuleMaxPayload, Field: "data", Message: fmt.Sprintf("%d bytes, max %d", len(e.Data), maxPayload)}
+	}
+
+	goType, ok := PayloadType(e.Type)
+	if !ok {
+		return nil
+	}
+	// 空负载按零值处理，由必填规则给出更明确的错误
+	payload := reflect.New(goType)
+	if len(e.Data) > 0 {
+		p, err := DecodePayloadAny(e)
+		if err != nil {
+			return &ValidationError{Rule: RulePayload, Field: "data", Message: err.Error()}
+		}
+		payload = reflect.ValueOf(p)
+	}
+	return validatePayload(payload.Elem(), rules.Fields)
+}
+
+// validateEnvelopeStrings 信封的字符串字段必须是合法 UTF-8
+func validateEnvelopeStrings(e *Envelope) error {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"version", e.Version},
+		{"type", string(e.Type)},
+		{"from", e.From},
+		{"to", e.To},
+		{"room", e.Room},
+		{"mid", e.Mid},
+		{"correlation_id", e.Correlation},
+	}
+	for _, f := range fields {
+		if !utf8.ValidString(f.value) {
+			return utf8Error(f.name)
+		}
+	}
+	for _, r := range e.Recipients {
+		if !utf8.ValidString(r) {
+			return utf8Error("recipients")
+		}
+	}
+	for k, val := range e.Ext {
+		if !utf8.ValidString(k) || !utf8.ValidString(val) {
+			return utf8Error("ext")
+		}
+	}
+	return nil
+}
+
+// validatePayload 按字段规则校验负载，所有字符串字段均需为合法 UTF-8
+func validatePayload(rv reflect.Value, rules map[string]FieldRule) error {
+	for i := 0; i < rv.NumField(); i++ {
+		name := payloadFieldName(rv.Type().Field(i))
+		fv := rv.Field(i)
+		rule := rules[name]
+
+		if rule.Required && fv.IsZero() {
+			return &ValidationError{Rule: RuleRequired, Field: name, Message: "field is required"}
+		}
+		switch fv.Kind() {
+		case reflect.String:
+			if !utf8.ValidString(fv.String()) {
+				return utf8Error(name)
+			}
+			if rule.MaxLen > 0 && fv.Len() > rule.MaxLen {
+				return maxLengthError(name, fv.Len(), rule.MaxLen)
+			}
+		case reflect.Slice:
+			if fv.Type().Elem().Kind() == reflect.Uint8 {
+				if rule.MaxLen > 0 && fv.Len() > rule.MaxLen {
+					return maxLengthError(name, fv.Len(), rule.MaxLen)
+				}
+				continue
+			}
+			if rule.MaxItems > 0 && fv.Len() > rule.MaxItems {
+				return &ValidationError{Rule: RuleMaxItems, Field: name, Message: fmt.Sprintf("%d items, max %d", fv.Len(), rule.MaxItems)}
+			}
+			for j := 0; j < fv.Len(); j++ {
+				item := fv.Index(j).String()
+				if !utf8.ValidString(item) {
+					return utf8Error(name)
+				}
+				if rule.MaxLen > 0 && len(item) > rule.MaxLen {
+					return maxLengthError(name, len(item), rule.MaxLen)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func utf8Error(field string) *ValidationError {
+	return &ValidationError{Rule: RuleUTF8, Field: field, Message: "invalid UTF-8"}
+}
+
+func maxLengthError(field string, n, max int) *ValidationError {
+	return &ValidationError{Rule: RuleMaxLength, Field: field, Message: fmt.Sprintf("%d bytes, max %d", n, max)}
+}
@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestValidator 每条规则都能被触发，并报告正确的规则与字段
+func TestValidator(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	v := NewValidator()
+	v.now = func() time.Time { return now }
+
+	withPayload := func(e *Envelope, p any) *Envelope {
+		if err := EncodePayload(e, p); err != nil {
+			t.Fatalf("encode payload: %v", err)
+		}
+		return e
+	}
+	many := make([]string, DefaultMaxRecipients+1)
+	for i := range many {
+		many[i] = "u"
+	}
+
+	cases := []struct {
+		name  string
+		env   *Envelope
+		rule  string
+		field string
+	}{
+		{"valid text", withPayload(&Envelope{Type: MsgText, Ts: now.UnixMilli()}, &TextPayload{Text: "hi"}), "", ""},
+		{"valid protobuf direct", withPayload(&Envelope{Type: MsgDirect, Encoding: EncodingProtobuf}, &DirectPayload{To: []string{"bob"}, Content: "hi"}), "", ""},
+		{"unregistered payload", &Envelope{Type: MsgHeartbeat}, "", ""},
+		{"missing type", &Envelope{}, RuleRequired, "type"},
+		{"empty text", &Envelope{Type: MsgText}, RuleRequired, "text"},
+		{"garbage data", &Envelope{Type: MsgText, Data: []byte("not json")}, RulePayload, "data"},
+		{"text too long", withPayload(&Envelope{Type: MsgText}, &TextPayload{Text: strings.Repeat("a", 4097)}), RuleMaxLength, "text"},
+		{"payload too large", &Envelope{Type: MsgText, Data: make([]byte, 16<<10+1)}, RuleMaxPayload, "data"},
+		{"too many payload recipients", withPayload(&Envelope{Type: MsgDirect}, &DirectPayload{To: many, Content: "hi"}), RuleMaxItems, "to"},
+		{"too many envelope recipients", &Envelope{Type: MsgPing, Recipients: many}, RuleMaxRecipients, "recipients"},
+		{"invalid utf8 envelope", &Envelope{Type: MsgPing, From: "\xff"}, RuleUTF8, "from"},
+		{"invalid utf8 cbor payload", withPayload(&Envelope{Type: MsgText, Encoding: EncodingCBOR}, &TextPayload{Text: "a\xffb"}), RulePayload, "data"},
+		{"timestamp in future", &Envelope{Type: MsgPing, Ts: now.Add(10 * time.Minute).UnixMilli()}, RuleTimestampSkew, "ts"},
+		{"timestamp in past", &Envelope{Type: MsgPing, Ts: now.Add(-10 * time.Minute).UnixMilli()}, RuleTimestampSkew, "ts"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := v.Validate(c.env)
+			if c.rule == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got %v, want rule %s", err, c.rule)
+			}
+			if verr.Rule != c.rule || verr.Field != c.field {
+				t.Errorf("got %s/%s, want %s/%s (%v)", verr.Rule, verr.Field, c.rule, c.field, verr)
+			}
+		})
+	}
+}
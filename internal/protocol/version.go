@@ -0,0 +1,167 @@
+package protocol
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedVersion 协议主版本不受支持
+var ErrUnsupportedVersion = NewError(CodeUnsupportedVersion, "")
+
+// VersionAdapter 版本适配器，负责某个主版本的线上形态与内部规范形态（v1）之间的转换。
+// 业务层只处理规范形态，不感知客户端实际使用的协议版本。
+type VersionAdapter interface {
+	// Version 该适配器对应的完整版本号，如 "2.0"
+	Version() string
+	// Upgrade 将该版本的入站 Envelope 就地转换为规范形态
+	Upgrade(e *Envelope)
+	// Downgrade 将规范形态的出站 Envelope 转换为该版本形态，返回副本，不修改原对象
+	Downgrade(e *Envelope) *Envelope
+}
+
+// versionAdapters 主版本号到适配器的映射
+var versionAdapters = map[int]VersionAdapter{
+	1: v1Adapter{},
+	2: v2Adapter{},
+}
+
+// ParseMajor 解析版本号中的主版本，空版本视为 1（兼容未声明版本的旧客户端）
+func ParseMajor(version string) (int, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return 1, nil
+	}
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil || n <= 0 {
+		return 0, Errorf(CodeUnsupportedVersion, "unsupported protocol version: %q", version)
+	}
+	return n, nil
+}
+
+// AdapterFor 返回版本号对应的适配器，主版本不受支持时返回 ErrUnsupportedVersion
+func AdapterFor(version string) (VersionAdapter, error) {
+	major, err := ParseMajor(version)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := versionAdapters[major]
+	if !ok {
+		return nil, Errorf(CodeUnsupportedVersion, "unsupported protocol version: %q", version)
+	}
+	return a, nil
+}
+
+// SupportedVersions 返回服务端支持的协议版本，按主版本升序
+func SupportedVersions() []string {
+	majors := make([]int, 0, len(versionAdapters))
+	for m := range versionAdapters {
+		majors = append(majors, m)
+	}
+	sort.Ints(majors)
+	out := make([]string, 0, len(majors))
+	for _, m := range majors {
+		out = append(out, versionAdapters[m].Version())
+	}
+	return out
+}
+
+// NegotiateVersion 从客户端声明的版本中选出双方都支持的最高版本
+func NegotiateVersion(client []string) (string, error) {
+	best := 0
+	for _, v := range client {
+		major, err := ParseMajor(v)
+		if err != nil {
+			continue
+		}
+		if _, ok := versionAdapters[major]; ok && major > best {
+			best = major
+		}
+	}
+	if best == 0 {
+		return "", Errorf(CodeUnsupportedVersion, "unsupported protocol version: %s", strings.Join(client, ","))
+	}
+	return versionAdapters[best].Version(), nil
+}
+
+// NegotiateHello 解析客户端 hello 负载并协商协议版本。
+// 客户端未声明版本列表时，退回到 hello 信封自身的版本。
+func NegotiateHello(e *Envelope) (string, error) {
+	var versions []string
+	if len(e.Data) > 0 {
+		p, err := DecodePayload[HelloPayload](e)
+		if err != nil {
+			return "", Errorf(CodeBadHello, "bad hello payload: %v", err)
+		}
+		versions = p.Versions
+	}
+	if len(versions) == 0 {
+		versions = []string{e.Version}
+	}
+	return NegotiateVersion(versions)
+}
+
+// v1Adapter v1 即规范形态，出站时剥离 v1 不认识的扩展字段，保证 v1 线上格式不变
+type v1Adapter struct{}
+
+func (v1Adapter) Version() string { return Version }
+
+func (v1Adapter) Upgrade(*Envelope) {}
+
+func (v1Adapter) Downgrade(e *Envelope) *Envelope {
+	out := *e
+	out.Version = Version
+	out.Ext = nil
+	return &out
+}
+
+// v2TypeNames v2 将消息类型改为带命名空间的名称，未列出的类型两版一致
+var v2TypeNames = map[MessageType]MessageType{
+	MsgNick:      "user.nick",
+	MsgText:      "chat.text",
+	MsgCommand:   "chat.command",
+	MsgDirect:    "chat.direct",
+	MsgFileMeta:  "file.meta",
+	MsgFileChunk: "file.chunk",
+	MsgPresence:  "user.presence",
+	MsgTyping:    "chat.typing",
+	MsgRead:      "chat.read",
+	MsgEdit:      "chat.edit",
+	MsgDelete:    "chat.delete",
+}
+
+// v1TypeNames v2 类型名到规范类型名的反向映射
+var v1TypeNames = func() map[MessageType]MessageType {
+	m := make(map[MessageType]MessageType, len(v2TypeNames))
+	for v1, v2 := range v2TypeNames {
+		m[v2] = v1
+	}
+	return m
+}()
+
+// CanonicalType 将任意受支持版本的消息类型名转换为规范（v1）名称
+func CanonicalType(t MessageType) MessageType {
+	if v1, ok := v1TypeNames[t]; ok {
+		return v1
+	}
+	return t
+}
+
+// v2Adapter v2 形态：带命名空间的类型名，并携带 Ext 扩展头
+type v2Adapter struct{}
+
+func (v2Adapter) Version() string { return "2.0" }
+
+func (v2Adapter) Upgrade(e *Envelope) {
+	e.Type = CanonicalType(e.Type)
+}
+
+func (a v2Adapter) Downgrade(e *Envelope) *Envelope {
+	out := *e
+	out.Version = a.Version()
+	if v2, ok := v2TypeNames[e.Type]; ok {
+		out.Type = v2
+	}
+	return &out
+}
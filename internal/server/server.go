@@ -0,0 +1,135 @@
+// Package server 统一管理聊天服务的生命周期：启动全部传输监听器与后台任务，
+// 处理 SIGINT/SIGTERM，停机时先排空会话再关闭监听器与后台任务。
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/observe"
+	"github.com/hongjun500/chat-go/internal/transport"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// defaultDrainTimeout 未配置时等待会话排空的时长
+const defaultDrainTimeout = 10 * time.Second
+
+// component 由 Server 启动的组件：传输监听器或后台任务。
+// run 阻塞直到 ctx 取消；在此之前返回错误视为启动或运行失败
+type component struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Server 持有全部传输与后台任务
+type Server struct {
+	// DrainTimeout 停机时等待会话收到通知并写完输出缓冲的最长时间
+	DrainTimeout time.Duration
+
+	components []component
+	drainers   []transport.Drainer
+}
+
+// New 创建服务
+func New(drainTimeout time.Duration) *Server {
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	return &Server{DrainTimeout: drainTimeout}
+}
+
+// AddTransport 注册传输监听器；网关实现 transport.Drainer 时停机前参与排空
+func (s *Server) AddTransport(t transport.Transport, addr string, gateway transport.Gateway, opt transport.Options) {
+	name := t.Name() + "@" + addr
+	s.components = append(s.components, component{name: name, run: func(ctx context.Context) error {
+		logger.L().Sugar().Infow("starting_transport", "transport", t.Name(), "addr", addr)
+		return t.Start(ctx, addr, gateway, opt)
+	}})
+	if d, ok := gateway.(transport.Drainer); ok {
+		s.drainers = append(s.drainers, d)
+	}
+}
+
+// AddTask 注册后台任务（HTTP 观测服务、Redis 消费者等），run 应在 ctx 取消后尽快返回
+func (s *Server) AddTask(name string, run func(ctx context.Context) error) {
+	s.components = append(s.components, component{name: name, run: run})
+}
+
+// Run 启动全部组件并阻塞，直到收到 SIGINT/SIGTERM、ctx 取消或某个组件失败，随后优雅停机：
+//  1. 标记排空（/healthz 返回 503），网关拒绝新会话并通知现有会话，等待输出缓冲写完，至多 DrainTimeout；
+//  2. 取消组件上下文：监听器停止接受连接并关闭剩余会话，后台任务（Redis 消费者）退出；
+//  3. 等待组件返回，同样至多 DrainTimeout。
+//
+// 组件失败（如端口被占用）时返回该错误，正常停机返回 nil
+func (s *Server) Run(ctx context.Context) error {
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	observe.SetDraining(false)
+	failed := make(chan error, len(s.components))
+	var wg sync.WaitGroup
+	for _, c := range s.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.run(runCtx)
+			if runCtx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, http.ErrServerClosed) {
+				return
+			}
+			if err == nil {
+				err = errors.New("stopped unexpectedly")
+			}
+			failed <- fmt.Errorf("%s: %w", c.name, err)
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-sigCtx.Done():
+		logger.L().Sugar().Infow("server_shutdown", "reason", "requested")
+	case runErr = <-failed:
+		logger.L().Sugar().Warnw("server_shutdown", "reason", "component_failed", "err", runErr)
+	}
+
+	s.drain()
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(s.DrainTimeout):
+		logger.L().Sugar().Warnw("server_shutdown_timeout", "timeout", s.DrainTimeout)
+	}
+	logger.L().Sugar().Infow("server_stopped")
+	return runErr
+}
+
+// drain 并发排空全部网关，至多等待 DrainTimeout
+func (s *Server) drain() {
+	observe.SetDraining(true)
+	ctx, cancel := context.WithTimeout(context.Background(), s.DrainTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, d := range s.drainers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.Drain(ctx); err != nil {
+				logger.L().Sugar().Warnw("drain_incomplete", "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/internal/transport"
+)
+
+// freeAddr 返回一个当前空闲的本地地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr
+}
+
+func newGateway(t *testing.T, hub *chat.Hub) *transport.ChatGateway {
+	t.Helper()
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	return transport.NewChatGateway(hub, reg)
+}
+
+// TestRunStartupFailure 端口被占用时 Run 停止其他组件并返回错误
+func TestRunStartupFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	stopped := make(chan struct{})
+	srv := New(time.Second)
+	srv.AddTask("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+	srv.AddTransport(transport.NewTCPServer(""), ln.Addr().String(), newGateway(t, chat.NewHub()), transport.Options{
+		TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Run must fail when the port is taken")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("other components must be stopped")
+	}
+}
+
+// TestGracefulShutdown 停机时会话先收到 server_shutdown 通知和缓冲中的消息，再被关闭
+func TestGracefulShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := chat.NewHub()
+	addr := freeAddr(t)
+	srv := New(2 * time.Second)
+	srv.AddTransport(transport.NewTCPServer(""), addr, newGateway(t, hub), transport.Options{
+		TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+	})
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	var c *transport.ClientConn
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var err error
+		if c, err = transport.DialClient(addr, &protocol.JSONCodec{}, nil); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dial: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer c.Close()
+	if err := c.Write(protocol.NewMessageFactory().CreateSetNickMessage("alice")); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		env, err := c.Read(1 << 20)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if env.Type == protocol.MsgAck {
+			break
+		}
+	}
+
+	cancel()
+	var notified bool
+	for {
+		env, err := c.Read(1 << 20)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if env.Type == protocol.MsgError {
+			p, _ := protocol.DecodePayload[protocol.ErrorPayload](env)
+			notified = p != nil && p.Code == protocol.CodeServerShutdown
+		}
+	}
+	if !notified {
+		t.Error("session was not notified of the shutdown")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run = %v, want nil", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	if names := hub.ListNames(); len(names) != 0 {
+		t.Errorf("online after shutdown = %v", names)
+	}
+}
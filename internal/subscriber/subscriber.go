@@ -1,9 +1,11 @@
 package subscriber
 
 import (
+	"strings"
 	"time"
 
 	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/i18n"
 	"github.com/hongjun500/chat-go/internal/observe"
 )
 
@@ -15,18 +17,24 @@ func RegisterAll(hub *chat.Hub) {
 	registerFile(hub)
 	registerHeartbeat(hub)
 	registerDirect(hub)
+	registerPresence(hub)
+	registerTyping(hub)
+	registerReceipt(hub)
+	registerEdit(hub)
 }
 
+// registerMessage 本地与其它节点同步来的聊天室消息都投递给本节点上的所有客户端
 func registerMessage(hub *chat.Hub) {
 	hub.Subscribe(chat.EventMessageLocal, func(e chat.Event) {
 		me := e.(*chat.MessageEvent)
-		text := "[" + me.When.Format("2006-01-02 15:04:05") + "] " + me.From + ": " + me.Content
-		hub.SendToAll(text)
+		text := me.Text()
+		hub.BroadcastEvent(me, func(*chat.Client) string { return text })
 		observe.IncMessage("local")
 	})
 	hub.Subscribe(chat.EventMessageRemote, func(e chat.Event) {
-		// 预留：远端消息可由分布式总线消费端发出
-		_ = e
+		me := e.(*chat.MessageEvent)
+		text := me.Text()
+		hub.BroadcastEvent(me, func(*chat.Client) string { return text })
 		observe.IncMessage("remote")
 	})
 }
@@ -34,18 +42,18 @@ func registerMessage(hub *chat.Hub) {
 func registerUserLifecycle(hub *chat.Hub) {
 	hub.Subscribe(chat.EventUserJoined, func(e chat.Event) {
 		ue := e.(*chat.UserEvent)
-		hub.SendToAll("[系统] " + ue.User.Name + " 加入")
+		hub.BroadcastEvent(ue, func(c *chat.Client) string { return i18n.T(c.Locale(), "system.joined", ue.User.Name()) })
 	})
 	hub.Subscribe(chat.EventUserLeave, func(e chat.Event) {
 		ue := e.(*chat.UserEvent)
-		hub.SendToAll("[系统] " + ue.User.Name + " 离开")
+		hub.BroadcastEvent(ue, func(c *chat.Client) string { return i18n.T(c.Locale(), "system.left", ue.User.Name()) })
 	})
 }
 
 func registerSystem(hub *chat.Hub) {
 	hub.Subscribe(chat.EventSystemNotice, func(e chat.Event) {
 		se := e.(*chat.SystemNoticeEvent)
-		hub.SendToAll("[系统通知][" + se.Level + "] " + se.Content)
+		hub.SendToAllLocalized("system.notice", se.Level, se.Content)
 	})
 }
 
@@ -54,10 +62,10 @@ func registerFile(hub *chat.Hub) {
 		fe := e.(*chat.FileTransferEvent)
 		target := fe.To
 		if target == "" || target == "*" {
-			hub.SendToAll("[文件] " + fe.From + " -> 所有人: " + fe.FileName)
+			hub.SendToAllLocalized("file.to_all", fe.From, fe.FileName)
 			return
 		}
-		hub.SendToAll("[文件] " + fe.From + " -> " + target + ": " + fe.FileName)
+		hub.SendToAllLocalized("file.to_user", fe.From, target, fe.FileName)
 	})
 }
 
@@ -73,12 +81,83 @@ func registerHeartbeat(hub *chat.Hub) {
 func registerDirect(hub *chat.Hub) {
 	hub.Subscribe(chat.EventMessageDirect, func(e chat.Event) {
 		de := e.(*chat.DirectMessageEvent)
-		// TCP 客户端走 Hub 点对点
-		sent := hub.SendToUser(de.To, "[私信] "+de.From+": "+de.Content)
-		if !sent {
-			// 找不到目标，可回执给发送者（也会被 WS 的连接侧订阅到，但这条主要面向 TCP）
-			hub.SendToUser(de.From, "[系统] 用户不在线或不存在: "+de.To)
+		// TCP 客户端走 Hub 点对点；群组私信逐个接收者投递，文本中列出全部接收者
+		for _, to := range de.To {
+			sent := hub.DeliverEvent(to, de, func(c *chat.Client) string {
+				if len(de.To) > 1 {
+					return i18n.T(c.Locale(), "direct.group", strings.Join(de.To, ","), de.From, de.Content)
+				}
+				return i18n.T(c.Locale(), "direct.message", de.From, de.Content)
+			})
+			switch {
+			case sent && de.ID != "":
+				hub.MarkDelivered(de.ID, to)
+			case !sent && !de.Remote && !onlineElsewhere(hub, to):
+				// 找不到目标，逐个回执给发送者（也会被 WS 的连接侧订阅到，但这条主要面向 TCP）
+				hub.SendToUserLocalized(de.From, "system.user_offline", to)
+			}
 		}
 		observe.IncDirect()
 	})
 }
+
+// onlineElsewhere 用户是否在其它节点在线（由跨节点同步的在线状态判断，隐身视为不在线），
+// 此时私信经总线转发投递，不提示发送者对方不在线
+func onlineElsewhere(hub *chat.Hub, name string) bool {
+	p, ok := hub.PresenceOf(name)
+	return ok && p.Visible().Status != chat.StatusOffline
+}
+
+func registerPresence(hub *chat.Hub) {
+	hub.Subscribe(chat.EventPresence, func(e chat.Event) {
+		// 状态变化只推送给支持结构化消息的会话，文本客户端通过 /whois 查询。
+		// 事件异步分发可能乱序，推送时取用户的最新状态
+		pe := e.(*chat.PresenceEvent)
+		if p, ok := hub.PresenceOf(pe.Presence.User); ok {
+			pe = &chat.PresenceEvent{When: pe.When, Presence: p, Remote: pe.Remote}
+		}
+		hub.NotifyObservers(pe)
+	})
+}
+
+func registerTyping(hub *chat.Hub) {
+	hub.Subscribe(chat.EventTyping, func(e chat.Event) {
+		// 输入提示只推送给支持结构化消息的会话，不计入消息指标
+		te := e.(*chat.TypingEvent)
+		if te.To == "" {
+			hub.NotifyObservers(te)
+			return
+		}
+		hub.NotifyUser(te.To, te)
+	})
+}
+
+func registerReceipt(hub *chat.Hub) {
+	hub.Subscribe(chat.EventReceipt, func(e chat.Event) {
+		// 投递回执以 ack 消息推送给私信发送者，文本客户端不推送
+		re := e.(*chat.ReceiptEvent)
+		hub.NotifyUser(re.From, re)
+	})
+}
+
+func registerEdit(hub *chat.Hub) {
+	// 编辑与删除通知发给原消息的所有接收者：聊天室消息广播，私信发给作者与各接收者
+	deliver := func(e chat.Event) {
+		me := e.(*chat.MessageEditEvent)
+		render := func(c *chat.Client) string {
+			if me.Deleted {
+				return i18n.T(c.Locale(), "message.deleted", me.From)
+			}
+			return i18n.T(c.Locale(), "message.edited", me.From, me.Content)
+		}
+		if len(me.To) == 0 {
+			hub.BroadcastEvent(me, render)
+			return
+		}
+		for _, name := range chat.Recipients(append([]string{me.From}, me.To...)) {
+			hub.DeliverEvent(name, me, render)
+		}
+	}
+	hub.Subscribe(chat.EventMessageEdit, deliver)
+	hub.Subscribe(chat.EventMessageDelete, deliver)
+}
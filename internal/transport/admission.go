@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hongjun500/chat-go/internal/observe"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// rejectWriteTimeout 向被拒绝的连接写入原因的最长时间
+const rejectWriteTimeout = time.Second
+
+// Admission 连接准入控制：全局接入速率（令牌桶）、全局并发上限与单 IP 并发上限。
+// 同一个 Admission 可在多个传输间共享，上限按全部传输合计；nil 表示不限制。
+type Admission struct {
+	mu     sync.Mutex
+	max    int
+	total  int
+	perIP  *ipLimiter
+	rate   float64 // 每秒补充的令牌数，0 不限制
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewAdmission 创建准入控制：maxConns 全局并发上限，maxPerIP 单 IP 并发上限，
+// rate 每秒允许接入的新连接数，burst 突发容量（<= 0 时取 rate，至少为 1）；各项 <= 0 表示不限制
+func NewAdmission(maxConns, maxPerIP int, rate float64, burst int) *Admission {
+	b := float64(burst)
+	if b <= 0 {
+		b = max(rate, 1)
+	}
+	return &Admission{
+		max:    maxConns,
+		perIP:  newIPLimiter(maxPerIP),
+		rate:   rate,
+		burst:  b,
+		tokens: b,
+		last:   time.Now(),
+	}
+}
+
+// Admit 检查来自 ip 的新连接能否接入（ip 为空时不做单 IP 限制）。
+// 通过时返回释放函数，连接关闭时调用一次；拒绝时返回带错误码的原因并计入拒绝指标
+func (a *Admission) Admit(transport, ip string) (release func(), rejected *protocol.Error) {
+	if a == nil {
+		return func() {}, nil
+	}
+	if err := a.admit(ip); err != nil {
+		observe.IncRejectedConnection(transport, err.Reason())
+		logger.L().Sugar().Warnw("connection_rejected", "transport", transport, "ip", ip, "reason", err.Reason())
+		return nil, err
+	}
+	observe.AddConnections(transport, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			a.total--
+			a.mu.Unlock()
+			if ip != "" {
+				a.perIP.release(ip)
+			}
+			observe.AddConnections(transport, -1)
+		})
+	}, nil
+}
+
+func (a *Admission) admit(ip string) *protocol.Error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rate > 0 {
+		now := time.Now()
+		a.tokens = min(a.burst, a.tokens+now.Sub(a.last).Seconds()*a.rate)
+		a.last = now
+		if a.tokens < 1 {
+			return protocol.NewError(protocol.CodeRateLimited, "")
+		}
+		a.tokens--
+	}
+	if a.max > 0 && a.total >= a.max {
+		return protocol.NewError(protocol.CodeServerFull, "")
+	}
+	if ip != "" && !a.perIP.acquire(ip) {
+		return protocol.NewError(protocol.CodeIPLimit, "")
+	}
+	a.total++
+	return nil
+}
+
+// remoteIP 连接的对端 IP，非 IP 地址（Unix 域套接字）返回空
+func remoteIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP.String()
+	}
+	return hostOnly(addr.String())
+}
+
+// rejectEnvelope 拒绝原因对应的 error 消息
+func rejectEnvelope(factory *protocol.MessageFactory, err *protocol.Error) *protocol.Envelope {
+	return factory.CreateErrorMessage(err, "")
+}
+
+// rejectFramed 以长度前缀帧写入拒绝原因后关闭连接，编码使用监听器默认编解码器
+func rejectFramed(conn net.Conn, pm *protocol.Manager, err *protocol.Error) {
+	defer conn.Close()
+	var buf bytes.Buffer
+	if pm.EncodeMessage(&buf, rejectEnvelope(pm.GetMessageFactory(), err)) != nil {
+		return
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+	_ = NewFrameCodec().WriteFrame(conn, buf.Bytes())
+}
+
+// rejectLine 以文本行写入拒绝原因（行协议与 IRC 使用各自的前缀）后关闭连接
+func rejectLine(conn net.Conn, line string) {
+	defer conn.Close()
+	_ = conn.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+	_, _ = conn.Write([]byte(strings.TrimRight(line, "\r\n") + "\r\n"))
+}
+
+// rejectWebSocket 完成升级后发送拒绝原因，再以 1013（Try Again Later）关闭
+func rejectWebSocket(conn *websocket.Conn, pm *protocol.Manager, err *protocol.Error) {
+	defer conn.Close()
+	var buf bytes.Buffer
+	if pm.EncodeMessage(&buf, rejectEnvelope(pm.GetMessageFactory(), err)) != nil {
+		return
+	}
+	deadline := time.Now().Add(rejectWriteTimeout)
+	_ = conn.SetWriteDeadline(deadline)
+	_ = conn.WriteMessage(wsMessageType(pm.GetCodec().Name()), buf.Bytes())
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Reason()), deadline)
+}
+
+// rejectHTTP 以 HTTP 错误状态返回拒绝原因，响应体为 JSON error 消息
+func rejectHTTP(w http.ResponseWriter, pm *protocol.Manager, err *protocol.Error) {
+	status := http.StatusTooManyRequests
+	if err.Code == protocol.CodeServerFull {
+		status = http.StatusServiceUnavailable
+	}
+	var buf bytes.Buffer
+	_ = pm.EncodeMessage(&buf, rejectEnvelope(pm.GetMessageFactory(), err))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+func TestAdmissionLimits(t *testing.T) {
+	var none *Admission
+	if release, err := none.Admit(Tcp, "10.0.0.1"); err != nil {
+		t.Fatalf("nil admission rejected: %v", err)
+	} else {
+		release()
+	}
+
+	a := NewAdmission(3, 2, 0, 0)
+	r1, _ := a.Admit(Tcp, "10.0.0.1")
+	r2, _ := a.Admit(WebSocket, "10.0.0.1")
+	if _, err := a.Admit(Tcp, "10.0.0.1"); err == nil || err.Code != protocol.CodeIPLimit {
+		t.Fatalf("third conn from same ip = %v, want ip_limit", err)
+	}
+	r3, err := a.Admit(Tcp, "10.0.0.2")
+	if err != nil {
+		t.Fatalf("other ip rejected: %v", err)
+	}
+	if _, err := a.Admit(Unix, ""); err == nil || err.Code != protocol.CodeServerFull {
+		t.Fatalf("fourth conn = %v, want server_full", err)
+	}
+	// 释放可重复调用，只归还一次名额
+	r1()
+	r1()
+	if _, err := a.Admit(Unix, ""); err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+	if _, err := a.Admit(Unix, ""); err == nil || err.Code != protocol.CodeServerFull {
+		t.Fatalf("double release must not free two slots, got %v", err)
+	}
+	r2()
+	r3()
+}
+
+func TestAdmissionRate(t *testing.T) {
+	a := NewAdmission(0, 0, 20, 2)
+	for i := 0; i < 2; i++ {
+		if _, err := a.Admit(Tcp, "10.0.0.1"); err != nil {
+			t.Fatalf("burst conn %d rejected: %v", i, err)
+		}
+	}
+	if _, err := a.Admit(Tcp, "10.0.0.2"); err == nil || err.Code != protocol.CodeRateLimited {
+		t.Fatalf("over burst = %v, want rate_limited", err)
+	}
+	// 20/s：约 50ms 补充一个令牌
+	time.Sleep(80 * time.Millisecond)
+	if _, err := a.Admit(Tcp, "10.0.0.2"); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+}
+
+// TestTCPAdmissionReject 超出上限的连接先收到带错误码的 error 消息再被关闭
+func TestTCPAdmissionReject(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	adm := NewAdmission(1, 0, 0, 0)
+	// 先占满唯一的名额
+	hold, _ := adm.Admit("test", "")
+	addr := freeAddr(t)
+	go func() {
+		_ = NewTCPServer(addr).Start(ctx, addr, NewSimpleGateway(), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+			Admission:          adm,
+		})
+	}()
+
+	conn := dialTCP(t, addr)
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	fc := NewFrameCodec()
+	frame, err := fc.ReadFrame(conn)
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	var env protocol.Envelope
+	if err := (&protocol.JSONCodec{}).Decode(bytes.NewReader(frame), &env, 1<<20); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	p, err := protocol.DecodePayload[protocol.ErrorPayload](&env)
+	if err != nil || env.Type != protocol.MsgError {
+		t.Fatalf("got %s, %v; want error message", env.Type, err)
+	}
+	if p.Code != protocol.CodeServerFull || p.Reason != "server_full" {
+		t.Errorf("got code=%d reason=%q, want server_full", p.Code, p.Reason)
+	}
+	if _, err := fc.ReadFrame(conn); err == nil {
+		t.Error("rejected connection must be closed")
+	}
+
+	// 名额释放后可以接入
+	hold()
+	ok := dialTCP(t, addr)
+	defer ok.Close()
+	_ = ok.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if frame, err := fc.ReadFrame(ok); err == nil {
+		var env protocol.Envelope
+		if (&protocol.JSONCodec{}).Decode(bytes.NewReader(frame), &env, 1<<20) == nil && env.Type == protocol.MsgError {
+			t.Fatalf("admitted connection got error: %s", frame)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package transport
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/i18n"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// ChatGateway 将传输层会话接入聊天业务：每个会话对应一个 chat.Client，
+// 入站的 nick/text/command/direct/presence/typing/read/edit/delete 消息转为 Hub 事件或命令调用，
+// Client 的输出文本以 text 消息回写到会话，私信、在线状态变化、输入提示、私信回执与消息的编辑/删除以 direct、presence、typing、ack、edit、delete 消息推送；
+// 业务错误以带错误码的 error 消息返回。
+type ChatGateway struct {
+	*SimpleGateway
+	hub      *chat.Hub
+	commands *command.Registry
+
+	clients sync.Map // 会话ID -> *chat.Client
+
+	drainMu  sync.Mutex
+	draining bool
+	pumps    sync.WaitGroup // 每个会话的输出泵
+}
+
+// NewChatGateway 创建接入聊天业务的网关
+func NewChatGateway(hub *chat.Hub, commands *command.Registry) *ChatGateway {
+	g := &ChatGateway{
+		SimpleGateway: NewSimpleGateway(),
+		hub:           hub,
+		commands:      commands,
+	}
+	g.disp.Register(string(protocol.MsgNick), g.handleNick)
+	g.disp.Register(string(protocol.MsgText), g.handleText)
+	g.disp.Register(string(protocol.MsgCommand), g.handleCommand)
+	g.disp.Register(string(protocol.MsgDirect), g.handleDirect)
+	g.disp.Register(string(protocol.MsgPresence), g.handlePresence)
+	g.disp.Register(string(protocol.MsgTyping), g.handleTyping)
+	g.disp.Register(string(protocol.MsgRead), g.handleRead)
+	g.disp.Register(string(protocol.MsgEdit), g.handleEdit)
+	g.disp.Register(string(protocol.MsgDelete), g.handleDelete)
+	return g
+}
+
+// OnSessionOpen 创建会话对应的聊天客户端，并提示输入昵称
+func (g *ChatGateway) OnSessionOpen(sc *SessionContext) {
+	g.SimpleGateway.OnSessionOpen(sc)
+
+	client := chat.NewClientWithBuffer(sc.Id, 0)
+	client.Observer = g.observer(sc, client)
+	if l, ok := sc.sess.(latencyReporter); ok {
+		client.Latency = l.Latency
+	}
+	g.drainMu.Lock()
+	if g.draining {
+		// 停机排空中：不再接纳新会话
+		g.drainMu.Unlock()
+		sendShutdownNotice(sc, client)
+		_ = sc.Close()
+		return
+	}
+	g.clients.Store(sc.Id, client)
+	g.pumps.Add(1)
+	g.drainMu.Unlock()
+	go g.pump(sc, client)
+
+	// 传输层已认证身份（mTLS）时直接以该身份登录
+	if sc.Identity != "" {
+		g.login(sc, sc.Identity, "", "")
+		return
+	}
+	if err := sc.Send(sc.Factory().CreateTextMessage(i18n.T(client.Locale(), "session.prompt_nick"))); err != nil {
+		logger.L().Sugar().Warnw("send_prompt_failed", "session", sc.Id, "err", err)
+	}
+}
+
+// OnSessionClose 注销聊天客户端
+func (g *ChatGateway) OnSessionClose(sc *SessionContext) {
+	if v, ok := g.clients.LoadAndDelete(sc.Id); ok {
+		g.hub.UnregisterClient(v.(*chat.Client))
+	}
+	g.SimpleGateway.OnSessionClose(sc)
+}
+
+// Online 在线用户昵称
+func (g *ChatGateway) Online() []string {
+	return g.hub.ListNames()
+}
+
+// observer 会话自身的观察者（如 IRC）优先处理事件；其余会话中，私信以带原 mid 与会话 ID 的 direct 消息推送，
+// 在线状态变化以 presence 消息推送（他人隐身时显示为 offline），他人的输入提示以 typing 消息推送，
+// 私信回执以 ack 消息推送；聊天室消息以带原 mid 的 text 消息推送，编辑与删除以 edit、delete 消息推送。
+// 只能呈现文本的会话不推送结构化消息，聊天室消息、私信与编辑/删除仍以文本送达
+func (g *ChatGateway) observer(sc *SessionContext, client *chat.Client) chat.EventObserver {
+	var next chat.EventObserver
+	if o, ok := sc.sess.(eventObserver); ok {
+		next = o.ObserveEvent
+	}
+	t, ok := sc.sess.(textOnly)
+	plain := ok && t.TextOnly()
+	return func(e chat.Event) bool {
+		if next != nil && next(e) {
+			return true
+		}
+		if plain {
+			switch e.(type) {
+			case *chat.PresenceEvent, *chat.TypingEvent, *chat.ReceiptEvent:
+				return true
+			}
+			return false
+		}
+		switch ev := e.(type) {
+		case *chat.MessageEvent:
+			if ev.ID == "" {
+				return false
+			}
+			msg := sc.Factory().CreateTextMessage(ev.Text())
+			msg.Mid, msg.From = ev.ID, ev.From
+			if err := sc.Send(msg); err != nil {
+				logger.L().Sugar().Debugw("send_text_failed", "session", sc.Id, "err", err)
+			}
+			return true
+		case *chat.MessageEditEvent:
+			msg := sc.Factory().CreateEditedMessage(ev.ID, ev.From, ev.By, ev.Content, ev.When.UnixMilli(), ev.History)
+			if ev.Deleted {
+				msg = sc.Factory().CreateDeletedMessage(ev.ID, ev.From, ev.By, ev.When.UnixMilli())
+			}
+			if err := sc.Send(msg); err != nil {
+				logger.L().Sugar().Debugw("send_edit_failed", "session", sc.Id, "err", err)
+			}
+			return true
+		case *chat.DirectMessageEvent:
+			msg := sc.Factory().CreateConversationMessage(ev.Conversation, ev.From, ev.To, ev.Content)
+			if ev.ID != "" {
+				msg.Mid = ev.ID
+			}
+			if err := sc.Send(msg); err != nil {
+				logger.L().Sugar().Debugw("send_direct_failed", "session", sc.Id, "err", err)
+			}
+			return true
+		case *chat.ReceiptEvent:
+			if err := sc.Send(sc.Factory().CreateReceiptMessage(string(ev.State), ev.User, ev.ID)); err != nil {
+				logger.L().Sugar().Debugw("send_receipt_failed", "session", sc.Id, "err", err)
+			}
+			return true
+		case *chat.PresenceEvent:
+			p := ev.Presence
+			if p.User != client.Name() {
+				p = p.Visible()
+			}
+			if err := sc.Send(presenceMessage(sc.Factory(), p)); err != nil {
+				logger.L().Sugar().Debugw("send_presence_failed", "session", sc.Id, "err", err)
+			}
+			return true
+		case *chat.TypingEvent:
+			if ev.From == client.Name() {
+				return true
+			}
+			if err := sc.Send(sc.Factory().CreateTypingMessage(ev.From, ev.To, ev.Active)); err != nil {
+				logger.L().Sugar().Debugw("send_typing_failed", "session", sc.Id, "err", err)
+			}
+			return true
+		}
+		return false
+	}
+}
+
+// presenceMessage 在线状态对应的 presence 消息
+func presenceMessage(f *protocol.MessageFactory, p chat.Presence) *protocol.Envelope {
+	var lastSeen int64
+	if !p.LastSeen.IsZero() {
+		lastSeen = p.LastSeen.UnixMilli()
+	}
+	return f.CreatePresenceMessage(p.User, string(p.Status), p.Text, lastSeen)
+}
+
+// pump 将客户端输出文本写回会话；客户端被注销（/quit、/kick）后关闭会话
+func (g *ChatGateway) pump(sc *SessionContext, client *chat.Client) {
+	defer g.pumps.Done()
+	for text := range client.Outgoing() {
+		if err := sc.Send(sc.Factory().CreateTextMessage(text)); err != nil {
+			logger.L().Sugar().Debugw("send_text_failed", "session", sc.Id, "err", err)
+		}
+	}
+	_ = sc.Close()
+}
+
+// Drain 优雅停机：之后打开的会话收到停机通知后立即关闭；现有会话收到停机通知并从 Hub 注销，
+// 输出缓冲中剩余的消息写完后关闭会话。ctx 到期时不再等待，返回 ctx.Err()。
+func (g *ChatGateway) Drain(ctx context.Context) error {
+	g.drainMu.Lock()
+	g.draining = true
+	g.drainMu.Unlock()
+
+	g.clients.Range(func(key, v any) bool {
+		client := v.(*chat.Client)
+		if sc, ok := g.GetSession(key.(string)); ok {
+			sendShutdownNotice(sc, client)
+		}
+		// 注销后 Hub 不再投递，Client.Close 关闭输出通道，pump 写完剩余消息后关闭会话
+		g.hub.UnregisterClient(client)
+		return true
+	})
+
+	done := make(chan struct{})
+	go func() {
+		g.pumps.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendShutdownNotice 以 server_shutdown 错误通知会话服务器即将关闭
+func sendShutdownNotice(sc *SessionContext, client *chat.Client) {
+	sendError(sc, protocol.NewError(protocol.CodeServerShutdown, i18n.T(client.Locale(), "session.shutdown")), "")
+}
+
+func (g *ChatGateway) client(sc *SessionContext) (*chat.Client, bool) {
+	v, ok := g.clients.Load(sc.Id)
+	if !ok {
+		return nil, false
+	}
+	return v.(*chat.Client), true
+}
+
+// loggedIn 返回已设置昵称的客户端，未登录时回复 not_logged_in 错误
+func (g *ChatGateway) loggedIn(sc *SessionContext, msg *protocol.Envelope) (*chat.Client, bool) {
+	client, ok := g.client(sc)
+	if !ok || client.Name() == "" {
+		sendError(sc, protocol.NewError(protocol.CodeNotLoggedIn, ""), msg.Mid)
+		return nil, false
+	}
+	return client, true
+}
+
+// login 设置昵称与会话语言；首次设置时注册到 Hub，已被在线用户使用的昵称回复 name_in_use。不支持的语言忽略，沿用当前语言。
+// 已认证身份的会话昵称固定为该身份，nick 消息只用于设置语言。
+func (g *ChatGateway) login(sc *SessionContext, nick string, locale string, correlationID string) {
+	client, ok := g.client(sc)
+	if !ok {
+		return
+	}
+	if sc.Identity != "" {
+		nick = sc.Identity
+	}
+	nick = strings.TrimSpace(nick)
+	if nick == "" {
+		sendError(sc, protocol.NewError(protocol.CodeBadArguments, "nickname is empty"), correlationID)
+		return
+	}
+	if g.hub.IsBanned(nick) {
+		sendError(sc, protocol.Errorf(protocol.CodeBanned, "user %s is banned", nick), correlationID)
+		return
+	}
+	if l, ok := i18n.Match(locale); ok {
+		client.SetLocale(l)
+	}
+	if err := g.hub.Login(client, nick, sc.Identity != ""); err != nil {
+		sendError(sc, chatError(err), correlationID)
+		return
+	}
+	if err := sc.Send(sc.Factory().CreateAckMessage("ok", correlationID)); err != nil {
+		logger.L().Sugar().Warnw("send_ack_failed", "session", sc.Id, "err", err)
+	}
+}
+
+func (g *ChatGateway) handleNick(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.SetNickPayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	g.login(sc, p.Nick, p.Locale, msg.Mid)
+}
+
+// handleText 未登录时第一条文本作为昵称（兼容逐行输入的客户端），之后广播到聊天室；
+// mid 已被其他发送者使用时回复 duplicate_message_id
+func (g *ChatGateway) handleText(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.TextPayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.client(sc)
+	if !ok {
+		return
+	}
+	if client.Name() == "" {
+		g.login(sc, p.Text, "", msg.Mid)
+		return
+	}
+	g.hub.Touch(client)
+	// 消息发出即结束输入
+	g.hub.Typing(client.Name(), "", false)
+	id, err := g.hub.SendRoom(msg.Mid, client.Name(), p.Text)
+	g.sent(sc, msg, client, id, err)
+}
+
+func (g *ChatGateway) handleCommand(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.CommandPayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.loggedIn(sc, msg)
+	if !ok {
+		return
+	}
+	g.hub.Touch(client)
+	handled, err := g.commands.Execute(p.Raw, &command.Context{Hub: g.hub, Client: client, Raw: p.Raw, Mid: msg.Mid})
+	if !handled {
+		err = protocol.Errorf(protocol.CodeCommandNotFound, "not a command: %s", p.Raw)
+	}
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeCommandFailed), msg.Mid)
+	}
+}
+
+// handleDirect 发送私信，to 有多个接收者时作为一条群组私信发送，投递回执按接收者分别推送；
+// mid 已被其他发送者使用时回复 duplicate_message_id
+func (g *ChatGateway) handleDirect(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.DirectPayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.loggedIn(sc, msg)
+	if !ok {
+		return
+	}
+	g.hub.Touch(client)
+	for _, to := range p.To {
+		g.hub.Typing(client.Name(), to, false)
+	}
+	id, err := g.hub.SendDirect(msg.Mid, client.Name(), p.To, p.Content)
+	g.sent(sc, msg, client, id, err)
+}
+
+// handlePresence 设置自身在线状态，等同于 /status；user 字段被忽略
+func (g *ChatGateway) handlePresence(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.PresencePayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.loggedIn(sc, msg)
+	if !ok {
+		return
+	}
+	status, ok := chat.ParseStatus(p.Status)
+	if !ok {
+		sendError(sc, protocol.Errorf(protocol.CodeBadArguments, "invalid status: %s", p.Status), msg.Mid)
+		return
+	}
+	g.hub.SetPresence(client.Name(), status, p.Text)
+	if err := sc.Send(sc.Factory().CreateAckMessage("ok", msg.Mid)); err != nil {
+		logger.L().Sugar().Warnw("send_ack_failed", "session", sc.Id, "err", err)
+	}
+}
+
+// handleTyping 开始或停止输入，to 为空表示聊天室；不回复 ack，节流与过期由 Hub 处理
+func (g *ChatGateway) handleTyping(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.TypingPayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.loggedIn(sc, msg)
+	if !ok {
+		return
+	}
+	if p.Active {
+		g.hub.Touch(client)
+	}
+	g.hub.Typing(client.Name(), strings.TrimSpace(p.To), p.Active)
+}
+
+// handleRead 标记 peer 发来的私信已读到 up_to 为止，peer 收到累计的已读回执
+func (g *ChatGateway) handleRead(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.ReadPayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.loggedIn(sc, msg)
+	if !ok {
+		return
+	}
+	g.hub.Touch(client)
+	if !g.hub.MarkRead(client.Name(), p.Peer, p.UpTo) {
+		sendError(sc, protocol.Errorf(protocol.CodeBadArguments, "no direct message %s from %s", p.UpTo, p.Peer), msg.Mid)
+	}
+}
+
+// handleEdit 编辑自己发送的消息（管理员可编辑任何消息），成功时回复 ack，编辑通知推送给原消息的所有接收者
+func (g *ChatGateway) handleEdit(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.EditPayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.loggedIn(sc, msg)
+	if !ok {
+		return
+	}
+	g.hub.Touch(client)
+	g.changed(sc, msg, g.hub.EditMessage(p.Mid, client.Name(), command.IsModerator(client), p.Content))
+}
+
+// handleDelete 删除消息，授权规则同 handleEdit；接收者收到不含原内容的 delete 墓碑
+func (g *ChatGateway) handleDelete(sc *SessionContext, msg *protocol.Envelope) {
+	p, err := protocol.DecodePayload[protocol.DeletePayload](msg)
+	if err != nil {
+		sendError(sc, protocol.AsError(err, protocol.CodeInvalidMessage), msg.Mid)
+		return
+	}
+	client, ok := g.loggedIn(sc, msg)
+	if !ok {
+		return
+	}
+	g.hub.Touch(client)
+	g.changed(sc, msg, g.hub.DeleteMessage(p.Mid, client.Name(), command.IsModerator(client)))
+}
+
+// sent 处理聊天室消息或私信的发送结果：成功时记录为本连接最近发送的消息（/edit last），失败时回复带错误码的 error
+func (g *ChatGateway) sent(sc *SessionContext, msg *protocol.Envelope, client *chat.Client, id string, err error) {
+	if err != nil {
+		sendError(sc, chatError(err), msg.Mid)
+		return
+	}
+	client.SetLastSent(id)
+}
+
+// changed 回复编辑/删除的结果：成功时 ack，失败时带错误码的 error
+func (g *ChatGateway) changed(sc *SessionContext, msg *protocol.Envelope, err error) {
+	if err != nil {
+		sendError(sc, chatError(err), msg.Mid)
+		return
+	}
+	if err := sc.Send(sc.Factory().CreateAckMessage("ok", msg.Mid)); err != nil {
+		logger.L().Sugar().Warnw("send_ack_failed", "session", sc.Id, "err", err)
+	}
+}
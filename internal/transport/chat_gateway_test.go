@@ -0,0 +1,458 @@
+package transport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/internal/subscriber"
+)
+
+// memSession 内存会话，记录发送给客户端的消息
+type memSession struct {
+	*Base
+	out chan *protocol.Envelope
+}
+
+func newMemSession(id string) *memSession {
+	return &memSession{Base: NewBase(id, "mem"), out: make(chan *protocol.Envelope, 64)}
+}
+
+func (s *memSession) SendEnvelope(e *protocol.Envelope) error {
+	s.out <- e
+	return nil
+}
+
+func (s *memSession) Close() error { return nil }
+
+// next 等待下一条指定类型的消息
+func (s *memSession) next(t *testing.T, mt protocol.MessageType) *protocol.Envelope {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-s.out:
+			if e.Type == mt {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", mt)
+		}
+	}
+}
+
+// TestChatGatewayErrorCodes 网关与命令错误以带错误码的 error 消息返回
+func TestChatGatewayErrorCodes(t *testing.T) {
+	hub := chat.NewHub()
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	g := NewChatGateway(hub, reg)
+	sess := newMemSession("s1")
+	sc := NewSessionContext(sess)
+	g.OnSessionOpen(sc)
+	defer g.OnSessionClose(sc)
+
+	f := protocol.NewMessageFactory()
+	expectError := func(msg *protocol.Envelope, code protocol.ErrorCode) {
+		t.Helper()
+		g.OnEnvelope(sc, msg)
+		env := sess.next(t, protocol.MsgError)
+		p, err := protocol.DecodePayload[protocol.ErrorPayload](env)
+		if err != nil {
+			t.Fatalf("decode error payload: %v", err)
+		}
+		if p.Code != code || p.Reason != code.Reason() || env.Correlation != msg.Mid {
+			t.Errorf("got code=%d reason=%q correlation=%q, want %d %q %q", p.Code, p.Reason, env.Correlation, code, code.Reason(), msg.Mid)
+		}
+	}
+
+	expectError(f.CreateCommandMessage("/who"), protocol.CodeNotLoggedIn)
+
+	nick := f.CreateSetNickMessage("alice")
+	g.OnEnvelope(sc, nick)
+	if ack := sess.next(t, protocol.MsgAck); ack.Correlation != nick.Mid {
+		t.Errorf("ack correlation: got %q, want %q", ack.Correlation, nick.Mid)
+	}
+
+	expectError(f.CreateCommandMessage("/nope"), protocol.CodeCommandNotFound)
+	expectError(f.CreateCommandMessage("/kick bob"), protocol.CodePermissionDenied)
+	expectError(f.CreateCommandMessage("/auth"), protocol.CodeBadArguments)
+	expectError(&protocol.Envelope{Type: protocol.MsgAck, Mid: "ack-1"}, protocol.CodeUnknownType)
+
+	hub.BanFor("mallory", 0)
+	expectError(f.CreateSetNickMessage("mallory"), protocol.CodeBanned)
+
+	// 在线用户的昵称不能被其它会话占用
+	bob := chat.NewClientWithBuffer("bob", 8)
+	if err := hub.Login(bob, "bob", false); err != nil {
+		t.Fatal(err)
+	}
+	expectError(f.CreateSetNickMessage("bob"), protocol.CodeNameInUse)
+}
+
+// TestChatGatewayPresence 状态变化以 presence 消息推送，他人隐身时显示为 offline
+func TestChatGatewayPresence(t *testing.T) {
+	hub := chat.NewHub()
+	subscriber.RegisterAll(hub)
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	g := NewChatGateway(hub, reg)
+	f := protocol.NewMessageFactory()
+	login := func(id, nick string) (*memSession, *SessionContext) {
+		sess := newMemSession(id)
+		sc := NewSessionContext(sess)
+		g.OnSessionOpen(sc)
+		g.OnEnvelope(sc, f.CreateSetNickMessage(nick))
+		sess.next(t, protocol.MsgAck)
+		return sess, sc
+	}
+	// nextPresence 等待 user 状态为 status 的 presence 消息（事件异步分发，中间状态可能先到）
+	nextPresence := func(s *memSession, user, status string) *protocol.PresencePayload {
+		t.Helper()
+		for {
+			p, err := protocol.DecodePayload[protocol.PresencePayload](s.next(t, protocol.MsgPresence))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.User == user && p.Status == status {
+				return p
+			}
+		}
+	}
+
+	aliceSess, aliceSC := login("a", "alice")
+	bobSess, bobSC := login("b", "bob")
+	defer g.OnSessionClose(bobSC)
+	nextPresence(aliceSess, "bob", "online")
+
+	set := f.CreatePresenceMessage("", "busy", "meeting", 0)
+	g.OnEnvelope(aliceSC, set)
+	if ack := aliceSess.next(t, protocol.MsgAck); ack.Correlation != set.Mid {
+		t.Errorf("ack correlation: got %q, want %q", ack.Correlation, set.Mid)
+	}
+	if p := nextPresence(bobSess, "alice", "busy"); p.Text != "meeting" {
+		t.Errorf("bob saw %+v, want status text", p)
+	}
+
+	// 隐身：本人收到 invisible，他人收到 offline
+	g.OnEnvelope(aliceSC, f.CreateCommandMessage("/status invisible"))
+	nextPresence(aliceSess, "alice", "invisible")
+	if p := nextPresence(bobSess, "alice", "offline"); p.LastSeen == 0 || p.Text != "" {
+		t.Errorf("bob saw %+v, want offline without text", p)
+	}
+
+	g.OnEnvelope(aliceSC, f.CreatePresenceMessage("", "sleeping", "", 0))
+	if env := aliceSess.next(t, protocol.MsgError); env.Correlation == "" {
+		t.Error("invalid status must be rejected")
+	}
+
+	g.OnSessionClose(aliceSC)
+	if p := nextPresence(bobSess, "alice", "offline"); p.LastSeen == 0 {
+		t.Errorf("alice left: bob saw %+v", p)
+	}
+}
+
+// TestChatGatewayTyping 聊天室输入提示推送给他人，私聊输入提示只推送给对方
+func TestChatGatewayTyping(t *testing.T) {
+	hub := chat.NewHub()
+	subscriber.RegisterAll(hub)
+	g := NewChatGateway(hub, command.NewRegistry())
+	f := protocol.NewMessageFactory()
+	login := func(id, nick string) (*memSession, *SessionContext) {
+		sess := newMemSession(id)
+		sc := NewSessionContext(sess)
+		g.OnSessionOpen(sc)
+		g.OnEnvelope(sc, f.CreateSetNickMessage(nick))
+		sess.next(t, protocol.MsgAck)
+		return sess, sc
+	}
+	aliceSess, aliceSC := login("a", "alice")
+	bobSess, bobSC := login("b", "bob")
+	carolSess, carolSC := login("c", "carol")
+	for _, sc := range []*SessionContext{aliceSC, bobSC, carolSC} {
+		defer g.OnSessionClose(sc)
+	}
+	typing := func(s *memSession) *protocol.TypingPayload {
+		t.Helper()
+		p, err := protocol.DecodePayload[protocol.TypingPayload](s.next(t, protocol.MsgTyping))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	g.OnEnvelope(aliceSC, f.CreateTypingMessage("", "", true))
+	for _, s := range []*memSession{bobSess, carolSess} {
+		if p := typing(s); p.User != "alice" || p.To != "" || !p.Active {
+			t.Errorf("room typing: got %+v", p)
+		}
+	}
+
+	g.OnEnvelope(aliceSC, f.CreateTypingMessage("", "bob", true))
+	if p := typing(bobSess); p.User != "alice" || p.To != "bob" || !p.Active {
+		t.Errorf("direct typing: got %+v", p)
+	}
+	// 发出私信即结束输入
+	g.OnEnvelope(aliceSC, f.CreateDirectMessage("alice", []string{"bob"}, "hi"))
+	if p := typing(bobSess); p.To != "bob" || p.Active {
+		t.Errorf("direct sent: got %+v, want stop", p)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	for name, s := range map[string]*memSession{"alice": aliceSess, "carol": carolSess} {
+		for len(s.out) > 0 {
+			if e := <-s.out; e.Type == protocol.MsgTyping {
+				t.Errorf("%s got unexpected typing from %s", name, e.From)
+			}
+		}
+	}
+}
+
+// TestChatGatewayReceipts 私信以原 mid 送达，发送者依次收到 sent、delivered 与累计的 read 回执
+func TestChatGatewayReceipts(t *testing.T) {
+	hub := chat.NewHub()
+	subscriber.RegisterAll(hub)
+	g := NewChatGateway(hub, command.NewRegistry())
+	f := protocol.NewMessageFactory()
+	login := func(id, nick string) (*memSession, *SessionContext) {
+		sess := newMemSession(id)
+		sc := NewSessionContext(sess)
+		g.OnSessionOpen(sc)
+		g.OnEnvelope(sc, f.CreateSetNickMessage(nick))
+		sess.next(t, protocol.MsgAck)
+		return sess, sc
+	}
+	aliceSess, aliceSC := login("a", "alice")
+	bobSess, bobSC := login("b", "bob")
+	defer g.OnSessionClose(aliceSC)
+	defer g.OnSessionClose(bobSC)
+
+	// waitReceipt 等待 mid 的 status 回执（事件异步分发，delivered 可能先于 sent，先到的回执记录下来）
+	seen := make(map[string]bool)
+	waitReceipt := func(mid, status string) {
+		t.Helper()
+		for !seen[mid+"/"+status] {
+			env := aliceSess.next(t, protocol.MsgAck)
+			p, err := protocol.DecodePayload[protocol.AckPayload](env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.User != "bob" {
+				t.Errorf("receipt user = %q, want bob", p.User)
+			}
+			seen[env.Correlation+"/"+p.Status] = true
+		}
+	}
+
+	first := f.CreateDirectMessage("alice", []string{"bob"}, "one")
+	second := f.CreateDirectMessage("alice", []string{"bob"}, "two")
+	for _, dm := range []*protocol.Envelope{first, second} {
+		g.OnEnvelope(aliceSC, dm)
+		got := bobSess.next(t, protocol.MsgDirect)
+		p, err := protocol.DecodePayload[protocol.DirectPayload](got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Mid != dm.Mid || got.From != "alice" {
+			t.Errorf("bob got mid=%q from=%q, want %q alice", got.Mid, got.From, dm.Mid)
+		}
+		want, _ := protocol.DecodePayload[protocol.DirectPayload](dm)
+		if p.Content != want.Content {
+			t.Errorf("content = %q, want %q", p.Content, want.Content)
+		}
+	}
+	waitReceipt(first.Mid, protocol.AckSent)
+	waitReceipt(second.Mid, protocol.AckDelivered)
+
+	g.OnEnvelope(bobSC, f.CreateReadMessage("alice", second.Mid))
+	waitReceipt(second.Mid, protocol.AckRead)
+	if r, _ := hub.DirectRecordOf(first.Mid, "bob"); r.State != chat.DeliveryRead {
+		t.Errorf("earlier message state = %s, want read", r.State)
+	}
+
+	bad := f.CreateReadMessage("carol", second.Mid)
+	g.OnEnvelope(bobSC, bad)
+	env := bobSess.next(t, protocol.MsgError)
+	if p, _ := protocol.DecodePayload[protocol.ErrorPayload](env); p.Code != protocol.CodeBadArguments || env.Correlation != bad.Mid {
+		t.Errorf("read from wrong peer: got %+v", p)
+	}
+}
+
+// TestChatGatewayGroupDirect 群组私信投递给每个在线接收者，带相同的会话 ID 与完整的接收者列表；
+// 不在线的接收者逐个提示发送者
+func TestChatGatewayGroupDirect(t *testing.T) {
+	hub := chat.NewHub()
+	subscriber.RegisterAll(hub)
+	g := NewChatGateway(hub, command.NewRegistry())
+	f := protocol.NewMessageFactory()
+	login := func(id, nick string) (*memSession, *SessionContext) {
+		sess := newMemSession(id)
+		sc := NewSessionContext(sess)
+		g.OnSessionOpen(sc)
+		g.OnEnvelope(sc, f.CreateSetNickMessage(nick))
+		sess.next(t, protocol.MsgAck)
+		return sess, sc
+	}
+	aliceSess, aliceSC := login("a", "alice")
+	bobSess, bobSC := login("b", "bob")
+	carolSess, carolSC := login("c", "carol")
+	defer g.OnSessionClose(aliceSC)
+	defer g.OnSessionClose(bobSC)
+	defer g.OnSessionClose(carolSC)
+
+	to := []string{"bob", "carol", "dave"}
+	dm := f.CreateDirectMessage("alice", to, "hi all")
+	g.OnEnvelope(aliceSC, dm)
+	conv := chat.ConversationID("alice", to)
+	for name, sess := range map[string]*memSession{"bob": bobSess, "carol": carolSess} {
+		got := sess.next(t, protocol.MsgDirect)
+		p, err := protocol.DecodePayload[protocol.DirectPayload](got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Mid != dm.Mid || p.Conversation != conv || strings.Join(p.To, ",") != "bob,carol,dave" {
+			t.Errorf("%s got mid=%q conversation=%q to=%v", name, got.Mid, p.Conversation, p.To)
+		}
+	}
+	// alice 还会收到 bob、carol 加入等系统通知，跳过直到离线提示
+	for {
+		p, _ := protocol.DecodePayload[protocol.TextPayload](aliceSess.next(t, protocol.MsgText))
+		if strings.Contains(p.Text, "dave") {
+			break
+		}
+	}
+}
+
+// TestChatGatewayEditDelete 聊天室消息带原 mid 推送；作者的编辑以带历史的 edit 消息推送给所有接收者，
+// 他人不能删除，删除以不含内容的 delete 墓碑推送
+func TestChatGatewayEditDelete(t *testing.T) {
+	hub := chat.NewHub()
+	subscriber.RegisterAll(hub)
+	g := NewChatGateway(hub, command.NewRegistry())
+	f := protocol.NewMessageFactory()
+	login := func(id, nick string) (*memSession, *SessionContext) {
+		sess := newMemSession(id)
+		sc := NewSessionContext(sess)
+		g.OnSessionOpen(sc)
+		g.OnEnvelope(sc, f.CreateSetNickMessage(nick))
+		sess.next(t, protocol.MsgAck)
+		return sess, sc
+	}
+	aliceSess, aliceSC := login("a", "alice")
+	bobSess, bobSC := login("b", "bob")
+	defer g.OnSessionClose(aliceSC)
+	defer g.OnSessionClose(bobSC)
+
+	text := f.CreateTextMessage("helo")
+	g.OnEnvelope(aliceSC, text)
+	// 跳过欢迎语等其它文本，bob 收到的聊天室消息带 alice 发送时的 mid
+	for bobSess.next(t, protocol.MsgText).Mid != text.Mid {
+	}
+
+	edit := f.CreateEditMessage(text.Mid, "hello")
+	g.OnEnvelope(aliceSC, edit)
+	if ack := aliceSess.next(t, protocol.MsgAck); ack.Correlation != edit.Mid {
+		t.Errorf("edit ack correlation = %q", ack.Correlation)
+	}
+	env := bobSess.next(t, protocol.MsgEdit)
+	p, err := protocol.DecodePayload[protocol.EditPayload](env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Mid != text.Mid || p.Content != "hello" || !p.Edited || p.By != "alice" || env.From != "alice" || len(p.History) != 1 || p.History[0] != "helo" {
+		t.Errorf("edit = %+v from %q", p, env.From)
+	}
+
+	del := f.CreateDeleteMessage(text.Mid)
+	g.OnEnvelope(bobSC, del)
+	env = bobSess.next(t, protocol.MsgError)
+	if e, _ := protocol.DecodePayload[protocol.ErrorPayload](env); e.Code != protocol.CodePermissionDenied || env.Correlation != del.Mid {
+		t.Errorf("delete by other user: got %+v", e)
+	}
+
+	g.OnEnvelope(aliceSC, f.CreateDeleteMessage(text.Mid))
+	env = bobSess.next(t, protocol.MsgDelete)
+	if d, _ := protocol.DecodePayload[protocol.DeletePayload](env); d.Mid != text.Mid || d.By != "alice" || d.DeletedAt == 0 {
+		t.Errorf("tombstone = %+v", d)
+	}
+
+	// 其它节点同步来的聊天室消息同样带原 mid 推送
+	hub.BroadcastRemote("r1", "dave", "from afar", time.Now())
+	env = bobSess.next(t, protocol.MsgText)
+	if p, _ := protocol.DecodePayload[protocol.TextPayload](env); env.Mid != "r1" || env.From != "dave" || !strings.Contains(p.Text, "from afar") {
+		t.Errorf("remote message mid=%q from=%q text=%q", env.Mid, env.From, p.Text)
+	}
+}
+
+// TestChatGatewayIdentityReserved 已认证身份的昵称为该身份保留：未认证会话不能再使用，之前占用的未认证会话被断开
+func TestChatGatewayIdentityReserved(t *testing.T) {
+	hub := chat.NewHub()
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	g := NewChatGateway(hub, reg)
+	f := protocol.NewMessageFactory()
+	open := func(id, identity string) (*memSession, *SessionContext) {
+		sess := newMemSession(id)
+		sc := NewSessionContext(sess)
+		sc.Identity = identity
+		g.OnSessionOpen(sc)
+		t.Cleanup(func() { g.OnSessionClose(sc) })
+		return sess, sc
+	}
+	nick := func(sess *memSession, sc *SessionContext, name string) *protocol.Envelope {
+		t.Helper()
+		g.OnEnvelope(sc, f.CreateSetNickMessage(name))
+		for {
+			if e := <-sess.out; e.Type == protocol.MsgAck || e.Type == protocol.MsgError {
+				return e
+			}
+		}
+	}
+	code := func(e *protocol.Envelope) protocol.ErrorCode {
+		t.Helper()
+		if e.Type == protocol.MsgAck {
+			return 0
+		}
+		p, err := protocol.DecodePayload[protocol.ErrorPayload](e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.Code
+	}
+
+	mallorySess, malloryCtx := open("m", "")
+	if c := code(nick(mallorySess, malloryCtx, "alice")); c != 0 {
+		t.Fatalf("mallory as alice before the identity logged in: code %d", c)
+	}
+	// 证书身份 alice 登录，先占用该昵称的未认证会话被断开
+	aliceSess, _ := open("a1", "alice")
+	aliceSess.next(t, protocol.MsgAck)
+	if c, _ := g.client(malloryCtx); !c.IsClosed() {
+		t.Error("unauthenticated alice was not disconnected")
+	}
+	// 同一身份可以有多个连接
+	second, _ := open("a2", "alice")
+	second.next(t, protocol.MsgAck)
+
+	eveSess, eveCtx := open("e", "")
+	if c := code(nick(eveSess, eveCtx, "alice")); c != protocol.CodeNameReserved {
+		t.Errorf("eve as online alice: code %d", c)
+	}
+	for _, id := range []string{"a1", "a2"} {
+		if c, ok := g.clients.Load(id); ok {
+			hub.UnregisterClient(c.(*chat.Client))
+		}
+	}
+	if c := code(nick(eveSess, eveCtx, "alice")); c != protocol.CodeNameReserved {
+		t.Errorf("eve as offline alice: code %d", c)
+	}
+}
@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// ClientConn 客户端连接，按地址 scheme 选择承载方式：
+//
+//	tcp://host:port   长度前缀帧（无 scheme 时默认）
+//	tls://host:port   TLS 上的长度前缀帧
+//	ws://host:port/ws WebSocket，wss:// 为 TLS 上的 WebSocket
+//
+// WebSocket 连接按编解码器声明对应的 Sec-WebSocket-Protocol 子协议。
+type ClientConn struct {
+	codec protocol.MessageCodec
+
+	conn net.Conn // 帧传输
+	fc   *FrameCodec
+
+	ws *websocket.Conn // WebSocket 传输
+
+	writeMu sync.Mutex // Read 自动应答 pong 时可能与调用方并发写
+}
+
+// ClientTLSConfig 创建客户端 TLS 配置：caFile 为空时使用系统根证书；
+// certFile/keyFile 用于双向 TLS；insecure 跳过服务端证书校验，仅用于调试
+func ClientTLSConfig(caFile, certFile, keyFile string, insecure bool) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecure}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// DialClient 按地址连接服务端；tlsCfg 仅用于 tls:// 与 wss://，为 nil 时使用默认配置
+func DialClient(addr string, codec protocol.MessageCodec, tlsCfg *tls.Config) (*ClientConn, error) {
+	if !strings.Contains(addr, "://") {
+		addr = "tcp://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg == nil {
+		tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	c := &ClientConn{codec: codec}
+	switch u.Scheme {
+	case "tcp":
+		c.conn, err = net.Dial(Tcp, u.Host)
+	case "unix":
+		c.conn, err = net.Dial(Unix, u.Path)
+	case "tls":
+		cfg := tlsCfg.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		c.conn, err = tls.Dial(Tcp, u.Host, cfg)
+	case "ws", "wss":
+		dialer := websocket.Dialer{
+			TLSClientConfig:   tlsCfg,
+			Subprotocols:      []string{wsSubprotocolFor(codec.Name())},
+			EnableCompression: true,
+		}
+		c.ws, _, err = dialer.Dial(u.String(), nil)
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q (want tcp, tls, unix, ws or wss)", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.conn != nil {
+		c.fc = NewFrameCodec()
+	}
+	return c, nil
+}
+
+// wsSubprotocolFor 编解码器名称对应的 WebSocket 子协议
+func wsSubprotocolFor(codecName string) string {
+	for _, sp := range wsSubprotocols {
+		if codec, err := protocol.NewCodec(sp.codec); err == nil && codec.Name() == codecName {
+			return sp.name
+		}
+	}
+	return ""
+}
+
+// Read 读取并解码一条消息；服务端发起的 ping 自动以同序号的 pong 应答，不返回给调用方
+func (c *ClientConn) Read(maxSize int) (*protocol.Envelope, error) {
+	for {
+		var data []byte
+		var err error
+		if c.ws != nil {
+			_, data, err = c.ws.ReadMessage()
+		} else {
+			data, err = c.fc.ReadFrame(c.conn)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var env protocol.Envelope
+		if err := c.codec.Decode(bytes.NewReader(data), &env, maxSize); err != nil {
+			return nil, err
+		}
+		if env.Type != protocol.MsgPing {
+			return &env, nil
+		}
+		var seq int64
+		if p, err := protocol.DecodePayload[protocol.PingPayload](&env); err == nil {
+			seq = p.Seq
+		}
+		factory := protocol.NewMessageFactoryWithEncoding(protocol.EncodingForCodec(c.codec.Name()))
+		if err := c.Write(factory.CreatePongMessage(seq, env.Mid)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Write 编码并发送一条消息
+func (c *ClientConn) Write(e *protocol.Envelope) error {
+	var buf bytes.Buffer
+	if err := c.codec.Encode(&buf, e); err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.ws != nil {
+		return c.ws.WriteMessage(wsMessageType(c.codec.Name()), buf.Bytes())
+	}
+	return c.fc.WriteFrame(c.conn, buf.Bytes())
+}
+
+// TLSState 返回 TLS 连接状态，非 TLS 连接返回 nil
+func (c *ClientConn) TLSState() *tls.ConnectionState {
+	var conn net.Conn = c.conn
+	if c.ws != nil {
+		conn = c.ws.UnderlyingConn()
+	}
+	if tc, ok := conn.(*tls.Conn); ok {
+		state := tc.ConnectionState()
+		return &state
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (c *ClientConn) Close() error {
+	if c.ws != nil {
+		return c.ws.Close()
+	}
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return errors.New("client connection is not open")
+}
@@ -1,38 +1,23 @@
 package transport
 
 import (
-	"errors"
-	"fmt"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
 )
 
-// tp errs
+// tp errs，错误码统一登记在 protocol 错误码目录中
 var (
-	ErrSessionClosed   = errors.New("session is closed")
-	ErrSessionNotFound = errors.New("session not found")
-	ErrInvalidFrame    = errors.New("invalid frame format")
-	ErrFrameTooLarge   = errors.New("frame size exceeds maximum allowed")
-	ErrConnectionLost  = errors.New("connection lost")
+	ErrSessionClosed   = protocol.NewError(protocol.CodeSessionClosed, "")
+	ErrSessionNotFound = protocol.NewError(protocol.CodeSessionNotFound, "")
+	ErrInvalidFrame    = protocol.NewError(protocol.CodeInvalidFrame, "")
+	ErrFrameTooLarge   = protocol.NewError(protocol.CodeFrameTooLarge, "")
+	ErrConnectionLost  = protocol.NewError(protocol.CodeConnectionLost, "")
+	ErrUnknownCodec    = protocol.NewError(protocol.CodeUnknownCodec, "")
 
-	ErrSessionContextClosed = newTpError(1001, "Session context is closed", "")
+	ErrSessionContextClosed = protocol.NewError(protocol.CodeSessionContextClosed, "")
 )
 
-type tpError struct {
-	code    int
-	msg     string
-	context string
-}
-
-func (e *tpError) Error() string {
-	if e.context != "" {
-		return fmt.Sprintf("Error %d: %s (context: %s)", e.code, e.msg, e.context)
-	}
-	return fmt.Sprintf("Error %d: %s", e.code, e.msg)
-}
-
-func newTpError(code int, message string, context string) *tpError {
-	return &tpError{
-		code:    code,
-		msg:     message,
-		context: context,
-	}
+// chatError 将 Hub 返回的业务错误（消息 ID 冲突、无权编辑等）映射为目录中的错误码，未登记的错误按内部错误返回
+func chatError(err error) *protocol.Error {
+	return protocol.AsError(command.MessageError(err), protocol.CodeInternal)
 }
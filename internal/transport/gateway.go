@@ -1,9 +1,11 @@
 package transport
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"sync"
 
+	"github.com/hongjun500/chat-go/internal/i18n"
 	"github.com/hongjun500/chat-go/internal/protocol"
 	"github.com/hongjun500/chat-go/pkg/logger"
 )
@@ -18,11 +20,17 @@ type Gateway interface {
 	OnSessionClose(sc *SessionContext)
 }
 
+// Drainer 支持优雅停机的网关：通知并关闭现有会话、拒绝新会话，ctx 到期时放弃等待
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
 // SimpleGateway 简单的网关实现，专注于消息转发和会话管理
 // 作为传输层与业务层的桥梁
 type SimpleGateway struct {
 	sessionManager *SessionManager
 	disp           *dispatcher
+	validator      *protocol.Validator
 
 	initOnce sync.Once
 }
@@ -32,9 +40,15 @@ func NewSimpleGateway() *SimpleGateway {
 	return &SimpleGateway{
 		sessionManager: NewSessionManager(),
 		disp:           newDispatcher(),
+		validator:      protocol.NewValidator(),
 	}
 }
 
+// SetValidator 替换入站消息校验器，nil 表示不校验
+func (g *SimpleGateway) SetValidator(v *protocol.Validator) {
+	g.validator = v
+}
+
 // OnSessionOpen 会话开启事件
 func (g *SimpleGateway) OnSessionOpen(sc *SessionContext) {
 	logger.L().Sugar().Infow("OnSessionOpen", "SessionId", sc.Id, "addr", sc.RemoteAddr)
@@ -46,21 +60,40 @@ func (g *SimpleGateway) OnSessionOpen(sc *SessionContext) {
 	g.initOnce.Do(func() {
 		// 处理 ping -> 返回 pong
 		g.disp.Register(string(protocol.MsgPing), func(ctx *SessionContext, msg *protocol.Envelope) {
-			// 试图解析 ping payload
-			var p protocol.PingPayload
-			_ = json.Unmarshal(msg.Data, &p) // 若解析失败，仍然返回 pong（seq 为 0）
+			// 试图解析 ping payload，若解析失败，仍然返回 pong（seq 为 0）
+			var seq int64
+			if p, err := protocol.DecodePayload[protocol.PingPayload](msg); err == nil {
+				seq = p.Seq
+			}
 			// 创建 pong（使用原 message id 作为 correlation）
-			pong := protocol.NewMessageFactory().CreatePongMessage(p.Seq, msg.Mid)
+			pong := ctx.Factory().CreatePongMessage(seq, msg.Mid)
 			if err := ctx.Send(pong); err != nil {
 				logger.L().Sugar().Warnw("send_pong_failed", "session", ctx.Id, "err", err)
 			}
 		})
 
+		// 处理 hello -> 协商协议版本，通告支持的编解码器与协议版本，并告知本会话选定的编解码器与版本
+		g.disp.Register(string(protocol.MsgHello), func(ctx *SessionContext, msg *protocol.Envelope) {
+			factory := ctx.Factory()
+			version, err := protocol.NegotiateHello(msg)
+			if err == nil {
+				err = ctx.SetVersion(version)
+			}
+			if err != nil {
+				sendError(ctx, protocol.AsError(err, protocol.CodeBadHello), msg.Mid)
+				return
+			}
+			hello := factory.CreateHelloMessage(ctx.Codec, version, msg.Mid)
+			if err := ctx.Send(hello); err != nil {
+				logger.L().Sugar().Warnw("send_hello_failed", "session", ctx.Id, "err", err)
+			}
+		})
+
 		// 可在此注册更多基础处理器（如 command、ack 等），保持简洁最小化实现
 	})
 
 	// 发送欢迎消息（非阻塞，记录错误）
-	welcome := protocol.NewMessageFactory().CreateTextMessage("Welcome to Chat-Go!")
+	welcome := sc.Factory().CreateTextMessage(i18n.T(i18n.Default(), "session.welcome"))
 	if err := sc.Send(welcome); err != nil {
 		logger.L().Sugar().Warnw("send_welcome_failed", "session", sc.Id, "err", err)
 	}
@@ -68,8 +101,31 @@ func (g *SimpleGateway) OnSessionOpen(sc *SessionContext) {
 
 // OnEnvelope 处理收到的消息
 func (g *SimpleGateway) OnEnvelope(sc *SessionContext, msg *protocol.Envelope) {
+	// 分发前校验信封与负载，不合法的消息回复错误并丢弃
+	if g.validator != nil {
+		if err := g.validator.Validate(msg); err != nil {
+			var verr *protocol.ValidationError
+			if !errors.As(err, &verr) {
+				verr = &protocol.ValidationError{Rule: protocol.RulePayload, Message: err.Error()}
+			}
+			logger.L().Sugar().Debugw("invalid_envelope", "session", sc.Id, "type", msg.Type, "rule", verr.Rule, "field", verr.Field)
+			if err := sc.Send(sc.Factory().CreateValidationErrorMessage(verr, msg.Mid)); err != nil {
+				logger.L().Sugar().Warnw("send_error_failed", "session", sc.Id, "err", err)
+			}
+			return
+		}
+	}
 	// todo 回退到 tp 层的全局处理器
-	g.disp.Dispatch(sc, msg)
+	if !g.disp.Dispatch(sc, msg) {
+		sendError(sc, protocol.Errorf(protocol.CodeUnknownType, "no handler for message type: %s", msg.Type), msg.Mid)
+	}
+}
+
+// sendError 向会话回复带错误码的错误消息，correlationID 指向出错的请求
+func sendError(sc *SessionContext, err *protocol.Error, correlationID string) {
+	if err := sc.Send(sc.Factory().CreateErrorMessage(err, correlationID)); err != nil {
+		logger.L().Sugar().Warnw("send_error_failed", "session", sc.Id, "err", err)
+	}
 }
 
 // OnSessionClose 会话关闭事件
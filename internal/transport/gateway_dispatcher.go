@@ -22,11 +22,12 @@ func (d *dispatcher) Register(msgType string, handler HandlerFunc) {
 	d.handlers[msgType] = handler
 }
 
-// Dispatch 分发消息
-func (d *dispatcher) Dispatch(ctx *SessionContext, msg *protocol.Envelope) {
+// Dispatch 分发消息，没有对应处理器时返回 false
+func (d *dispatcher) Dispatch(ctx *SessionContext, msg *protocol.Envelope) bool {
 	if handler, ok := d.handlers[string(msg.Type)]; ok {
 		handler(ctx, msg)
-	} else {
-		log.Printf("no handler for message type: %s", msg.Type)
+		return true
 	}
+	log.Printf("no handler for message type: %s", msg.Type)
+	return false
 }
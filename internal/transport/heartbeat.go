@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/observe"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// heartbeat 服务端发起的应用层心跳，TCP 与 WebSocket 会话共用：
+// 每隔 HeartbeatInterval 发送带递增序号的 ping，收到同序号的 pong 时记录往返时延；
+// 超过 HeartbeatTimeout 没有任何入站数据（消息、pong 或 WebSocket 控制帧）时关闭会话
+type heartbeat struct {
+	transport  string
+	seq        atomic.Int64
+	lastActive atomic.Int64 // 上次入站数据时间（UnixNano）
+	rtt        atomic.Int64 // 最近一次测得的往返时延（纳秒），0 表示尚未测得
+
+	mu      sync.Mutex
+	pending map[int64]time.Time // 已发送未应答的 ping 序号 -> 发送时间
+}
+
+func newHeartbeat(transport string) *heartbeat {
+	h := &heartbeat{transport: transport, pending: make(map[int64]time.Time)}
+	h.touch()
+	return h
+}
+
+// touch 记录一次入站活动
+func (h *heartbeat) touch() {
+	h.lastActive.Store(time.Now().UnixNano())
+}
+
+// idle 距上次入站活动的时长
+func (h *heartbeat) idle() time.Duration {
+	return time.Since(time.Unix(0, h.lastActive.Load()))
+}
+
+// Latency 最近一次测得的往返时延，尚未测得时为 0
+func (h *heartbeat) Latency() time.Duration {
+	return time.Duration(h.rtt.Load())
+}
+
+// nextPing 分配序号并登记发送时间；丢弃超过 expire 仍未应答的序号，避免客户端从不回复时无限增长
+func (h *heartbeat) nextPing(expire time.Duration) int64 {
+	seq := h.seq.Add(1)
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s, sent := range h.pending {
+		if now.Sub(sent) > expire {
+			delete(h.pending, s)
+		}
+	}
+	h.pending[seq] = now
+	return seq
+}
+
+// pong 处理客户端的 pong：序号对应本端发出的 ping 时更新往返时延并返回 true
+func (h *heartbeat) pong(e *protocol.Envelope) bool {
+	h.touch()
+	p, err := protocol.DecodePayload[protocol.PongPayload](e)
+	if err != nil {
+		return false
+	}
+	h.mu.Lock()
+	sent, ok := h.pending[p.Seq]
+	delete(h.pending, p.Seq)
+	h.mu.Unlock()
+	if !ok {
+		return false
+	}
+	rtt := time.Since(sent)
+	h.rtt.Store(int64(rtt))
+	observe.ObserveHeartbeatRTT(h.transport, rtt)
+	return true
+}
+
+// run 按配置发送 ping 并检测超时，直到 done 关闭或超时关闭会话。
+// HeartbeatInterval <= 0 时不发送 ping；HeartbeatTimeout <= 0 时不检测超时
+func (h *heartbeat) run(sc *SessionContext, opt Options, done <-chan struct{}) {
+	interval, timeout := opt.HeartbeatInterval, opt.HeartbeatTimeout
+	tick := interval
+	if tick <= 0 {
+		if timeout <= 0 {
+			return
+		}
+		// 只检测超时
+		tick = timeout / 2
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		if timeout > 0 && h.idle() > timeout {
+			logger.L().Sugar().Infow("heartbeat_timeout", "transport", h.transport, "session", sc.Id, "idle", h.idle())
+			observe.IncHeartbeatTimeout(h.transport)
+			_ = sc.Close()
+			return
+		}
+		if interval > 0 {
+			seq := h.nextPing(max(timeout, 2*interval))
+			if err := sc.Send(sc.Factory().CreatePingMessage(seq)); err != nil {
+				logger.L().Sugar().Debugw("send_ping_failed", "transport", h.transport, "session", sc.Id, "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// readEnvelope 从帧连接读取一条 JSON 消息
+func readEnvelope(t *testing.T, fc *FrameCodec, c net.Conn) *protocol.Envelope {
+	t.Helper()
+	frame, err := fc.ReadFrame(c)
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	var env protocol.Envelope
+	if err := (&protocol.JSONCodec{}).Decode(bytes.NewReader(frame), &env, 1<<20); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return &env
+}
+
+// TestTCPHeartbeat 服务端发送带序号的 ping，pong 之后 /who 附带往返时延；
+// 只读不应答的会话超时后被关闭
+func TestTCPHeartbeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	go func() {
+		_ = NewTCPServer(addr).Start(ctx, addr, NewChatGateway(chat.NewHub(), reg), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+			ReadTimeout:        2 * time.Second,
+			HeartbeatInterval:  50 * time.Millisecond,
+			HeartbeatTimeout:   300 * time.Millisecond,
+		})
+	}()
+
+	// ClientConn 自动应答 ping，会话保持存活并测得时延
+	dialTCP(t, addr).Close()
+	c, err := DialClient("tcp://"+addr, &protocol.JSONCodec{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Write(protocol.NewMessageFactory().CreateSetNickMessage("alice")); err != nil {
+		t.Fatal(err)
+	}
+	// 后台持续读取（期间自动应答 ping），定期发送 /who 直到结果带上时延
+	texts := make(chan string, 16)
+	go func() {
+		defer close(texts)
+		for {
+			env, err := c.Read(1 << 20)
+			if err != nil {
+				return
+			}
+			if p, err := protocol.DecodePayload[protocol.TextPayload](env); err == nil {
+				texts <- p.Text
+			}
+		}
+	}()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(2 * time.Second)
+	for found := false; !found; {
+		select {
+		case text, ok := <-texts:
+			if !ok {
+				t.Fatal("client connection closed")
+			}
+			found = strings.Contains(text, "alice(")
+		case <-ticker.C:
+			if err := c.Write(protocol.NewMessageFactory().CreateCommandMessage("/who")); err != nil {
+				t.Fatal(err)
+			}
+		case <-timeout:
+			t.Fatal("/who never reported latency")
+		}
+	}
+
+	// 原始连接：收到的 ping 序号递增，不应答时超时关闭
+	conn := dialTCP(t, addr)
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	fc := NewFrameCodec()
+	var last int64
+	pings := 0
+	start := time.Now()
+	for {
+		frame, err := fc.ReadFrame(conn)
+		if err != nil {
+			break
+		}
+		var env protocol.Envelope
+		if err := (&protocol.JSONCodec{}).Decode(bytes.NewReader(frame), &env, 1<<20); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if env.Type != protocol.MsgPing {
+			continue
+		}
+		p, err := protocol.DecodePayload[protocol.PingPayload](&env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Seq <= last {
+			t.Errorf("ping seq %d after %d", p.Seq, last)
+		}
+		last = p.Seq
+		pings++
+	}
+	if pings < 2 {
+		t.Errorf("got %d pings, want at least 2", pings)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("idle session closed after %v, want about 300ms", elapsed)
+	}
+}
+
+// TestTCPReadTimeout 读取超时按配置的时长生效
+func TestTCPReadTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := freeAddr(t)
+	go func() {
+		_ = NewTCPServer(addr).Start(ctx, addr, NewSimpleGateway(), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+			ReadTimeout:        200 * time.Millisecond,
+		})
+	}()
+	conn := dialTCP(t, addr)
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	fc := NewFrameCodec()
+	if env := readEnvelope(t, fc, conn); env.Type != protocol.MsgText {
+		t.Fatalf("first message = %s, want welcome text", env.Type)
+	}
+	start := time.Now()
+	if _, err := fc.ReadFrame(conn); err == nil {
+		t.Fatal("expected connection to be closed")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("closed after %v, want about 200ms", elapsed)
+	}
+}
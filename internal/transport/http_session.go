@@ -0,0 +1,339 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+const (
+	// HTTPSessionCookie 承载会话ID的 Cookie 名称（浏览器自动携带）
+	HTTPSessionCookie = "chat_sid"
+	// HTTPSessionHeader 承载会话ID的请求/响应头（非浏览器客户端使用）
+	HTTPSessionHeader = "X-Chat-Session"
+
+	defaultHTTPBacklog     = 256              // 默认保留的出站事件数，用于断线重连补发
+	defaultHTTPGrace       = 60 * time.Second // 默认无连接后会话的保留时长
+	defaultHTTPMaxPostSize = 1 << 20
+)
+
+// outEvent 已编码的出站消息，id 在会话内单调递增
+type outEvent struct {
+	id   int64
+	data []byte
+}
+
+// httpSession 基于 HTTP 请求的会话（SSE、长轮询）：出站消息缓存在有界队列中，
+// 由当前附着的读取请求按 id 取走；入站消息由 POST 请求送达
+type httpSession struct {
+	*Base
+	protocolManager *protocol.Manager
+	identity        string // mTLS 客户端证书身份
+
+	mu       sync.Mutex
+	backlog  []outEvent
+	limit    int
+	nextID   int64
+	notify   chan struct{} // 有新事件时关闭并替换，唤醒等待的读取请求
+	stream   int64         // 当前附着的读取请求代次，新请求替换旧请求
+	attached bool
+	lastSeen time.Time
+
+	closeChan chan struct{}
+}
+
+func newHTTPSession(id, remoteAddr string, protocolManager *protocol.Manager, limit int) *httpSession {
+	return &httpSession{
+		Base:            NewBase(id, remoteAddr),
+		protocolManager: protocolManager,
+		limit:           limit,
+		notify:          make(chan struct{}),
+		lastSeen:        time.Now(),
+		closeChan:       make(chan struct{}),
+	}
+}
+
+// SendEnvelope 编码消息并加入出站队列；队列满时丢弃最旧的事件
+func (s *httpSession) SendEnvelope(e *protocol.Envelope) error {
+	if s.State() == SessionStateClosed {
+		return ErrSessionClosed
+	}
+	var buf bytes.Buffer
+	if err := s.protocolManager.EncodeMessage(&buf, e); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.nextID++
+	s.backlog = append(s.backlog, outEvent{id: s.nextID, data: bytes.TrimSpace(buf.Bytes())})
+	if len(s.backlog) > s.limit {
+		s.backlog = s.backlog[len(s.backlog)-s.limit:]
+	}
+	s.wake()
+	s.mu.Unlock()
+	return nil
+}
+
+// wake 唤醒等待中的读取请求，调用方持有 mu
+func (s *httpSession) wake() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Identity 客户端证书身份，非 mTLS 连接为空
+func (s *httpSession) Identity() string {
+	return s.identity
+}
+
+// CodecName 本会话使用的编解码器名称
+func (s *httpSession) CodecName() string {
+	return s.protocolManager.GetCodec().Name()
+}
+
+// Close 关闭会话，结束附着的读取请求
+func (s *httpSession) Close() error {
+	s.closeOnce.Do(func() {
+		s.stateMu.Lock()
+		s.state = SessionStateClosed
+		s.stateMu.Unlock()
+		close(s.closeChan)
+	})
+	return nil
+}
+
+// attach 附着新的读取请求，返回其代次；旧请求在下次唤醒时退出
+func (s *httpSession) attach() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stream++
+	s.attached = true
+	s.wake()
+	return s.stream
+}
+
+// detach 读取请求结束，开始计算空闲时长
+func (s *httpSession) detach(gen int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == gen {
+		s.attached = false
+		s.lastSeen = time.Now()
+	}
+}
+
+// touch 记录入站活动
+func (s *httpSession) touch() {
+	s.mu.Lock()
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+}
+
+// expired 未附着读取请求且空闲超过 grace
+func (s *httpSession) expired(grace time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.attached && time.Since(s.lastSeen) > grace
+}
+
+// ack 丢弃客户端已确认收到的事件（id <= cursor）
+func (s *httpSession) ack(cursor int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := 0
+	for i < len(s.backlog) && s.backlog[i].id <= cursor {
+		i++
+	}
+	s.backlog = s.backlog[i:]
+}
+
+// pending 返回 id 大于 after 的事件（最多 max 条，<=0 不限）；gen 不再是当前代次时 stale 为 true
+func (s *httpSession) pending(gen, after int64, max int) (events []outEvent, wait <-chan struct{}, stale bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream != gen {
+		return nil, nil, true
+	}
+	for _, ev := range s.backlog {
+		if max > 0 && len(events) >= max {
+			break
+		}
+		if ev.id > after {
+			events = append(events, ev)
+		}
+	}
+	return events, s.notify, false
+}
+
+// httpConn 会话及其上下文
+type httpConn struct {
+	session *httpSession
+	sc      *SessionContext
+	release func() // 归还准入名额
+}
+
+// httpSessions HTTP 传输的会话表：会话ID通过 Cookie 或请求头携带，
+// 负责创建、查找、接收 POST 入站消息以及回收空闲会话
+type httpSessions struct {
+	name            string // 传输名称，用于日志
+	protocolManager *protocol.Manager
+	sessions        sync.Map // 会话ID -> *httpConn
+}
+
+// newHTTPSessions 创建会话表；HTTP 传输是文本协议，固定使用 JSON 编解码器
+func newHTTPSessions(name string) *httpSessions {
+	return &httpSessions{name: name, protocolManager: protocol.NewProtocolManager(protocol.CodecJson)}
+}
+
+// sessionID 从请求头或 Cookie 读取会话ID，请求头优先
+func sessionID(r *http.Request) string {
+	if id := r.Header.Get(HTTPSessionHeader); id != "" {
+		return id
+	}
+	if c, err := r.Cookie(HTTPSessionCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+func (hs *httpSessions) lookup(r *http.Request) (*httpConn, bool) {
+	id := sessionID(r)
+	if id == "" {
+		return nil, false
+	}
+	v, ok := hs.sessions.Load(id)
+	if !ok {
+		return nil, false
+	}
+	c := v.(*httpConn)
+	if c.session.State() == SessionStateClosed {
+		return nil, false
+	}
+	return c, true
+}
+
+// open 创建新会话并通知网关；准入被拒绝时写入 503/429 响应（响应体为 JSON error 消息）并返回 false
+func (hs *httpSessions) open(w http.ResponseWriter, r *http.Request, gateway Gateway, opt Options) (*httpConn, bool) {
+	release, rejected := opt.Admission.Admit(hs.name, hostOnly(r.RemoteAddr))
+	if rejected != nil {
+		rejectHTTP(w, hs.protocolManager, rejected)
+		return nil, false
+	}
+	limit := opt.OutBuffer
+	if limit <= 0 {
+		limit = defaultHTTPBacklog
+	}
+	session := newHTTPSession(uuid.New().String(), r.RemoteAddr, hs.protocolManager, limit)
+	session.identity = peerIdentity(r.TLS)
+	c := &httpConn{session: session, sc: NewSessionContext(session), release: release}
+	hs.sessions.Store(session.ID(), c)
+	gateway.OnSessionOpen(c.sc)
+	return c, true
+}
+
+// bind 通过响应头与 Cookie 告知客户端会话ID
+func (hs *httpSessions) bind(w http.ResponseWriter, r *http.Request, c *httpConn, path string) {
+	id := c.session.ID()
+	w.Header().Set(HTTPSessionHeader, id)
+	http.SetCookie(w, &http.Cookie{
+		Name:     HTTPSessionCookie,
+		Value:    id,
+		Path:     path,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// closeConn 关闭会话并通知网关，重复调用无副作用
+func (hs *httpSessions) closeConn(c *httpConn, gateway Gateway) {
+	if _, loaded := hs.sessions.LoadAndDelete(c.session.ID()); loaded {
+		_ = c.session.Close()
+		gateway.OnSessionClose(c.sc)
+		c.release()
+	}
+}
+
+// reap 关闭已关闭或空闲超过 grace 的会话；ctx 结束时关闭全部会话
+func (hs *httpSessions) reap(ctx context.Context, gateway Gateway, grace time.Duration) {
+	ticker := time.NewTicker(grace / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			hs.sessions.Range(func(_, v any) bool {
+				hs.closeConn(v.(*httpConn), gateway)
+				return true
+			})
+			return
+		case <-ticker.C:
+			hs.sessions.Range(func(_, v any) bool {
+				c := v.(*httpConn)
+				if c.session.State() == SessionStateClosed || c.session.expired(grace) {
+					logger.L().Sugar().Infow(hs.name+"_session_expired", "session", c.session.ID())
+					hs.closeConn(c, gateway)
+				}
+				return true
+			})
+		}
+	}
+}
+
+// handlePost 接收一条入站消息：JSON 消息，或纯文本/命令行
+func (hs *httpSessions) handlePost(w http.ResponseWriter, r *http.Request, gateway Gateway, opt Options) {
+	c, ok := hs.lookup(r)
+	if !ok {
+		http.Error(w, ErrSessionNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(opt.MaxFrameSize)))
+	if err != nil {
+		http.Error(w, ErrFrameTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	c.session.touch()
+
+	var envelope protocol.Envelope
+	if err := c.session.protocolManager.DecodeMessage(bytes.NewReader(data), &envelope, opt.MaxFrameSize); err == nil && envelope.Type != "" {
+		if envelope.Type != protocol.MsgHeartbeat {
+			deliver(gateway, c.sc, &envelope)
+		}
+	} else if text := string(bytes.TrimSpace(data)); text != "" {
+		// 回退处理纯文本消息，与 WebSocket 一致
+		factory := c.session.protocolManager.GetMessageFactory()
+		if text[0] == '/' {
+			gateway.OnEnvelope(c.sc, factory.CreateCommandMessage(text))
+		} else {
+			gateway.OnEnvelope(c.sc, factory.CreateTextMessage(text))
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// serveHTTP 启动承载 handler 的 HTTP 服务器，ctx 结束时优雅关闭。
+// 读取请求是长连接，不设置 WriteTimeout
+func serveHTTP(ctx context.Context, addr string, handler http.Handler, tlsCfg *tls.Config) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsCfg,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if tlsCfg != nil {
+		// 证书由 TLSConfig 提供（支持热加载），无需文件参数
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
@@ -0,0 +1,772 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// ircMaxInbound 入站单行上限：512 字节消息 + 8191 字节 IRCv3 标签
+const ircMaxInbound = 512 + 8191
+
+// IRC 数字回复（RFC 1459/2812）
+const (
+	rplWelcome         = "001"
+	rplYourHost        = "002"
+	rplCreated         = "003"
+	rplMyInfo          = "004"
+	rplUModeIs         = "221"
+	rplEndOfWho        = "315"
+	rplChannelModeIs   = "324"
+	rplNoTopic         = "331"
+	rplWhoReply        = "352"
+	rplNamReply        = "353"
+	rplEndOfNames      = "366"
+	errNoSuchNick      = "401"
+	errNoSuchChannel   = "403"
+	errCannotSendToCh  = "404"
+	errNoRecipient     = "411"
+	errNoTextToSend    = "412"
+	errUnknownCommand  = "421"
+	errNoMOTD          = "422"
+	errNoNicknameGiven = "431"
+	errErroneusNick    = "432"
+	errNicknameInUse   = "433"
+	errNotOnChannel    = "442"
+	errNotRegistered   = "451"
+	errNeedMoreParams  = "461"
+	errAlreadyRegistrd = "462"
+	errYoureBanned     = "465"
+	errChanOPrivsNeed  = "482"
+)
+
+// onlineLister 可选接口：网关提供在线用户列表（用于 NAMES/WHO）
+type onlineLister interface {
+	Online() []string
+}
+
+// IRCServer IRC 协议网关（RFC 1459/2812 子集），作为传输层接入同一个网关与 Hub：
+// IRC 命令转为聊天消息（NICK→nick，PRIVMSG #频道→text，PRIVMSG 昵称→direct，KICK→/kick），
+// 聊天消息、私信与上下线以 PRIVMSG/JOIN/QUIT 呈现，其余输出以服务器 NOTICE 呈现。
+// 聊天室对应唯一的频道 Channel，注册完成后自动加入。
+type IRCServer struct {
+	ServerName string // 服务器名，用作回复前缀，defaults to "chat-go"
+	Channel    string // 聊天室对应的频道，defaults to "#chat"
+
+	created time.Time
+}
+
+// NewIRCServer 创建 IRC 服务器
+func NewIRCServer(serverName, channel string) *IRCServer {
+	if serverName == "" {
+		serverName = "chat-go"
+	}
+	if channel == "" {
+		channel = "#chat"
+	}
+	return &IRCServer{ServerName: serverName, Channel: channel, created: time.Now()}
+}
+
+// Name 获取传输类型名称
+func (s *IRCServer) Name() string {
+	return IRC
+}
+
+// Start 启动 IRC 服务器
+func (s *IRCServer) Start(ctx context.Context, addr string, gateway Gateway, opt Options) error {
+	ln, err := net.Listen(Tcp, addr)
+	if err != nil {
+		return err
+	}
+	if opt.TLS != nil {
+		ln = tls.NewListener(ln, opt.TLS)
+	}
+	logger.L().Sugar().Infow("irc_listen", "addr", addr, "channel", s.Channel, "tls", opt.TLS != nil)
+
+	// 优雅关闭
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	lister, _ := gateway.(onlineLister)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.L().Sugar().Warnw("irc_accept_error", "err", err)
+			continue
+		}
+		go s.handleConnection(ctx, conn, gateway, lister, opt)
+	}
+}
+
+// handleConnection 处理新连接
+func (s *IRCServer) handleConnection(ctx context.Context, conn net.Conn, gateway Gateway, lister onlineLister, opt Options) {
+	session := &ircSession{
+		Base:      NewBase(uuid.New().String(), conn.RemoteAddr().String()),
+		conn:      conn,
+		server:    s,
+		lister:    lister,
+		opt:       opt,
+		pending:   make(map[string]ircPending),
+		closeChan: make(chan struct{}),
+	}
+	if tc, ok := conn.(*tls.Conn); ok {
+		_ = tc.SetDeadline(time.Now().Add(negotiateTimeout))
+		if err := tc.Handshake(); err != nil {
+			logger.L().Sugar().Warnw("tls_handshake_error", "session", session.ID(), "addr", session.RemoteAddr(), "err", err)
+			_ = conn.Close()
+			return
+		}
+		_ = tc.SetDeadline(time.Time{})
+		state := tc.ConnectionState()
+		session.identity = peerIdentity(&state)
+	}
+	release, rejected := opt.Admission.Admit(s.Name(), remoteIP(conn.RemoteAddr()))
+	if rejected != nil {
+		rejectLine(conn, "ERROR :Closing Link: "+session.RemoteAddr()+" ("+rejected.Message+")")
+		return
+	}
+	go func() {
+		<-session.closeChan
+		release()
+	}()
+	sc := NewSessionContext(session)
+	session.sc = sc
+	session.gateway = gateway
+	gateway.OnSessionOpen(sc)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = session.Close()
+		case <-session.closeChan:
+		}
+	}()
+	go session.pingLoop()
+	session.readLoop()
+}
+
+// maxIRCPending 同时等待网关回复的请求上限，超出时不再记录，回复以 NOTICE 呈现
+const maxIRCPending = 64
+
+// ircPending 等待网关回复（ack/error）的请求
+type ircPending struct {
+	command string // NICK / KICK / PRIVMSG
+	target  string
+}
+
+// ircSession IRC 会话：入站行转为聊天消息交给网关，出站消息与 Hub 事件渲染为 IRC 行
+type ircSession struct {
+	*Base
+	conn     net.Conn
+	server   *IRCServer
+	lister   onlineLister
+	opt      Options
+	identity string // mTLS 客户端证书身份
+	sc       *SessionContext
+	gateway  Gateway
+	writeMu  sync.Mutex
+
+	mu         sync.Mutex
+	nick       string // 注册完成后为聊天昵称
+	user       string
+	realname   string
+	registered bool
+	joined     bool
+	pending    map[string]ircPending // 消息ID -> 请求
+
+	closeChan chan struct{}
+}
+
+// Identity 客户端证书身份，非 mTLS 连接为空
+func (s *ircSession) Identity() string {
+	return s.identity
+}
+
+// CodecName 本会话的线路协议
+func (s *ircSession) CodecName() string {
+	return IRC
+}
+
+// TextOnly IRC 只呈现 ObserveEvent 渲染的行与文本通知
+func (s *ircSession) TextOnly() bool {
+	return true
+}
+
+// Close 关闭会话
+func (s *ircSession) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.stateMu.Lock()
+		s.state = SessionStateClosed
+		s.stateMu.Unlock()
+		err = s.conn.Close()
+		close(s.closeChan)
+	})
+	return err
+}
+
+// snapshot 当前昵称、注册与入频道状态
+func (s *ircSession) snapshot() (nick string, registered, joined bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nick, s.registered, s.joined
+}
+
+// prefix 用户前缀 nick!user@server
+func (s *ircSession) prefix(nick string) string {
+	n := ircNick(nick)
+	return n + "!" + n + "@" + s.server.ServerName
+}
+
+// write 写出若干行
+func (s *ircSession) write(msgs ...*ircMessage) {
+	if s.State() == SessionStateClosed {
+		return
+	}
+	var b strings.Builder
+	for _, m := range msgs {
+		b.WriteString(m.String())
+		b.WriteString("\r\n")
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.opt.WriteTimeout > 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.opt.WriteTimeout))
+	}
+	if _, err := s.conn.Write([]byte(b.String())); err != nil {
+		logger.L().Sugar().Debugw("irc_write_error", "session", s.ID(), "err", err)
+	}
+}
+
+// reply 服务器发出的命令或数字回复，第一个参数为当前昵称（未注册时为 *）
+func (s *ircSession) reply(command string, params ...string) {
+	nick, _, _ := s.snapshot()
+	s.write(&ircMessage{Prefix: s.server.ServerName, Command: command, Params: append([]string{ircNick(nick)}, params...)})
+}
+
+// relay 以 from 的身份向 target 发送文本，超长或多行时拆为多条
+func (s *ircSession) relay(prefix, command, target, text string) {
+	// 预留前缀、命令、目标与分隔符的长度
+	room := ircMaxLine - 2 - len(prefix) - len(command) - len(target) - 5
+	var msgs []*ircMessage
+	for _, line := range strings.Split(text, "\n") {
+		for _, part := range splitIRCText(strings.TrimRight(line, "\r"), room) {
+			msgs = append(msgs, &ircMessage{Prefix: prefix, Command: command, Params: []string{target, part}, trailing: true})
+		}
+	}
+	s.write(msgs...)
+}
+
+// splitIRCText 按字节上限在字符边界处拆分文本
+func splitIRCText(text string, max int) []string {
+	if max <= 0 || len(text) <= max {
+		return []string{text}
+	}
+	var out []string
+	for len(text) > max {
+		cut := max
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		out = append(out, text[:cut])
+		text = text[cut:]
+	}
+	return append(out, text)
+}
+
+// notice 以服务器 NOTICE 呈现文本
+func (s *ircSession) notice(text string) {
+	nick, _, _ := s.snapshot()
+	s.relay(s.server.ServerName, "NOTICE", ircNick(nick), text)
+}
+
+// SendEnvelope 将网关发出的消息渲染为 IRC 行
+func (s *ircSession) SendEnvelope(e *protocol.Envelope) error {
+	if s.State() == SessionStateClosed {
+		return ErrSessionClosed
+	}
+	switch e.Type {
+	case protocol.MsgText:
+		p, err := protocol.DecodePayload[protocol.TextPayload](e)
+		if err != nil {
+			return err
+		}
+		s.notice(p.Text)
+	case protocol.MsgAck:
+		if req, ok := s.takePending(e.Correlation); ok && req.command == "NICK" {
+			s.nickAccepted(req.target)
+		}
+	case protocol.MsgError:
+		p, err := protocol.DecodePayload[protocol.ErrorPayload](e)
+		if err != nil {
+			return err
+		}
+		req, _ := s.takePending(e.Correlation)
+		s.renderError(req, p)
+	}
+	return nil
+}
+
+func (s *ircSession) takePending(mid string) (ircPending, bool) {
+	if mid == "" {
+		return ircPending{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req, ok := s.pending[mid]
+	delete(s.pending, mid)
+	return req, ok
+}
+
+// renderError 将网关错误转为对应的数字回复，无对应回复时以 NOTICE 呈现
+func (s *ircSession) renderError(req ircPending, p *protocol.ErrorPayload) {
+	switch {
+	case req.command == "NICK" && p.Code == protocol.CodeBanned:
+		s.reply(errYoureBanned, p.Message)
+		s.write(&ircMessage{Command: "ERROR", Params: []string{"Closing Link: " + p.Message}})
+		_ = s.Close()
+	case req.command == "NICK" && (p.Code == protocol.CodeNameInUse || p.Code == protocol.CodeNameReserved):
+		s.reply(errNicknameInUse, req.target, "Nickname is already in use")
+	case req.command == "NICK":
+		s.reply(errErroneusNick, req.target, p.Message)
+	case req.command == "KICK" && (p.Code == protocol.CodePermissionDenied || p.Code == protocol.CodeCommandNotFound):
+		s.reply(errChanOPrivsNeed, s.server.Channel, "You're not channel operator")
+	case req.command == "PRIVMSG" && p.Code == protocol.CodeNotLoggedIn:
+		s.reply(errNotRegistered, "You have not registered")
+	default:
+		s.notice(p.Message)
+	}
+}
+
+// ObserveEvent 将 Hub 中的聊天消息、私信与上下线渲染为 IRC 行
+func (s *ircSession) ObserveEvent(e chat.Event) bool {
+	nick, registered, joined := s.snapshot()
+	if !registered {
+		return true
+	}
+	switch ev := e.(type) {
+	case *chat.MessageEvent:
+		// IRC 不回显自己发出的消息
+		if joined && ev.From != nick {
+			s.relay(s.prefix(ev.From), "PRIVMSG", s.server.Channel, ev.Content)
+		}
+		return true
+	case *chat.DirectMessageEvent:
+		s.relay(s.prefix(ev.From), "PRIVMSG", ircNick(nick), ev.Content)
+		return true
+	case *chat.UserEvent:
+		if !joined || ev.User.Name() == nick {
+			return true
+		}
+		if ev.Type() == chat.EventUserJoined {
+			s.write(&ircMessage{Prefix: s.prefix(ev.User.Name()), Command: "JOIN", Params: []string{s.server.Channel}})
+		} else {
+			s.write(&ircMessage{Prefix: s.prefix(ev.User.Name()), Command: "QUIT", Params: []string{"Leaving"}})
+		}
+		return true
+	}
+	return false
+}
+
+// readLoop 逐行读取并处理
+func (s *ircSession) readLoop() {
+	defer func() {
+		s.gateway.OnSessionClose(s.sc)
+		_ = s.Close()
+	}()
+	sc := bufio.NewScanner(s.conn)
+	sc.Buffer(make([]byte, 4096), ircMaxInbound)
+	for {
+		if s.opt.ReadTimeout > 0 {
+			_ = s.conn.SetReadDeadline(time.Now().Add(s.opt.ReadTimeout))
+		}
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil && s.State() == SessionStateActive {
+				logger.L().Sugar().Debugw("irc_read_error", "session", s.ID(), "err", err)
+			}
+			return
+		}
+		m, err := parseIRCMessage(sc.Text())
+		if err != nil {
+			continue
+		}
+		s.handle(m)
+		if s.State() == SessionStateClosed {
+			return
+		}
+	}
+}
+
+// pingLoop 按心跳间隔向客户端发送 PING，客户端的 PONG 会刷新读取超时
+func (s *ircSession) pingLoop() {
+	if s.opt.HeartbeatInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.opt.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.write(&ircMessage{Command: "PING", Params: []string{s.server.ServerName}})
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+// send 将聊天消息交给网关；command 非空时记录请求以渲染 ack/error。
+// 网关在 OnEnvelope 中同步回复，成功的 PRIVMSG 与 KICK 没有 ack，返回后即移除记录
+func (s *ircSession) send(e *protocol.Envelope, command, target string) {
+	tracked := false
+	if command != "" && e.Mid != "" {
+		s.mu.Lock()
+		if len(s.pending) < maxIRCPending {
+			s.pending[e.Mid] = ircPending{command: command, target: target}
+			tracked = true
+		}
+		s.mu.Unlock()
+	}
+	deliver(s.gateway, s.sc, e)
+	if tracked {
+		s.takePending(e.Mid)
+	}
+}
+
+// handle 处理一条 IRC 命令
+func (s *ircSession) handle(m *ircMessage) {
+	_, registered, _ := s.snapshot()
+	switch m.Command {
+	case "NICK":
+		s.handleNick(m)
+	case "USER":
+		s.handleUser(m)
+	case "PASS":
+	case "CAP":
+		if strings.ToUpper(m.param(0)) == "LS" {
+			s.write(&ircMessage{Prefix: s.server.ServerName, Command: "CAP", Params: []string{"*", "LS", ""}})
+		}
+	case "PING":
+		s.write(&ircMessage{Prefix: s.server.ServerName, Command: "PONG", Params: []string{s.server.ServerName, m.param(0)}})
+	case "PONG":
+	case "QUIT":
+		reason := m.param(0)
+		if reason == "" {
+			reason = "Client Quit"
+		}
+		s.write(&ircMessage{Command: "ERROR", Params: []string{"Closing Link: " + s.RemoteAddr() + " (" + reason + ")"}})
+		_ = s.Close()
+	default:
+		if !registered {
+			s.reply(errNotRegistered, "You have not registered")
+			return
+		}
+		s.handleRegistered(m)
+	}
+}
+
+// handleRegistered 处理注册完成后才可用的命令
+func (s *ircSession) handleRegistered(m *ircMessage) {
+	switch m.Command {
+	case "JOIN":
+		s.handleJoin(m)
+	case "PART":
+		s.handlePart(m)
+	case "PRIVMSG", "NOTICE":
+		s.handlePrivmsg(m)
+	case "WHO":
+		s.handleWho(m)
+	case "NAMES":
+		s.sendNames()
+	case "KICK":
+		s.handleKick(m)
+	case "MODE":
+		if target := m.param(0); isIRCChannel(target) {
+			s.reply(rplChannelModeIs, target, "+")
+		} else {
+			s.reply(rplUModeIs, "+")
+		}
+	default:
+		s.reply(errUnknownCommand, m.Command, "Unknown command")
+	}
+}
+
+func (s *ircSession) handleNick(m *ircMessage) {
+	nick := m.param(0)
+	if nick == "" {
+		s.reply(errNoNicknameGiven, "No nickname given")
+		return
+	}
+	if !validIRCNick(nick) {
+		s.reply(errErroneusNick, nick, "Erroneous nickname")
+		return
+	}
+	s.mu.Lock()
+	registered := s.registered
+	if !registered {
+		s.nick = nick
+	}
+	s.mu.Unlock()
+	if registered {
+		s.send(s.sc.Factory().CreateSetNickMessage(nick), "NICK", nick)
+		return
+	}
+	s.tryRegister()
+}
+
+func (s *ircSession) handleUser(m *ircMessage) {
+	if len(m.Params) < 4 {
+		s.reply(errNeedMoreParams, "USER", "Not enough parameters")
+		return
+	}
+	s.mu.Lock()
+	registered := s.registered
+	if !registered {
+		s.user, s.realname = m.Params[0], m.Params[3]
+	}
+	s.mu.Unlock()
+	if registered {
+		s.reply(errAlreadyRegistrd, "You may not reregister")
+		return
+	}
+	s.tryRegister()
+}
+
+// tryRegister NICK 与 USER 都收到后以该昵称登录聊天；已认证身份（mTLS）的会话使用证书身份
+func (s *ircSession) tryRegister() {
+	s.mu.Lock()
+	nick, ready := s.nick, s.nick != "" && s.user != ""
+	s.mu.Unlock()
+	if !ready {
+		return
+	}
+	if s.identity != "" {
+		nick = s.identity
+	}
+	s.send(s.sc.Factory().CreateSetNickMessage(nick), "NICK", nick)
+}
+
+// nickAccepted 网关接受昵称：首次为注册完成，之后为改名
+func (s *ircSession) nickAccepted(nick string) {
+	if s.identity != "" {
+		nick = s.identity
+	}
+	s.mu.Lock()
+	old, registered := s.nick, s.registered
+	s.nick, s.registered = nick, true
+	s.mu.Unlock()
+	if registered {
+		if old != nick {
+			s.write(&ircMessage{Prefix: s.prefix(old), Command: "NICK", Params: []string{ircNick(nick)}})
+		}
+		return
+	}
+	name := s.server.ServerName
+	s.reply(rplWelcome, "Welcome to the "+name+" IRC gateway "+s.prefix(nick))
+	s.reply(rplYourHost, "Your host is "+name)
+	s.reply(rplCreated, "This server was created "+s.server.created.Format(time.RFC1123))
+	s.reply(rplMyInfo, name, "chat-go", "i", "o")
+	s.reply(errNoMOTD, "MOTD File is missing")
+	s.join()
+}
+
+// join 加入聊天室频道并发送成员列表
+func (s *ircSession) join() {
+	s.mu.Lock()
+	already := s.joined
+	s.joined = true
+	nick := s.nick
+	s.mu.Unlock()
+	if already {
+		return
+	}
+	s.write(&ircMessage{Prefix: s.prefix(nick), Command: "JOIN", Params: []string{s.server.Channel}})
+	s.reply(rplNoTopic, s.server.Channel, "No topic is set")
+	s.sendNames()
+}
+
+func (s *ircSession) sendNames() {
+	names := make([]string, 0)
+	for _, n := range s.online() {
+		names = append(names, ircNick(n))
+	}
+	s.reply(rplNamReply, "=", s.server.Channel, strings.Join(names, " "))
+	s.reply(rplEndOfNames, s.server.Channel, "End of /NAMES list")
+}
+
+// online 在线昵称（网关不提供列表时仅含自己）
+func (s *ircSession) online() []string {
+	if s.lister != nil {
+		return s.lister.Online()
+	}
+	nick, _, _ := s.snapshot()
+	return []string{nick}
+}
+
+// sameChannel 是否为聊天室频道（大小写不敏感）
+func (s *ircSession) sameChannel(name string) bool {
+	return strings.EqualFold(name, s.server.Channel)
+}
+
+func (s *ircSession) handleJoin(m *ircMessage) {
+	if m.param(0) == "" {
+		s.reply(errNeedMoreParams, "JOIN", "Not enough parameters")
+		return
+	}
+	if m.param(0) == "0" {
+		s.part("Left all channels")
+		return
+	}
+	for _, ch := range strings.Split(m.param(0), ",") {
+		if s.sameChannel(ch) {
+			s.join()
+		} else {
+			s.reply(errNoSuchChannel, ch, "No such channel")
+		}
+	}
+}
+
+func (s *ircSession) handlePart(m *ircMessage) {
+	if m.param(0) == "" {
+		s.reply(errNeedMoreParams, "PART", "Not enough parameters")
+		return
+	}
+	for _, ch := range strings.Split(m.param(0), ",") {
+		_, _, joined := s.snapshot()
+		switch {
+		case !s.sameChannel(ch):
+			s.reply(errNoSuchChannel, ch, "No such channel")
+		case !joined:
+			s.reply(errNotOnChannel, ch, "You're not on that channel")
+		default:
+			s.part(m.param(1))
+		}
+	}
+}
+
+// part 离开聊天室频道；会话仍在线，可收私信
+func (s *ircSession) part(reason string) {
+	s.mu.Lock()
+	joined := s.joined
+	s.joined = false
+	nick := s.nick
+	s.mu.Unlock()
+	if joined {
+		s.write(&ircMessage{Prefix: s.prefix(nick), Command: "PART", Params: []string{s.server.Channel, reason}})
+	}
+}
+
+// handlePrivmsg 频道消息转为 text，发给昵称的消息转为 direct；NOTICE 不产生错误回复
+func (s *ircSession) handlePrivmsg(m *ircMessage) {
+	quiet := m.Command == "NOTICE"
+	if m.param(0) == "" {
+		if !quiet {
+			s.reply(errNoRecipient, "No recipient given ("+m.Command+")")
+		}
+		return
+	}
+	text := m.param(1)
+	if text == "" {
+		if !quiet {
+			s.reply(errNoTextToSend, "No text to send")
+		}
+		return
+	}
+	nick, _, joined := s.snapshot()
+	tracked := "PRIVMSG"
+	if quiet {
+		tracked = ""
+	}
+	factory := s.sc.Factory()
+	for _, target := range strings.Split(m.param(0), ",") {
+		switch {
+		case s.sameChannel(target) && joined:
+			s.send(factory.CreateTextMessage(text), tracked, target)
+		case s.sameChannel(target):
+			if !quiet {
+				s.reply(errCannotSendToCh, target, "Cannot send to channel")
+			}
+		case isIRCChannel(target):
+			if !quiet {
+				s.reply(errNoSuchChannel, target, "No such channel")
+			}
+		case !s.isOnline(target):
+			if !quiet {
+				s.reply(errNoSuchNick, target, "No such nick/channel")
+			}
+		default:
+			s.send(factory.CreateDirectMessage(nick, []string{s.chatName(target)}, text), tracked, target)
+		}
+	}
+}
+
+// chatName 将 IRC 昵称映射回聊天昵称（昵称含 IRC 保留字符时两者不同）
+func (s *ircSession) chatName(target string) string {
+	for _, n := range s.online() {
+		if strings.EqualFold(ircNick(n), target) {
+			return n
+		}
+	}
+	return target
+}
+
+func (s *ircSession) isOnline(target string) bool {
+	if s.lister == nil {
+		return true
+	}
+	for _, n := range s.lister.Online() {
+		if strings.EqualFold(ircNick(n), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleWho 列出频道成员或指定昵称
+func (s *ircSession) handleWho(m *ircMessage) {
+	mask := m.param(0)
+	if mask == "" {
+		mask = "*"
+	}
+	name := s.server.ServerName
+	for _, n := range s.online() {
+		n = ircNick(n)
+		if mask != "*" && !s.sameChannel(mask) && !strings.EqualFold(mask, n) {
+			continue
+		}
+		s.reply(rplWhoReply, s.server.Channel, n, name, name, n, "H", "0 "+n)
+	}
+	s.reply(rplEndOfWho, mask, "End of WHO list")
+}
+
+// handleKick KICK 频道 昵称 转为 /kick 命令，权限由命令注册表校验
+func (s *ircSession) handleKick(m *ircMessage) {
+	if len(m.Params) < 2 {
+		s.reply(errNeedMoreParams, "KICK", "Not enough parameters")
+		return
+	}
+	if !s.sameChannel(m.Params[0]) {
+		s.reply(errNoSuchChannel, m.Params[0], "No such channel")
+		return
+	}
+	target := s.chatName(m.Params[1])
+	if !s.isOnline(m.Params[1]) {
+		s.reply(errNoSuchNick, m.Params[1], "No such nick/channel")
+		return
+	}
+	s.send(s.sc.Factory().CreateCommandMessage("/kick "+target), "KICK", target)
+}
@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"errors"
+	"strings"
+)
+
+// ircMaxLine RFC 1459 单行上限（含 CRLF）；入站按更宽松的上限读取以兼容 IRCv3 标签
+const ircMaxLine = 512
+
+// errEmptyIRCLine 空行或只有前缀的行
+var errEmptyIRCLine = errors.New("irc: empty line")
+
+// ircMessage 一行 IRC 消息：[:prefix] command params... [:trailing]
+type ircMessage struct {
+	Prefix  string
+	Command string
+	Params  []string
+
+	trailing bool // 最后一个参数总以 trailing（':' 前缀）形式输出，用于消息正文
+}
+
+// parseIRCMessage 解析一行 IRC 消息（不含 CRLF），命令统一转为大写；IRCv3 标签被忽略
+func parseIRCMessage(line string) (*ircMessage, error) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "@") {
+		_, line, _ = strings.Cut(line, " ")
+	}
+	line = strings.TrimLeft(line, " ")
+	m := &ircMessage{}
+	if strings.HasPrefix(line, ":") {
+		m.Prefix, line, _ = strings.Cut(line[1:], " ")
+		line = strings.TrimLeft(line, " ")
+	}
+	for line != "" {
+		if line[0] == ':' {
+			m.Params = append(m.Params, line[1:])
+			break
+		}
+		var param string
+		param, line, _ = strings.Cut(line, " ")
+		line = strings.TrimLeft(line, " ")
+		if m.Command == "" {
+			m.Command = strings.ToUpper(param)
+		} else {
+			m.Params = append(m.Params, param)
+		}
+	}
+	if m.Command == "" {
+		return nil, errEmptyIRCLine
+	}
+	return m, nil
+}
+
+// param 第 i 个参数，不存在时为空
+func (m *ircMessage) param(i int) string {
+	if i < len(m.Params) {
+		return m.Params[i]
+	}
+	return ""
+}
+
+// String 格式化为一行（不含 CRLF）；最后一个参数为空、含空格或以 ':' 开头时作为 trailing
+func (m *ircMessage) String() string {
+	var b strings.Builder
+	if m.Prefix != "" {
+		b.WriteByte(':')
+		b.WriteString(m.Prefix)
+		b.WriteByte(' ')
+	}
+	b.WriteString(m.Command)
+	for i, p := range m.Params {
+		b.WriteByte(' ')
+		if i == len(m.Params)-1 && (m.trailing || p == "" || strings.ContainsRune(p, ' ') || p[0] == ':') {
+			b.WriteByte(':')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// ircNick 将聊天昵称转为合法的 IRC 昵称：空白与 IRC 保留字符替换为 '_'
+func ircNick(name string) string {
+	if name == "" {
+		return "*"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r <= ' ', r == ',', r == '!', r == '@', r == '*', r == '?', r == ':':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// validIRCNick 客户端提交的昵称是否可用：非空、不超过 30 字符、不以 '#'/'&'/':'/数字开头、不含保留字符
+func validIRCNick(nick string) bool {
+	if nick == "" || len([]rune(nick)) > 30 {
+		return false
+	}
+	switch c := nick[0]; {
+	case c == '#', c == '&', c == ':', c >= '0' && c <= '9', c == '-':
+		return false
+	}
+	return ircNick(nick) == nick
+}
+
+// isIRCChannel 目标是否为频道名
+func isIRCChannel(target string) bool {
+	return strings.HasPrefix(target, "#") || strings.HasPrefix(target, "&")
+}
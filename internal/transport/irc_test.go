@@ -0,0 +1,270 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/internal/subscriber"
+)
+
+func TestParseIRCMessage(t *testing.T) {
+	cases := []struct {
+		line string
+		want ircMessage
+	}{
+		{"NICK alice", ircMessage{Command: "NICK", Params: []string{"alice"}}},
+		{"privmsg #chat :hello world\r\n", ircMessage{Command: "PRIVMSG", Params: []string{"#chat", "hello world"}}},
+		{":bob!bob@host PRIVMSG alice ::)", ircMessage{Prefix: "bob!bob@host", Command: "PRIVMSG", Params: []string{"alice", ":)"}}},
+		{"@time=2024 USER u 0 *  :Real Name", ircMessage{Command: "USER", Params: []string{"u", "0", "*", "Real Name"}}},
+		{"PRIVMSG #chat :", ircMessage{Command: "PRIVMSG", Params: []string{"#chat", ""}}},
+	}
+	for _, c := range cases {
+		got, err := parseIRCMessage(c.line)
+		if err != nil {
+			t.Errorf("parse %q: %v", c.line, err)
+			continue
+		}
+		if got.Prefix != c.want.Prefix || got.Command != c.want.Command || fmt.Sprint(got.Params) != fmt.Sprint(c.want.Params) {
+			t.Errorf("parse %q = %+v, want %+v", c.line, got, c.want)
+		}
+	}
+	if _, err := parseIRCMessage(":prefix-only"); err == nil {
+		t.Error("line without command must fail")
+	}
+
+	m := &ircMessage{Prefix: "srv", Command: "NOTICE", Params: []string{"alice", "hi there"}}
+	if got := m.String(); got != ":srv NOTICE alice :hi there" {
+		t.Errorf("format = %q", got)
+	}
+	if got := (&ircMessage{Command: "JOIN", Params: []string{"#chat"}}).String(); got != "JOIN #chat" {
+		t.Errorf("format = %q", got)
+	}
+}
+
+func TestIRCNick(t *testing.T) {
+	if got := ircNick("张 三"); got != "张_三" {
+		t.Errorf("ircNick = %q", got)
+	}
+	for nick, ok := range map[string]bool{"alice": true, "[bot]": true, "#chan": false, "9lives": false, "a b": false, "": false} {
+		if validIRCNick(nick) != ok {
+			t.Errorf("validIRCNick(%q) = %v, want %v", nick, !ok, ok)
+		}
+	}
+	if parts := splitIRCText("你好世界", 7); len(parts) != 2 || strings.Join(parts, "") != "你好世界" {
+		t.Errorf("split = %q", parts)
+	}
+}
+
+// ircClient 测试用 IRC 客户端
+type ircClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialIRC(t *testing.T, addr string) *ircClient {
+	t.Helper()
+	conn := dialTCP(t, addr)
+	return &ircClient{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *ircClient) send(t *testing.T, format string, args ...any) {
+	t.Helper()
+	if _, err := fmt.Fprintf(c.conn, format+"\r\n", args...); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+// expect 读取直到出现包含 want 的行，返回该行
+func (c *ircClient) expect(t *testing.T, want string) string {
+	t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var seen []string
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("waiting for %q: %v; seen %q", want, err, seen)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.Contains(line, want) {
+			return line
+		}
+		seen = append(seen, line)
+	}
+}
+
+// register 完成注册并等待加入频道
+func (c *ircClient) register(t *testing.T, nick string) {
+	t.Helper()
+	c.send(t, "NICK %s", nick)
+	c.send(t, "USER %s 0 * :%s", nick, nick)
+	c.expect(t, " 001 "+nick+" ")
+	c.expect(t, " 366 "+nick+" #chat ")
+}
+
+// TestIRCGateway IRC 用户与 TCP 用户经同一个 Hub 互通
+func TestIRCGateway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := chat.NewHub()
+	subscriber.RegisterAll(hub)
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	ircAddr, tcpAddr := freeAddr(t), freeAddr(t)
+	go func() {
+		_ = NewIRCServer("", "").Start(ctx, ircAddr, NewChatGateway(hub, reg), Options{})
+	}()
+	go func() {
+		_ = NewTCPServer(tcpAddr).Start(ctx, tcpAddr, NewChatGateway(hub, reg), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+		})
+	}()
+
+	alice := dialIRC(t, ircAddr)
+	defer alice.conn.Close()
+	alice.send(t, "PRIVMSG #chat :too early")
+	alice.expect(t, " 451 * ")
+	alice.register(t, "alice")
+
+	bob := dialIRC(t, ircAddr)
+	defer bob.conn.Close()
+	bob.register(t, "bob")
+	alice.expect(t, ":bob!bob@chat-go JOIN #chat")
+
+	// 在线用户的昵称不能再被注册
+	eve := dialIRC(t, ircAddr)
+	defer eve.conn.Close()
+	eve.send(t, "NICK alice")
+	eve.send(t, "USER eve 0 * :eve")
+	if line := eve.expect(t, " 433 "); !strings.Contains(line, "alice") {
+		t.Errorf("433 reply = %q", line)
+	}
+
+	// 频道消息：对方收到 PRIVMSG，发送者不回显
+	alice.send(t, "PRIVMSG #chat :hello irc")
+	bob.expect(t, ":alice!alice@chat-go PRIVMSG #chat :hello irc")
+
+	// 私信
+	bob.send(t, "PRIVMSG alice :psst")
+	alice.expect(t, ":bob!bob@chat-go PRIVMSG alice :psst")
+	bob.send(t, "PRIVMSG nobody :hi")
+	bob.expect(t, " 401 bob nobody ")
+
+	// TCP 用户与 IRC 用户互通
+	tcp, err := DialClient(tcpAddr, &protocol.JSONCodec{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	if err := tcp.Write(protocol.NewMessageFactory().CreateSetNickMessage("carol")); err != nil {
+		t.Fatal(err)
+	}
+	alice.expect(t, ":carol!carol@chat-go JOIN #chat")
+	if err := tcp.Write(protocol.NewMessageFactory().CreateTextMessage("from tcp")); err != nil {
+		t.Fatal(err)
+	}
+	alice.expect(t, ":carol!carol@chat-go PRIVMSG #chat :from tcp")
+	alice.send(t, "PRIVMSG #chat :from irc")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		env, err := tcp.Read(1 << 20)
+		if err != nil {
+			t.Fatalf("tcp read: %v", err)
+		}
+		if p, err := protocol.DecodePayload[protocol.TextPayload](env); err == nil && strings.Contains(p.Text, "alice: from irc") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("tcp user did not receive irc message")
+		}
+	}
+
+	// WHO 列出全部在线用户
+	alice.send(t, "WHO #chat")
+	who := map[string]bool{}
+	for len(who) < 3 {
+		line := alice.expect(t, " 352 alice #chat ")
+		who[strings.Fields(line)[4]] = true
+	}
+	alice.expect(t, " 315 alice #chat ")
+	if !who["bob"] || !who["carol"] {
+		t.Errorf("WHO = %v", who)
+	}
+
+	// PING/PONG、KICK 权限、PART、QUIT
+	alice.send(t, "PING :token-1")
+	alice.expect(t, "PONG chat-go token-1")
+	alice.send(t, "KICK #chat bob :bye")
+	alice.expect(t, " 482 alice #chat ")
+	alice.send(t, "PART #chat :later")
+	alice.expect(t, ":alice!alice@chat-go PART #chat later")
+	alice.send(t, "JOIN #other")
+	alice.expect(t, " 403 alice #other ")
+	bob.send(t, "QUIT :gone")
+	bob.expect(t, "ERROR :Closing Link")
+	alice.send(t, "JOIN #chat")
+	alice.expect(t, " 366 alice #chat ")
+	alice.send(t, "NAMES")
+	if line := alice.expect(t, " 353 alice = #chat "); strings.Contains(line, "bob") {
+		t.Errorf("bob should have left: %q", line)
+	}
+}
+
+// TestIRCPendingReleased 成功的 PRIVMSG 与 KICK 没有 ack，请求记录在网关处理后即移除，不随消息数增长
+func TestIRCPendingReleased(t *testing.T) {
+	hub := chat.NewHub()
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	g := NewChatGateway(hub, reg)
+	if err := hub.Login(chat.NewClientWithBuffer("bob", 8), "bob", false); err != nil {
+		t.Fatal(err)
+	}
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	go func() { _, _ = io.Copy(io.Discard, peer) }()
+	s := &ircSession{
+		Base:      NewBase("irc-1", "pipe"),
+		conn:      conn,
+		server:    NewIRCServer("", ""),
+		lister:    g,
+		pending:   make(map[string]ircPending),
+		closeChan: make(chan struct{}),
+	}
+	s.sc = NewSessionContext(s)
+	s.gateway = g
+	g.OnSessionOpen(s.sc)
+	defer g.OnSessionClose(s.sc)
+
+	lines := []string{"NICK alice", "USER alice 0 * :alice"}
+	for i := 0; i < 2*maxIRCPending; i++ {
+		lines = append(lines, "PRIVMSG #chat :hi", "PRIVMSG bob :psst", "KICK #chat bob")
+	}
+	for _, line := range lines {
+		m, err := parseIRCMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.handle(m)
+	}
+	if nick, registered, _ := s.snapshot(); !registered || nick != "alice" {
+		t.Fatalf("registration: nick=%q registered=%v", nick, registered)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.pending); n != 0 {
+		t.Errorf("%d requests still pending", n)
+	}
+}
@@ -0,0 +1,268 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// defaultMaxLineLength 行协议单行默认上限（字节，不含行尾）
+const defaultMaxLineLength = 4096
+
+// bufferedConn 带缓冲读取的连接，用于在不丢失数据的前提下预读首字节
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newBufferedConn(conn net.Conn) *bufferedConn {
+	return &bufferedConn{Conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// isLineMode 预读首字节判断客户端是否使用行协议：长度前缀帧的首字节是长度的最高字节，
+// 帧不超过 16MB 时恒为 0；人工输入的文本首字节不会是 0
+func isLineMode(c *bufferedConn, timeout time.Duration) (bool, error) {
+	_ = c.SetReadDeadline(time.Now().Add(timeout))
+	defer func() { _ = c.SetReadDeadline(time.Time{}) }()
+	b, err := c.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	return b[0] != 0, nil
+}
+
+// LineServer 换行分隔的纯文本协议，供 telnet/nc 直接使用：
+// 每行输入转为 text 或命令（以 / 开头）消息，出站消息渲染为可读文本行（CRLF 结尾）。
+// 行尾接受 LF 与 CRLF，超过 MaxLineLength 的行被丢弃并回复错误。
+// TCP 监听器开启编解码器协商时也会按首字节自动识别行协议客户端。
+type LineServer struct{}
+
+// NewLineServer 创建行协议服务器
+func NewLineServer() *LineServer {
+	return &LineServer{}
+}
+
+// Name 获取传输类型名称
+func (s *LineServer) Name() string {
+	return Line
+}
+
+// Start 启动行协议服务器
+func (s *LineServer) Start(ctx context.Context, addr string, gateway Gateway, opt Options) error {
+	ln, err := net.Listen(Tcp, addr)
+	if err != nil {
+		return err
+	}
+	if opt.TLS != nil {
+		ln = tls.NewListener(ln, opt.TLS)
+	}
+	logger.L().Sugar().Infow("line_listen", "addr", addr, "tls", opt.TLS != nil)
+
+	// 优雅关闭
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.L().Sugar().Warnw("line_accept_error", "err", err)
+			continue
+		}
+		go func() {
+			id := uuid.New().String()
+			identity, err := tlsHandshake(conn, opt)
+			if err != nil {
+				logger.L().Sugar().Warnw("tls_handshake_error", "session", id, "addr", conn.RemoteAddr().String(), "err", err)
+				_ = conn.Close()
+				return
+			}
+			release, rejected := opt.Admission.Admit(s.Name(), remoteIP(conn.RemoteAddr()))
+			if rejected != nil {
+				rejectLine(conn, "! "+rejected.Message)
+				return
+			}
+			defer release()
+			serveLine(ctx, id, newBufferedConn(conn), identity, gateway, opt)
+		}()
+	}
+}
+
+// lineSession 行协议会话
+type lineSession struct {
+	*Base
+	conn      *bufferedConn
+	identity  string
+	opt       Options
+	writeMu   sync.Mutex
+	closeChan chan struct{}
+}
+
+// serveLine 以行协议服务一个连接，直到连接关闭
+func serveLine(ctx context.Context, id string, conn *bufferedConn, identity string, gateway Gateway, opt Options) {
+	if opt.MaxLineLength <= 0 {
+		opt.MaxLineLength = defaultMaxLineLength
+	}
+	session := &lineSession{
+		Base:      NewBase(id, conn.RemoteAddr().String()),
+		conn:      conn,
+		identity:  identity,
+		opt:       opt,
+		closeChan: make(chan struct{}),
+	}
+	sc := NewSessionContext(session)
+	gateway.OnSessionOpen(sc)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = session.Close()
+		case <-session.closeChan:
+		}
+	}()
+	session.readLoop(gateway, sc)
+}
+
+// Identity 客户端证书身份，非 mTLS 连接为空
+func (s *lineSession) Identity() string {
+	return s.identity
+}
+
+// CodecName 本会话的线路协议
+func (s *lineSession) CodecName() string {
+	return Line
+}
+
+// TextOnly 行协议只输出文本
+func (s *lineSession) TextOnly() bool {
+	return true
+}
+
+// Close 关闭会话
+func (s *lineSession) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.stateMu.Lock()
+		s.state = SessionStateClosed
+		s.stateMu.Unlock()
+		err = s.conn.Close()
+		close(s.closeChan)
+	})
+	return err
+}
+
+// SendEnvelope 将消息渲染为文本行：text 原样输出，error 以 "! " 开头，其余消息不输出
+func (s *lineSession) SendEnvelope(e *protocol.Envelope) error {
+	if s.State() == SessionStateClosed {
+		return ErrSessionClosed
+	}
+	var text string
+	switch e.Type {
+	case protocol.MsgText:
+		p, err := protocol.DecodePayload[protocol.TextPayload](e)
+		if err != nil {
+			return err
+		}
+		text = p.Text
+	case protocol.MsgError:
+		p, err := protocol.DecodePayload[protocol.ErrorPayload](e)
+		if err != nil {
+			return err
+		}
+		text = "! " + p.Message
+	default:
+		return nil
+	}
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n") + "\r\n"
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.opt.WriteTimeout > 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.opt.WriteTimeout))
+	}
+	_, err := s.conn.Write([]byte(text))
+	return err
+}
+
+// readLoop 逐行读取，每行转为 text 或命令消息
+func (s *lineSession) readLoop(gateway Gateway, sc *SessionContext) {
+	defer func() {
+		gateway.OnSessionClose(sc)
+		_ = s.Close()
+	}()
+	factory := sc.Factory()
+	for {
+		if s.opt.ReadTimeout > 0 {
+			_ = s.conn.SetReadDeadline(time.Now().Add(s.opt.ReadTimeout))
+		}
+		line, tooLong, err := readLine(s.conn.r, s.opt.MaxLineLength)
+		if err != nil {
+			return
+		}
+		if tooLong {
+			sendError(sc, protocol.Errorf(protocol.CodeFrameTooLarge, "line exceeds %d bytes", s.opt.MaxLineLength), "")
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if line[0] == '/' {
+			deliver(gateway, sc, factory.CreateCommandMessage(line))
+		} else {
+			deliver(gateway, sc, factory.CreateTextMessage(line))
+		}
+	}
+}
+
+// readLine 读取一行并去掉行尾（LF 或 CRLF）、控制字符与首尾空白，非法 UTF-8 被替换；
+// 超过 max 字节的行整行丢弃，tooLong 为 true
+func readLine(r *bufio.Reader, max int) (line string, tooLong bool, err error) {
+	var buf []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !tooLong {
+			if len(buf)+len(chunk) > max+2 {
+				tooLong, buf = true, nil
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+		break
+	}
+	if tooLong {
+		return "", true, nil
+	}
+	line = strings.TrimRight(string(buf), "\r\n")
+	if len(line) > max {
+		return "", true, nil
+	}
+	line = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(line, "�"))
+	return strings.TrimSpace(line), false, nil
+}
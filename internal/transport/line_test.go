@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+	"github.com/hongjun500/chat-go/internal/subscriber"
+)
+
+func TestReadLine(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("hi\r\n\x1b[31mred\x00\n"+strings.Repeat("x", 40)+"\nok\n"), 16)
+	for _, want := range []struct {
+		line    string
+		tooLong bool
+	}{{"hi", false}, {"[31mred", false}, {"", true}, {"ok", false}} {
+		line, tooLong, err := readLine(r, 32)
+		if err != nil {
+			t.Fatalf("readLine: %v", err)
+		}
+		if line != want.line || tooLong != want.tooLong {
+			t.Errorf("readLine = %q, %v; want %q, %v", line, tooLong, want.line, want.tooLong)
+		}
+	}
+}
+
+// TestLineTransport 行协议客户端登录、聊天、执行命令，与 TCP 帧协议客户端互通
+func TestLineTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := chat.NewHub()
+	subscriber.RegisterAll(hub)
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	lineAddr, tcpAddr := freeAddr(t), freeAddr(t)
+	go func() {
+		_ = NewLineServer().Start(ctx, lineAddr, NewChatGateway(hub, reg), Options{MaxLineLength: 64})
+	}()
+	// TCP 端口开启协商：首字节非 0 的连接自动按行协议处理
+	go func() {
+		_ = NewTCPServer(tcpAddr).Start(ctx, tcpAddr, NewChatGateway(hub, reg), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+			CodecNegotiation:   true,
+		})
+	}()
+
+	alice := dialIRC(t, lineAddr)
+	defer alice.conn.Close()
+	alice.send(t, "alice")
+	alice.expect(t, "alice")
+
+	bob := dialIRC(t, tcpAddr)
+	defer bob.conn.Close()
+	bob.send(t, "bob")
+	alice.expect(t, "bob")
+
+	alice.send(t, "hello \x07there")
+	bob.expect(t, "alice: hello there")
+	bob.send(t, "/who")
+	if line := bob.expect(t, "alice"); !strings.Contains(line, "bob") {
+		t.Errorf("/who = %q", line)
+	}
+	alice.send(t, "%s", strings.Repeat("y", 100))
+	alice.expect(t, "! line exceeds 64 bytes")
+
+	// 帧协议客户端在同一 TCP 端口照常工作
+	carol, err := DialClient(tcpAddr, &protocol.JSONCodec{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer carol.Close()
+	if err := carol.Write(protocol.NewMessageFactory().CreateSetNickMessage("carol")); err != nil {
+		t.Fatal(err)
+	}
+	alice.expect(t, "carol")
+	if err := carol.Write(protocol.NewMessageFactory().CreateTextMessage("framed")); err != nil {
+		t.Fatal(err)
+	}
+	alice.expect(t, "carol: framed")
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// LongPollCursorHeader 响应头：本批最后一条消息的 id，客户端在下次 GET 中以 cursor 参数回传
+const LongPollCursorHeader = "X-Chat-Cursor"
+
+const (
+	defaultPollHold  = 25 * time.Second // 默认无消息时挂起 GET 的时长，低于常见代理的空闲超时
+	defaultPollBatch = 100              // 默认每次 GET 返回的最大消息数
+)
+
+// LongPollServer HTTP 长轮询传输，供既不能用 WebSocket 也不能用 SSE 的客户端使用：
+//
+//	GET  <path>?cursor=N  确认 id <= N 的消息并取回之后的一批消息（每行一条 JSON，application/x-ndjson），
+//	                      没有消息时挂起至多 Hold，超时返回 204；响应头 X-Chat-Cursor 为新的 cursor
+//	POST <path>           请求体为一条 JSON 入站消息，也接受纯文本/命令行
+//
+// 会话ID通过 Cookie（chat_sid）或请求头 X-Chat-Session 携带；未携带或已失效时 GET 创建新会话并立即返回。
+// 超过宽限期没有新的 GET 时关闭会话。
+type LongPollServer struct {
+	Path  string        // endpoint path, defaults to "/poll"
+	Hold  time.Duration // 无消息时挂起 GET 的时长，defaults to 25s
+	Batch int           // 每次 GET 返回的最大消息数，defaults to 100
+
+	sessions *httpSessions
+}
+
+// NewLongPollServer 创建长轮询服务器
+func NewLongPollServer(path string) *LongPollServer {
+	if path == "" {
+		path = "/poll"
+	}
+	return &LongPollServer{
+		Path:     path,
+		Hold:     defaultPollHold,
+		Batch:    defaultPollBatch,
+		sessions: newHTTPSessions(LongPoll),
+	}
+}
+
+// Name 获取传输类型名称
+func (s *LongPollServer) Name() string {
+	return LongPoll
+}
+
+// Start 启动长轮询服务器
+func (s *LongPollServer) Start(ctx context.Context, addr string, gateway Gateway, opt Options) error {
+	if opt.MaxFrameSize <= 0 {
+		opt.MaxFrameSize = defaultHTTPMaxPostSize
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.Path, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.handlePoll(ctx, w, r, gateway, opt)
+		case http.MethodPost:
+			s.sessions.handlePost(w, r, gateway, opt)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	logger.L().Sugar().Infow("longpoll_listen", "addr", addr, "path", s.Path, "hold", s.Hold, "tls", opt.TLS != nil)
+
+	// 宽限期从上一次 GET 结束开始计算，挂起中的会话不会被回收
+	grace := opt.ReadTimeout
+	if grace <= 0 {
+		grace = defaultHTTPGrace
+	}
+	go s.sessions.reap(ctx, gateway, grace)
+	return serveHTTP(ctx, addr, mux, opt.TLS)
+}
+
+// handlePoll 确认 cursor 之前的消息，返回之后的一批消息；没有消息时挂起
+func (s *LongPollServer) handlePoll(ctx context.Context, w http.ResponseWriter, r *http.Request, gateway Gateway, opt Options) {
+	cursor, _ := strconv.ParseInt(r.URL.Query().Get("cursor"), 10, 64)
+	hold := s.Hold
+	c, ok := s.sessions.lookup(r)
+	if !ok {
+		// 新会话立即返回，让客户端尽快拿到会话ID
+		if c, ok = s.sessions.open(w, r, gateway, opt); !ok {
+			return
+		}
+		cursor, hold = 0, 0
+	}
+	s.sessions.bind(w, r, c, s.Path)
+	w.Header().Set("Cache-Control", "no-store")
+
+	c.session.ack(cursor)
+	gen := c.session.attach()
+	defer c.session.detach(gen)
+
+	timer := time.NewTimer(hold)
+	defer timer.Stop()
+	for {
+		events, wait, stale := c.session.pending(gen, cursor, s.Batch)
+		if len(events) > 0 {
+			writePollBatch(w, events)
+			return
+		}
+		if stale {
+			// 同一会话的新请求替换了本请求
+			writePollEmpty(w, cursor)
+			return
+		}
+		select {
+		case <-wait:
+		case <-timer.C:
+			writePollEmpty(w, cursor)
+			return
+		case <-c.session.closeChan:
+			http.Error(w, ErrSessionClosed.Error(), http.StatusGone)
+			return
+		case <-r.Context().Done():
+			return
+		case <-ctx.Done():
+			writePollEmpty(w, cursor)
+			return
+		}
+	}
+}
+
+// writePollBatch 写出一批消息，每行一条
+func writePollBatch(w http.ResponseWriter, events []outEvent) {
+	var buf bytes.Buffer
+	for _, ev := range events {
+		buf.Write(ev.data)
+		buf.WriteByte('\n')
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set(LongPollCursorHeader, strconv.FormatInt(events[len(events)-1].id, 10))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// writePollEmpty 挂起超时，没有新消息
+func writePollEmpty(w http.ResponseWriter, cursor int64) {
+	w.Header().Set(LongPollCursorHeader, strconv.FormatInt(cursor, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,160 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// poll 发起一次长轮询，返回状态码、新 cursor 与消息
+func poll(t *testing.T, url, sid string, cursor int64) (int, string, int64, []*protocol.Envelope) {
+	t.Helper()
+	code, sid, next, envs, err := doPoll(url, sid, cursor)
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	return code, sid, next, envs
+}
+
+// doPoll 同 poll，以返回值报告错误，可在测试之外的 goroutine 中使用
+func doPoll(url, sid string, cursor int64) (int, string, int64, []*protocol.Envelope, error) {
+	req, _ := http.NewRequest(http.MethodGet, url+"?cursor="+strconv.FormatInt(cursor, 10), nil)
+	if sid != "" {
+		req.Header.Set(HTTPSessionHeader, sid)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", 0, nil, err
+	}
+	defer resp.Body.Close()
+	next, _ := strconv.ParseInt(resp.Header.Get(LongPollCursorHeader), 10, 64)
+	var envs []*protocol.Envelope
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		var env protocol.Envelope
+		if err := json.Unmarshal(sc.Bytes(), &env); err != nil {
+			return 0, "", 0, nil, fmt.Errorf("decode %q: %w", sc.Text(), err)
+		}
+		envs = append(envs, &env)
+	}
+	return resp.StatusCode, resp.Header.Get(HTTPSessionHeader), next, envs, nil
+}
+
+// TestLongPollTransport 新会话立即返回、POST 入站、挂起等待新消息、超时返回 204
+func TestLongPollTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewLongPollServer("")
+	srv.Hold = 300 * time.Millisecond
+	addr := freeAddr(t)
+	go func() {
+		_ = srv.Start(ctx, addr, NewChatGateway(chat.NewHub(), reg), Options{})
+	}()
+	dialTCP(t, addr).Close()
+	url := "http://" + addr + "/poll"
+
+	code, sid, cursor, envs := poll(t, url, "", 0)
+	if code != http.StatusOK || sid == "" || len(envs) == 0 {
+		t.Fatalf("first poll = %d, sid %q, %d messages", code, sid, len(envs))
+	}
+
+	// 挂起中的 GET 在 POST 产生回复后立即返回
+	type result struct {
+		code   int
+		cursor int64
+		envs   []*protocol.Envelope
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		code, _, next, envs, err := doPoll(url, sid, cursor)
+		done <- result{code, next, envs, err}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if code := postHTTP(t, url, sid, []byte("carol")); code != http.StatusAccepted {
+		t.Fatalf("post status = %d", code)
+	}
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("held poll: %v", r.err)
+	}
+	if r.code != http.StatusOK || len(r.envs) == 0 || r.envs[0].Type != protocol.MsgAck {
+		t.Fatalf("held poll = %d, %+v", r.code, r.envs)
+	}
+	if r.cursor <= cursor {
+		t.Errorf("cursor did not advance: %d -> %d", cursor, r.cursor)
+	}
+	cursor = r.cursor
+
+	if code := postHTTP(t, url, sid, []byte("/who")); code != http.StatusAccepted {
+		t.Fatalf("post command status = %d", code)
+	}
+	_, _, cursor, envs = poll(t, url, sid, cursor)
+	if len(envs) != 1 || !strings.Contains(textOf(t, envs[0]), "carol") {
+		t.Fatalf("/who batch = %+v", envs)
+	}
+
+	// 无新消息：挂起至 Hold 后返回 204，cursor 不变
+	start := time.Now()
+	code, _, next, envs := poll(t, url, sid, cursor)
+	if code != http.StatusNoContent || next != cursor || len(envs) != 0 {
+		t.Errorf("idle poll = %d, cursor %d, %d messages", code, next, len(envs))
+	}
+	if held := time.Since(start); held < 250*time.Millisecond {
+		t.Errorf("idle poll returned after %v, want it held", held)
+	}
+}
+
+// TestLongPollReaping 停止轮询超过宽限期后会话关闭
+func TestLongPollReaping(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	closed := make(chan string, 1)
+	gw := &closeRecorder{SimpleGateway: NewSimpleGateway(), closed: closed}
+	addr := freeAddr(t)
+	go func() {
+		_ = NewLongPollServer("").Start(ctx, addr, gw, Options{ReadTimeout: 100 * time.Millisecond})
+	}()
+	dialTCP(t, addr).Close()
+	url := "http://" + addr + "/poll"
+
+	_, sid, _, _ := poll(t, url, "", 0)
+	select {
+	case id := <-closed:
+		if id != sid {
+			t.Errorf("closed session = %q, want %q", id, sid)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle session was not reaped")
+	}
+	if code := postHTTP(t, url, sid, []byte("hi")); code != http.StatusNotFound {
+		t.Errorf("reaped session status = %d, want 404", code)
+	}
+}
+
+// closeRecorder 记录会话关闭
+type closeRecorder struct {
+	*SimpleGateway
+	closed chan string
+}
+
+func (g *closeRecorder) OnSessionClose(sc *SessionContext) {
+	g.SimpleGateway.OnSessionClose(sc)
+	g.closed <- sc.Id
+}
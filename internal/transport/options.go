@@ -1,8 +1,10 @@
 package transport
 
 import (
-	"github.com/hongjun500/chat-go/internal/protocol"
+	"crypto/tls"
 	"time"
+
+	"github.com/hongjun500/chat-go/internal/protocol"
 )
 
 // Options configures transports (shared across TCP/WS where applicable)
@@ -13,6 +15,24 @@ type Options struct {
 	HeartbeatInterval time.Duration // 心跳间隔，（服务端检测间隔
 	HeartbeatTimeout  time.Duration // 心跳超时（客户端允许多长时间不发心跳）
 	MaxFrameSize      int           // for framed transports (bytes), default 1MB
+	MaxLineLength     int           // 行协议单行上限（字节），默认 4096
+	// WebSocket 读写缓冲区大小（字节），默认 1024；缓冲区只影响系统调用次数，不限制消息大小
+	WSReadBufferSize  int
+	WSWriteBufferSize int
+	// WSCompression 开启后与声明支持的客户端协商 permessage-deflate；
+	// WSCompressionLevel 为 flate 压缩级别（-2..9），0 表示默认级别
+	WSCompression      bool
+	WSCompressionLevel int
+	// CodecNegotiation 开启后 TCP 连接等待客户端首帧，按首字节嗅探本会话的编解码器，
+	// 首字节非 0 的连接按行协议（telnet/nc）处理；
+	// WebSocket 始终支持通过 Sec-WebSocket-Protocol 子协议协商，不受此开关影响
+	CodecNegotiation bool
+	// TLS 非 nil 时监听器使用 TLS（见 NewTLSConfig）；配置了客户端 CA 时，
+	// 客户端证书身份作为会话的聊天身份（SessionContext.Identity）
+	TLS *tls.Config
+	// Admission 连接准入控制（全局/单 IP 并发上限与接入速率），nil 不限制；
+	// 被拒绝的连接先收到带错误码的 error 消息再关闭
+	Admission *Admission
 
 	// 新的协议管理器配置
 	TCPProtocolManager *protocol.Manager // TCP 协议管理器
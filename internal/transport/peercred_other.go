@@ -0,0 +1,10 @@
+//go:build !linux
+
+package transport
+
+import "net"
+
+// peerCredentials 非 Linux 平台不支持 SO_PEERCRED
+func peerCredentials(conn net.Conn) (*peerCred, error) {
+	return nil, errPeerCredUnsupported
+}
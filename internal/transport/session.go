@@ -3,7 +3,9 @@ package transport
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/hongjun500/chat-go/internal/chat"
 	"github.com/hongjun500/chat-go/internal/protocol"
 )
 
@@ -38,10 +40,41 @@ type Base struct {
 	closeOnce  sync.Once
 }
 
+// codecNamer 可选接口：会话对外暴露本连接协商出的编解码器名称
+type codecNamer interface {
+	CodecName() string
+}
+
+// identified 可选接口：会话对外暴露经过认证的客户端身份（如 mTLS 客户端证书）
+type identified interface {
+	Identity() string
+}
+
+// latencyReporter 可选接口：会话对外暴露心跳测得的往返时延
+type latencyReporter interface {
+	Latency() time.Duration
+}
+
+// textOnly 可选接口：会话只能呈现文本（行协议、IRC），网关不向其推送 presence、typing、回执等结构化消息
+type textOnly interface {
+	TextOnly() bool
+}
+
+// eventObserver 可选接口：会话自行渲染 Hub 的结构化事件（见 chat.EventObserver）
+type eventObserver interface {
+	ObserveEvent(chat.Event) bool
+}
+
 type SessionContext struct {
 	Id         string
 	RemoteAddr string
+	Codec      string // 本会话使用的编解码器名称
+	Identity   string // 传输层认证的客户端身份，为空表示未认证
 	sess       Session
+	factory    *protocol.MessageFactory // 按本会话编解码器选择负载编码的消息工厂
+
+	versionMu sync.RWMutex
+	adapter   protocol.VersionAdapter // 本会话协商出的协议版本，nil 表示尚未协商（按 v1 发送）
 
 	closed    int32
 	closeOnce sync.Once
@@ -74,14 +107,76 @@ func (s *Base) State() SessionState {
 }
 
 func NewSessionContext(s Session) *SessionContext {
-	return &SessionContext{Id: s.ID(), RemoteAddr: s.RemoteAddr(), sess: s}
+	sc := &SessionContext{Id: s.ID(), RemoteAddr: s.RemoteAddr(), sess: s}
+	if cn, ok := s.(codecNamer); ok {
+		sc.Codec = cn.CodecName()
+	}
+	if id, ok := s.(identified); ok {
+		sc.Identity = id.Identity()
+	}
+	sc.factory = protocol.NewMessageFactoryWithEncoding(protocol.EncodingForCodec(sc.Codec))
+	return sc
 }
 
+// Factory 本会话的消息工厂，负载编码与会话编解码器一致
+func (sc *SessionContext) Factory() *protocol.MessageFactory {
+	return sc.factory
+}
+
+// Send 将规范形态的消息按本会话的协议版本转换后发送
 func (sc *SessionContext) Send(e *protocol.Envelope) error {
 	if atomic.LoadInt32(&sc.closed) == SessionContextClosed {
 		return ErrSessionContextClosed
 	}
-	return sc.sess.SendEnvelope(e)
+	return sc.sess.SendEnvelope(sc.versionAdapter().Downgrade(e))
+}
+
+// Version 本会话使用的协议版本
+func (sc *SessionContext) Version() string {
+	return sc.versionAdapter().Version()
+}
+
+// SetVersion 设置本会话的协议版本，主版本不受支持时返回错误
+func (sc *SessionContext) SetVersion(version string) error {
+	a, err := protocol.AdapterFor(version)
+	if err != nil {
+		return err
+	}
+	sc.versionMu.Lock()
+	sc.adapter = a
+	sc.versionMu.Unlock()
+	return nil
+}
+
+func (sc *SessionContext) versionAdapter() protocol.VersionAdapter {
+	sc.versionMu.RLock()
+	defer sc.versionMu.RUnlock()
+	if sc.adapter == nil {
+		a, _ := protocol.AdapterFor(protocol.Version)
+		return a
+	}
+	return sc.adapter
+}
+
+// deliver 校验入站消息的协议版本并转换为规范形态后交给网关。
+// 不受支持的主版本回复错误消息并丢弃；hello 不做校验，由握手流程协商版本。
+// 会话尚未协商版本时，采用首条合法消息声明的版本。
+func deliver(gateway Gateway, sc *SessionContext, e *protocol.Envelope) {
+	if e.Type != protocol.MsgHello {
+		a, err := protocol.AdapterFor(e.Version)
+		if err != nil {
+			reject := sc.Factory().CreateErrorMessage(protocol.AsError(err, protocol.CodeUnsupportedVersion), e.Mid)
+			_ = sc.Send(reject)
+			return
+		}
+		sc.versionMu.Lock()
+		if sc.adapter == nil {
+			sc.adapter = a
+		}
+		sc.versionMu.Unlock()
+		a.Upgrade(e)
+	}
+	gateway.OnEnvelope(sc, e)
 }
 
 func (sc *SessionContext) Close() error {
@@ -0,0 +1,156 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+const (
+	defaultSSEKeepAlive = 15 * time.Second // 注释行保活间隔，防止代理断开空闲连接
+	sseRetryMillis      = 3000             // 建议客户端的重连间隔
+	sseEventSession     = "session"        // 首个事件：告知客户端会话ID
+	sseEventEnvelope    = "envelope"       // 出站消息事件
+)
+
+// SSEServer Server-Sent Events + HTTP POST 传输，适用于 WebSocket 与 TCP 受限的网络：
+//
+//	GET  <path>  建立事件流，出站消息以 "envelope" 事件下发，事件 id 用于 Last-Event-ID 重连补发
+//	POST <path>  请求体为一条 JSON 入站消息，也接受纯文本/命令行
+//
+// 会话ID通过 Cookie（chat_sid）或请求头 X-Chat-Session 携带；未携带或已失效时 GET 创建新会话。
+// 事件流断开后会话保留一段宽限期等待重连，超时后关闭。
+type SSEServer struct {
+	Path string // endpoint path, defaults to "/sse"
+
+	sessions *httpSessions
+}
+
+// NewSSEServer 创建 SSE 服务器
+func NewSSEServer(path string) *SSEServer {
+	if path == "" {
+		path = "/sse"
+	}
+	return &SSEServer{Path: path, sessions: newHTTPSessions(SSE)}
+}
+
+// Name 获取传输类型名称
+func (s *SSEServer) Name() string {
+	return SSE
+}
+
+// Start 启动 SSE 服务器
+func (s *SSEServer) Start(ctx context.Context, addr string, gateway Gateway, opt Options) error {
+	if opt.MaxFrameSize <= 0 {
+		opt.MaxFrameSize = defaultHTTPMaxPostSize
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.Path, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.handleStream(ctx, w, r, gateway, opt)
+		case http.MethodPost:
+			s.sessions.handlePost(w, r, gateway, opt)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	logger.L().Sugar().Infow("sse_listen", "addr", addr, "path", s.Path, "tls", opt.TLS != nil)
+
+	grace := opt.ReadTimeout
+	if grace <= 0 {
+		grace = defaultHTTPGrace
+	}
+	go s.sessions.reap(ctx, gateway, grace)
+	return serveHTTP(ctx, addr, mux, opt.TLS)
+}
+
+// handleStream 建立（或恢复）事件流
+func (s *SSEServer) handleStream(ctx context.Context, w http.ResponseWriter, r *http.Request, gateway Gateway, opt Options) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// 恢复已有会话时从 Last-Event-ID 之后补发；新会话从头开始
+	var after int64
+	c, resumed := s.sessions.lookup(r)
+	if resumed {
+		after, _ = strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	} else {
+		if c, ok = s.sessions.open(w, r, gateway, opt); !ok {
+			return
+		}
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	s.sessions.bind(w, r, c, s.Path)
+	w.WriteHeader(http.StatusOK)
+
+	gen := c.session.attach()
+	defer c.session.detach(gen)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\nevent: %s\ndata: %s\n\n", sseRetryMillis, sseEventSession, c.session.ID()); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(defaultSSEKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, wait, stale := c.session.pending(gen, after, 0)
+		if stale {
+			return // 被同一会话的新事件流替换
+		}
+		for _, ev := range events {
+			if err := writeSSEEvent(w, ev); err != nil {
+				return
+			}
+			after = ev.id
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+		select {
+		case <-wait:
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-c.session.closeChan:
+			return
+		case <-r.Context().Done():
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent 写出一个事件；数据按行拆分为多个 data 字段
+func writeSSEEvent(w io.Writer, ev outEvent) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "id: %d\nevent: %s\n", ev.id, sseEventEnvelope)
+	for _, line := range bytes.Split(ev.data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
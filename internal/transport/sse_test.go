@@ -0,0 +1,207 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// sseStream 测试用事件流读取器
+type sseStream struct {
+	resp *http.Response
+	sc   *bufio.Scanner
+}
+
+type sseTestEvent struct {
+	id    int64
+	event string
+	data  string
+}
+
+func openSSE(t *testing.T, url, sid string, lastID int64) *sseStream {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if sid != "" {
+		req.Header.Set(HTTPSessionHeader, sid)
+	}
+	if lastID > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatInt(lastID, 10))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get %s: %v", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("stream status = %d", resp.StatusCode)
+	}
+	return &sseStream{resp: resp, sc: bufio.NewScanner(resp.Body)}
+}
+
+// next 读取下一个事件，跳过注释行
+func (s *sseStream) next(t *testing.T) sseTestEvent {
+	t.Helper()
+	var ev sseTestEvent
+	var data []string
+	for s.sc.Scan() {
+		line := s.sc.Text()
+		switch {
+		case line == "":
+			if ev.event != "" || len(data) > 0 {
+				ev.data = strings.Join(data, "\n")
+				return ev
+			}
+		case strings.HasPrefix(line, "id: "):
+			ev.id, _ = strconv.ParseInt(line[4:], 10, 64)
+		case strings.HasPrefix(line, "event: "):
+			ev.event = line[7:]
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, line[6:])
+		}
+	}
+	t.Fatalf("stream ended: %v", s.sc.Err())
+	return ev
+}
+
+// nextEnvelope 读取下一条出站消息
+func (s *sseStream) nextEnvelope(t *testing.T) (int64, *protocol.Envelope) {
+	t.Helper()
+	for {
+		ev := s.next(t)
+		if ev.event != sseEventEnvelope {
+			continue
+		}
+		var env protocol.Envelope
+		if err := json.Unmarshal([]byte(ev.data), &env); err != nil {
+			t.Fatalf("decode %q: %v", ev.data, err)
+		}
+		return ev.id, &env
+	}
+}
+
+func (s *sseStream) Close() { _ = s.resp.Body.Close() }
+
+func postHTTP(t *testing.T, url, sid string, body []byte) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if sid != "" {
+		req.Header.Set(HTTPSessionHeader, sid)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+func textOf(t *testing.T, env *protocol.Envelope) string {
+	t.Helper()
+	p, err := protocol.DecodePayload[protocol.TextPayload](env)
+	if err != nil {
+		t.Fatalf("decode text payload of %s: %v", env.Type, err)
+	}
+	return p.Text
+}
+
+// TestSSETransport 事件流下发、POST 入站，以及按 Last-Event-ID 断线重连补发
+func TestSSETransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	go func() {
+		_ = NewSSEServer("/sse").Start(ctx, addr, NewChatGateway(chat.NewHub(), reg), Options{})
+	}()
+	dialTCP(t, addr).Close()
+	url := "http://" + addr + "/sse"
+
+	stream := openSSE(t, url, "", 0)
+	first := stream.next(t)
+	if first.event != sseEventSession || first.data == "" {
+		t.Fatalf("first event = %+v, want session id", first)
+	}
+	sid := first.data
+	if got := stream.resp.Header.Get(HTTPSessionHeader); got != sid {
+		t.Errorf("session header = %q, want %q", got, sid)
+	}
+	if _, env := stream.nextEnvelope(t); env.Type != protocol.MsgText {
+		t.Fatalf("prompt type = %s", env.Type)
+	}
+
+	// 登录：POST JSON 消息，事件流收到 ack
+	var buf bytes.Buffer
+	login := protocol.NewMessageFactory().CreateLoginMessage("bob", "en")
+	if err := (&protocol.JSONCodec{}).Encode(&buf, login); err != nil {
+		t.Fatal(err)
+	}
+	if code := postHTTP(t, url, sid, buf.Bytes()); code != http.StatusAccepted {
+		t.Fatalf("post status = %d", code)
+	}
+	var lastID int64
+	for {
+		id, env := stream.nextEnvelope(t)
+		lastID = id
+		if env.Type == protocol.MsgAck {
+			break
+		}
+	}
+	stream.Close()
+
+	// 断线期间的输出在重连后补发，且不重复已收到的事件
+	if code := postHTTP(t, url, sid, []byte("/who")); code != http.StatusAccepted {
+		t.Fatalf("post command status = %d", code)
+	}
+	resumed := openSSE(t, url, sid, lastID)
+	defer resumed.Close()
+	if ev := resumed.next(t); ev.data != sid {
+		t.Fatalf("resumed session = %q, want %q", ev.data, sid)
+	}
+	id, env := resumed.nextEnvelope(t)
+	if id != lastID+1 {
+		t.Errorf("replayed event id = %d, want %d", id, lastID+1)
+	}
+	if text := textOf(t, env); !strings.Contains(text, "bob") {
+		t.Errorf("/who reply = %q, want it to list bob", text)
+	}
+
+	if code := postHTTP(t, url, "no-such-session", []byte("hi")); code != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want 404", code)
+	}
+}
+
+// TestSSESessionExpiry 事件流断开超过宽限期后会话关闭
+func TestSSESessionExpiry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := freeAddr(t)
+	go func() {
+		_ = NewSSEServer("").Start(ctx, addr, NewSimpleGateway(), Options{ReadTimeout: 100 * time.Millisecond})
+	}()
+	dialTCP(t, addr).Close()
+	url := "http://" + addr + "/sse"
+
+	stream := openSSE(t, url, "", 0)
+	sid := stream.next(t).data
+	stream.Close()
+
+	// POST 会刷新活动时间，因此等待宽限期过后再探测
+	time.Sleep(400 * time.Millisecond)
+	if code := postHTTP(t, url, sid, []byte("/help")); code != http.StatusNotFound {
+		t.Fatalf("expired session status = %d, want 404", code)
+	}
+}
@@ -3,11 +3,11 @@ package transport
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"io"
 	"net"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +15,9 @@ import (
 	"github.com/hongjun500/chat-go/pkg/logger"
 )
 
+// negotiateTimeout 未配置读取超时时，等待客户端首帧的默认时长
+const negotiateTimeout = 10 * time.Second
+
 // tcpSession TCP 会话实现
 type tcpSession struct {
 	*Base
@@ -23,7 +26,8 @@ type tcpSession struct {
 	protocolManager *protocol.Manager
 	writeMu         sync.Mutex
 	closeChan       chan struct{}
-	lastActive      atomic.Value // 上次活动时间
+	hb              *heartbeat
+	identity        string // mTLS 客户端证书身份
 }
 
 // newTcpSession 创建 TCP 会话
@@ -34,8 +38,8 @@ func newTcpSession(id string, conn net.Conn, protocolManager *protocol.Manager)
 		frameCodec:      NewFrameCodec(),
 		protocolManager: protocolManager,
 		closeChan:       make(chan struct{}),
+		hb:              newHeartbeat(conn.LocalAddr().Network()), // tcp 或 unix
 	}
-	se.lastActive.Store(time.Now())
 	return se
 }
 
@@ -55,6 +59,21 @@ func (s *tcpSession) SendEnvelope(e *protocol.Envelope) error {
 	return s.frameCodec.WriteFrame(s.conn, buff.Bytes())
 }
 
+// Identity 客户端证书身份，非 mTLS 连接为空
+func (s *tcpSession) Identity() string {
+	return s.identity
+}
+
+// CodecName 本会话使用的编解码器名称
+func (s *tcpSession) CodecName() string {
+	return s.protocolManager.GetCodec().Name()
+}
+
+// Latency 最近一次心跳测得的往返时延
+func (s *tcpSession) Latency() time.Duration {
+	return s.hb.Latency()
+}
+
 // Close 关闭会话
 func (s *tcpSession) Close() error {
 	var err error
@@ -98,8 +117,11 @@ func (s *TCPServer) Start(ctx context.Context, addr string, gateway Gateway, opt
 	if err != nil {
 		return err
 	}
+	if opt.TLS != nil {
+		ln = tls.NewListener(ln, opt.TLS)
+	}
 
-	logger.L().Sugar().Infow("tcp_listen", "addr", listenAddr)
+	logger.L().Sugar().Infow("tcp_listen", "addr", listenAddr, "tls", opt.TLS != nil)
 
 	// 优雅关闭
 	go func() {
@@ -123,18 +145,103 @@ func (s *TCPServer) Start(ctx context.Context, addr string, gateway Gateway, opt
 // handleConnection 处理新连接
 func (s *TCPServer) handleConnection(ctx context.Context, conn net.Conn, gateway Gateway, opt Options) {
 	id := uuid.New().String()
+	// TLS：先完成握手，取得客户端证书身份；准入检查放在握手之后，拒绝原因才能被 TLS 客户端读到
+	identity, err := tlsHandshake(conn, opt)
+	if err != nil {
+		logger.L().Sugar().Warnw("tls_handshake_error", "session", id, "addr", conn.RemoteAddr().String(), "err", err)
+		_ = conn.Close()
+		return
+	}
+	release, rejected := opt.Admission.Admit(s.Name(), remoteIP(conn.RemoteAddr()))
+	if rejected != nil {
+		rejectFramed(conn, opt.GetTCPProtocolManager(), rejected)
+		return
+	}
+	defer release()
+	serveFramed(ctx, id, conn, identity, gateway, opt)
+}
+
+// serveFramed 以长度前缀帧协议服务一个已建立（已完成认证）的连接，直到连接关闭；
+// TCP 与 Unix 域套接字监听器共用
+func serveFramed(ctx context.Context, id string, conn net.Conn, identity string, gateway Gateway, opt Options) {
 	// 创建会话（使用协议管理器）
 	session := newTcpSession(id, conn, opt.GetTCPProtocolManager())
+	session.identity = identity
+	// 编解码器协商：先按首字节识别行协议客户端（telnet/nc），否则读取首帧并嗅探编码，首帧随后照常交给网关
+	var first []byte
+	if opt.CodecNegotiation {
+		bc := newBufferedConn(conn)
+		line, err := isLineMode(bc, negotiateDeadline(opt))
+		if err != nil {
+			logger.L().Sugar().Warnw("tcp_negotiate_error", "session", id, "addr", session.RemoteAddr(), "err", err)
+			_ = session.Close()
+			return
+		}
+		if line {
+			serveLine(ctx, id, bc, identity, gateway, opt)
+			return
+		}
+		session.conn = bc
+		if first, err = session.negotiateCodec(opt); err != nil {
+			logger.L().Sugar().Warnw("tcp_negotiate_error", "session", id, "addr", session.RemoteAddr(), "err", err)
+			_ = session.Close()
+			return
+		}
+	}
 	// 创建会话上下文
 	sc := NewSessionContext(session)
 	// 通知网关会话开启
 	gateway.OnSessionOpen(sc)
 	// 会话生命周期监控
 	go session.lifecycleWatcher(ctx, gateway, sc)
-	// 心跳监控
-	go session.heartbeatWatcher(opt)
+	// 心跳：定期 ping 并检测超时
+	go session.hb.run(sc, opt, session.closeChan)
 	// 启动读取循环
-	session.readLoop(gateway, sc, opt)
+	session.readLoop(gateway, sc, opt, first)
+}
+
+// negotiateDeadline 握手与协商阶段的等待时长：优先使用读取超时
+func negotiateDeadline(opt Options) time.Duration {
+	if opt.ReadTimeout > 0 {
+		return opt.ReadTimeout
+	}
+	return negotiateTimeout
+}
+
+// tlsHandshake 对 TLS 连接在超时内完成握手并返回客户端证书身份；非 TLS 连接直接返回空身份
+func tlsHandshake(conn net.Conn, opt Options) (string, error) {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return "", nil
+	}
+	_ = tc.SetDeadline(time.Now().Add(negotiateDeadline(opt)))
+	defer func() { _ = tc.SetDeadline(time.Time{}) }()
+	if err := tc.Handshake(); err != nil {
+		return "", err
+	}
+	state := tc.ConnectionState()
+	return peerIdentity(&state), nil
+}
+
+// negotiateCodec 读取客户端首帧，根据首字节选择本会话的编解码器，返回首帧数据
+func (s *tcpSession) negotiateCodec(opt Options) ([]byte, error) {
+	_ = s.conn.SetReadDeadline(time.Now().Add(negotiateDeadline(opt)))
+	defer func() { _ = s.conn.SetReadDeadline(time.Time{}) }()
+
+	frame, err := s.frameCodec.ReadFrame(s.conn)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := protocol.DetectCodec(frame)
+	if !ok {
+		return nil, ErrUnknownCodec
+	}
+	codec, err := protocol.NewCodec(c)
+	if err != nil {
+		return nil, err
+	}
+	s.protocolManager = s.protocolManager.WithCodec(codec)
+	return frame, nil
 }
 
 // lifecycleWatcher 会话生命周期监控
@@ -148,27 +255,8 @@ func (s *tcpSession) lifecycleWatcher(ctx context.Context, gateway Gateway, sc *
 	}
 }
 
-// heartbeatWatcher 心跳监控
-func (s *tcpSession) heartbeatWatcher(opt Options) {
-	if opt.HeartbeatInterval <= 0 || opt.HeartbeatTimeout <= 0 {
-		return
-	}
-	ticker := time.NewTicker(opt.HeartbeatInterval)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		last := s.lastActive.Load().(time.Time)
-		// 超过心跳超时，关闭会话
-		if time.Since(last) > opt.HeartbeatTimeout {
-			logger.L().Sugar().Infow("tcp_heartbeat_timeout", "session", s.ID())
-			_ = s.Close()
-			return
-		}
-	}
-}
-
-// readLoop 读取循环（内部方法）
-func (s *tcpSession) readLoop(gateway Gateway, sessionContext *SessionContext, opt Options) {
+// readLoop 读取循环（内部方法），first 为协商阶段已读取的首帧
+func (s *tcpSession) readLoop(gateway Gateway, sessionContext *SessionContext, opt Options, first []byte) {
 
 	defer func() {
 		// 通知网关会话关闭
@@ -176,10 +264,13 @@ func (s *tcpSession) readLoop(gateway Gateway, sessionContext *SessionContext, o
 		_ = s.Close()
 	}()
 
+	if first != nil {
+		s.handleFrame(gateway, sessionContext, first, opt)
+	}
 	for {
 		// 设置读取超时
 		if opt.ReadTimeout > 0 {
-			_ = s.conn.SetReadDeadline(time.Now().Add(opt.ReadTimeout * time.Second))
+			_ = s.conn.SetReadDeadline(time.Now().Add(opt.ReadTimeout))
 		}
 		// 读取帧数据
 		frameData, err := s.frameCodec.ReadFrame(s.conn)
@@ -192,18 +283,28 @@ func (s *tcpSession) readLoop(gateway Gateway, sessionContext *SessionContext, o
 			}
 			return // 读取错误，退出循环
 		}
-		// 解码消息
-		var envelope protocol.Envelope
-		if err := s.protocolManager.DecodeMessage(bytes.NewReader(frameData), &envelope, opt.MaxFrameSize); err != nil {
-			logger.L().Sugar().Warnw("tcp_decode_error", "session", s.ID(), "err", err)
-			continue
-		}
-		// 更新最后活动时间
-		s.lastActive.Store(time.Now())
-		if envelope.Type == protocol.MsgHeartbeat {
-			continue // 忽略心跳消息不传递给网关
-		}
-		// 传递给网关处理
-		gateway.OnEnvelope(sessionContext, &envelope)
+		s.handleFrame(gateway, sessionContext, frameData, opt)
+	}
+}
+
+// handleFrame 解码一帧数据并交给网关
+func (s *tcpSession) handleFrame(gateway Gateway, sessionContext *SessionContext, frameData []byte, opt Options) {
+	// 解码消息
+	var envelope protocol.Envelope
+	if err := s.protocolManager.DecodeMessage(bytes.NewReader(frameData), &envelope, opt.MaxFrameSize); err != nil {
+		logger.L().Sugar().Warnw("tcp_decode_error", "session", s.ID(), "err", err)
+		sendError(sessionContext, protocol.Errorf(protocol.CodeInvalidFrame, "decode frame: %v", err), "")
+		return
+	}
+	// 更新最后活动时间
+	s.hb.touch()
+	switch envelope.Type {
+	case protocol.MsgHeartbeat:
+		return // 忽略心跳消息不传递给网关
+	case protocol.MsgPong:
+		s.hb.pong(&envelope)
+		return
 	}
+	// 传递给网关处理
+	deliver(gateway, sessionContext, &envelope)
 }
@@ -0,0 +1,209 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// freeAddr 获取一个可用的本地监听地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen(Tcp, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr
+}
+
+// dialTCP 连接测试服务器，等待监听就绪
+func dialTCP(t *testing.T, addr string) net.Conn {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.Dial(Tcp, addr)
+		if err == nil {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dial %s: %v", addr, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestTCPCodecNegotiation 同一监听端口按首帧为每个会话选择编解码器
+func TestTCPCodecNegotiation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := freeAddr(t)
+	go func() {
+		_ = NewTCPServer(addr).Start(ctx, addr, NewSimpleGateway(), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+			CodecNegotiation:   true,
+		})
+	}()
+
+	for _, c := range []int{protocol.CodecJson, protocol.CodecProtobuf, protocol.CodecCbor} {
+		codec, _ := protocol.NewCodec(c)
+		t.Run(codec.Name(), func(t *testing.T) {
+			conn := dialTCP(t, addr)
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+			fc := NewFrameCodec()
+
+			var buf bytes.Buffer
+			hello := &protocol.Envelope{Version: protocol.Version, Type: protocol.MsgHello, Mid: "hello-1"}
+			if err := codec.Encode(&buf, hello); err != nil {
+				t.Fatalf("encode hello: %v", err)
+			}
+			if err := fc.WriteFrame(conn, buf.Bytes()); err != nil {
+				t.Fatalf("write hello: %v", err)
+			}
+
+			// 欢迎消息之后应收到握手回复，均使用协商出的编解码器
+			for {
+				frame, err := fc.ReadFrame(conn)
+				if err != nil {
+					t.Fatalf("read frame: %v", err)
+				}
+				var env protocol.Envelope
+				if err := codec.Decode(bytes.NewReader(frame), &env, 1<<20); err != nil {
+					t.Fatalf("decode with %s: %v", codec.Name(), err)
+				}
+				if env.Type != protocol.MsgHello {
+					continue
+				}
+				if env.Correlation != hello.Mid {
+					t.Errorf("correlation mismatch: got %s, want %s", env.Correlation, hello.Mid)
+				}
+				if env.Encoding != protocol.EncodingForCodec(codec.Name()) {
+					t.Errorf("payload encoding: got %s, want %s", env.Encoding, protocol.EncodingForCodec(codec.Name()))
+				}
+				p, err := protocol.DecodePayload[protocol.HelloPayload](&env)
+				if err != nil {
+					t.Fatalf("decode hello payload: %v", err)
+				}
+				if p.Codec != codec.Name() {
+					t.Errorf("server hello reports codec %q, want %q", p.Codec, codec.Name())
+				}
+				return
+			}
+		})
+	}
+}
+
+// TestTCPRejectUnsupportedVersion 不受支持的主版本应收到带关联 ID 的错误消息
+func TestTCPRejectUnsupportedVersion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := freeAddr(t)
+	go func() {
+		_ = NewTCPServer(addr).Start(ctx, addr, NewSimpleGateway(), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+		})
+	}()
+
+	conn := dialTCP(t, addr)
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	fc := NewFrameCodec()
+	codec := &protocol.JSONCodec{}
+
+	var buf bytes.Buffer
+	ping := &protocol.Envelope{Version: "3.0", Type: protocol.MsgPing, Mid: "ping-v3"}
+	if err := codec.Encode(&buf, ping); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if err := fc.WriteFrame(conn, buf.Bytes()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	for {
+		frame, err := fc.ReadFrame(conn)
+		if err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		var env protocol.Envelope
+		if err := codec.Decode(bytes.NewReader(frame), &env, 1<<20); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		switch env.Type {
+		case protocol.MsgPong:
+			t.Fatalf("v3 ping must not be dispatched")
+		case protocol.MsgError:
+			if env.Correlation != ping.Mid {
+				t.Errorf("correlation mismatch: got %s, want %s", env.Correlation, ping.Mid)
+			}
+			p, err := protocol.DecodePayload[protocol.ErrorPayload](&env)
+			if err != nil {
+				t.Fatalf("decode error payload: %v", err)
+			}
+			if p.Code != protocol.CodeUnsupportedVersion || p.Reason != "unsupported_version" {
+				t.Errorf("got code=%d reason=%q, want %d unsupported_version", p.Code, p.Reason, protocol.CodeUnsupportedVersion)
+			}
+			return
+		}
+	}
+}
+
+// TestTCPRejectInvalidPayload 负载校验失败时回复指明规则与字段的错误消息，且不分发
+func TestTCPRejectInvalidPayload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := freeAddr(t)
+	go func() {
+		_ = NewTCPServer(addr).Start(ctx, addr, NewSimpleGateway(), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+		})
+	}()
+
+	conn := dialTCP(t, addr)
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	fc := NewFrameCodec()
+	codec := &protocol.JSONCodec{}
+
+	var buf bytes.Buffer
+	text := protocol.NewMessageFactory().CreateTextMessage("")
+	if err := codec.Encode(&buf, text); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if err := fc.WriteFrame(conn, buf.Bytes()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	for {
+		frame, err := fc.ReadFrame(conn)
+		if err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		var env protocol.Envelope
+		if err := codec.Decode(bytes.NewReader(frame), &env, 1<<20); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if env.Type != protocol.MsgError {
+			continue
+		}
+		if env.Correlation != text.Mid {
+			t.Errorf("correlation mismatch: got %s, want %s", env.Correlation, text.Mid)
+		}
+		p, err := protocol.DecodePayload[protocol.ErrorPayload](&env)
+		if err != nil {
+			t.Fatalf("decode error payload: %v", err)
+		}
+		if p.Code != protocol.CodeInvalidMessage || p.Rule != protocol.RuleRequired || p.Field != "text" {
+			t.Errorf("got %+v, want code=%d rule=%s field=text", p, protocol.CodeInvalidMessage, protocol.RuleRequired)
+		}
+		return
+	}
+}
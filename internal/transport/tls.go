@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// defaultReloadInterval 证书文件变化的默认轮询间隔
+const defaultReloadInterval = 30 * time.Second
+
+// TLSOptions TCP/WebSocket 监听器的 TLS 配置
+type TLSOptions struct {
+	CertFile     string // PEM 证书（可含中间证书链）
+	KeyFile      string // PEM 私钥
+	ClientCAFile string // 非空时开启双向 TLS：要求并校验客户端证书
+	MinVersion   string // 最低版本 "1.2"（默认）或 "1.3"
+	// CipherPolicy 密码套件策略（仅作用于 TLS 1.2，1.3 的套件不可配置）：
+	// "default" 使用 Go 默认套件；"modern" 仅保留 ECDHE + AEAD 套件；
+	// 也可给出逗号分隔的套件名，如 "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,..."
+	CipherPolicy   string
+	ReloadInterval time.Duration // 证书文件轮询间隔，<=0 使用默认 30s
+}
+
+// Enabled 是否配置了证书
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" && o.KeyFile != ""
+}
+
+// NewTLSConfig 按配置创建服务端 tls.Config，并在 ctx 结束前轮询证书文件，变化时热加载。
+// 新证书只影响之后的握手，已建立的连接不受影响；加载失败时保留旧证书并记录日志。
+func NewTLSConfig(ctx context.Context, o TLSOptions) (*tls.Config, error) {
+	if !o.Enabled() {
+		return nil, errors.New("tls: cert and key files are required")
+	}
+	minVersion, err := parseTLSVersion(o.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	ciphers, err := parseCipherPolicy(o.CipherPolicy)
+	if err != nil {
+		return nil, err
+	}
+	r := &certReloader{opts: o}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	interval := o.ReloadInterval
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	go r.watch(ctx, interval)
+
+	base := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: ciphers,
+	}
+	cfg := base.Clone()
+	// 每次握手取当前证书与客户端 CA，实现热加载
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cert, pool := r.current()
+		c := base.Clone()
+		c.Certificates = []tls.Certificate{*cert}
+		if pool != nil {
+			c.ClientCAs = pool
+			c.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		return c, nil
+	}
+	// 仅为满足 http.Server.ServeTLS 对证书的检查，实际握手使用 GetConfigForClient
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, _ := r.current()
+		return cert, nil
+	}
+	return cfg, nil
+}
+
+// certReloader 持有当前证书与客户端 CA，按文件修改时间热加载
+type certReloader struct {
+	opts TLSOptions
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+func (r *certReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+// latestModTime 证书相关文件中最新的修改时间
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCAFile} {
+		if f == "" {
+			continue
+		}
+		st, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if st.ModTime().After(latest) {
+			latest = st.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read client ca: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates in %s", r.opts.ClientCAFile)
+		}
+	}
+	r.mu.Lock()
+	r.cert, r.pool, r.modTime = &cert, pool, modTime
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				logger.L().Sugar().Warnw("tls_stat_error", "err", err)
+				continue
+			}
+			r.mu.RLock()
+			changed := modTime.After(r.modTime)
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := r.load(); err != nil {
+				logger.L().Sugar().Warnw("tls_reload_error", "err", err)
+				continue
+			}
+			logger.L().Sugar().Infow("tls_reloaded", "cert", r.opts.CertFile)
+		}
+	}
+}
+
+func parseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimSpace(s) {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("tls: unsupported min version %q (want 1.2 or 1.3)", s)
+	}
+}
+
+// modernCiphers ECDHE 密钥交换 + AEAD 的 TLS 1.2 套件
+var modernCiphers = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// parseCipherPolicy 解析密码套件策略，nil 表示使用 Go 默认套件
+func parseCipherPolicy(s string) ([]uint16, error) {
+	switch strings.TrimSpace(s) {
+	case "", "default":
+		return nil, nil
+	case "modern":
+		return modernCiphers, nil
+	}
+	byName := make(map[string]uint16)
+	for _, c := range tls.CipherSuites() {
+		byName[c.Name] = c.ID
+	}
+	var out []uint16
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("tls: unknown or insecure cipher suite %q", name)
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+// certIdentity 从客户端证书提取聊天身份：优先 CommonName，其次第一个邮箱或 DNS SAN
+func certIdentity(cert *x509.Certificate) string {
+	if cert == nil {
+		return ""
+	}
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
+
+// peerIdentity 从 TLS 连接状态提取已校验的客户端证书身份
+func peerIdentity(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	return certIdentity(state.PeerCertificates[0])
+}
@@ -0,0 +1,270 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// testPKI 测试时生成的自签 CA 及其签发的证书文件
+type testPKI struct {
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caFile string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "chat-go test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	p := &testPKI{dir: t.TempDir(), ca: ca, caKey: key}
+	p.caFile = filepath.Join(p.dir, "ca.pem")
+	writePEM(t, p.caFile, "CERTIFICATE", der)
+	return p
+}
+
+// issue 签发证书，写入 <name>.pem / <name>-key.pem，返回两个文件路径
+func (p *testPKI) issue(t *testing.T, name, cn string, serial int64, client bool) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+	if client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(p.dir, name+".pem")
+	keyFile := filepath.Join(p.dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTCPMutualTLS 双向 TLS：客户端证书 CN 作为聊天身份自动登录，无证书的客户端握手失败
+func TestTCPMutualTLS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pki := newTestPKI(t)
+	certFile, keyFile := pki.issue(t, "server", "localhost", 2, false)
+	clientCert, clientKey := pki.issue(t, "alice", "alice", 3, true)
+	tlsCfg, err := NewTLSConfig(ctx, TLSOptions{CertFile: certFile, KeyFile: keyFile, ClientCAFile: pki.caFile, CipherPolicy: "modern"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	go func() {
+		_ = NewTCPServer(addr).Start(ctx, addr, NewChatGateway(chat.NewHub(), reg), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+			TLS:                tlsCfg,
+		})
+	}()
+	dialTCP(t, addr).Close()
+
+	clientCfg, err := ClientTLSConfig(pki.caFile, clientCert, clientKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := DialClient("tls://"+addr, &protocol.JSONCodec{}, clientCfg)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.Write(protocol.NewMessageFactory().CreateCommandMessage("/who")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		env, err := conn.Read(1 << 20)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if env.Type == protocol.MsgError {
+			p, _ := protocol.DecodePayload[protocol.ErrorPayload](env)
+			t.Fatalf("unexpected error: %+v", p)
+		}
+		if p, err := protocol.DecodePayload[protocol.TextPayload](env); err == nil && strings.Contains(p.Text, "alice") {
+			break
+		}
+	}
+
+	// 无客户端证书：服务端拒绝握手
+	noCert, _ := ClientTLSConfig(pki.caFile, "", "", false)
+	bad, err := DialClient("tls://"+addr, &protocol.JSONCodec{}, noCert)
+	if err == nil {
+		defer bad.Close()
+		if _, err = bad.Read(1 << 20); err == nil {
+			t.Fatal("client without certificate must be rejected")
+		}
+	}
+}
+
+// TestWSSecure wss:// 连接可收到欢迎消息
+func TestWSSecure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pki := newTestPKI(t)
+	certFile, keyFile := pki.issue(t, "server", "localhost", 2, false)
+	tlsCfg, err := NewTLSConfig(ctx, TLSOptions{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	go func() {
+		_ = NewWebSocketServer("/ws").Start(ctx, addr, NewSimpleGateway(), Options{
+			WSProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+			TLS:               tlsCfg,
+		})
+	}()
+	dialTCP(t, addr).Close()
+
+	clientCfg, _ := ClientTLSConfig(pki.caFile, "", "", false)
+	conn, err := DialClient("wss://"+addr+"/ws", &protocol.JSONCodec{}, clientCfg)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if state := conn.TLSState(); state == nil || state.Version != tls.VersionTLS13 {
+		t.Errorf("tls state = %+v, want TLS 1.3", state)
+	}
+	env, err := conn.Read(1 << 20)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if env.Type != protocol.MsgText {
+		t.Errorf("first message type = %s, want text", env.Type)
+	}
+}
+
+// TestTLSHotReload 证书文件变化后，新握手使用新证书
+func TestTLSHotReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pki := newTestPKI(t)
+	certFile, keyFile := pki.issue(t, "server", "localhost", 10, false)
+	tlsCfg, err := NewTLSConfig(ctx, TLSOptions{CertFile: certFile, KeyFile: keyFile, ReloadInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen(Tcp, "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _ = c.(*tls.Conn).Handshake(); _ = c.Close() }()
+		}
+	}()
+
+	clientCfg, _ := ClientTLSConfig(pki.caFile, "", "", false)
+	serial := func() int64 {
+		c, err := tls.Dial(Tcp, ln.Addr().String(), clientCfg)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer c.Close()
+		return c.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	if got := serial(); got != 10 {
+		t.Fatalf("initial serial = %d, want 10", got)
+	}
+
+	pki.issue(t, "server", "localhost", 11, false)
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	_ = os.Chtimes(keyFile, future, future)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for serial() != 11 {
+		if time.Now().After(deadline) {
+			t.Fatal("certificate was not reloaded")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestTLSOptionsValidation(t *testing.T) {
+	if _, err := parseTLSVersion("1.0"); err == nil {
+		t.Error("TLS 1.0 must be rejected")
+	}
+	if _, err := parseCipherPolicy("TLS_RSA_WITH_RC4_128_SHA"); err == nil {
+		t.Error("insecure cipher suite must be rejected")
+	}
+	ids, err := parseCipherPolicy("TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+	if err != nil || len(ids) != 2 {
+		t.Errorf("explicit cipher list = %v, %v", ids, err)
+	}
+	if _, err := NewTLSConfig(context.Background(), TLSOptions{}); err == nil {
+		t.Error("missing cert/key must be rejected")
+	}
+}
@@ -7,10 +7,15 @@ import (
 const (
 	Tcp       = "tcp"
 	WebSocket = "websocket"
+	SSE       = "sse"
+	LongPoll  = "longpoll"
+	IRC       = "irc"
+	Line      = "line"
+	Unix      = "unix"
 )
 
 // Transport 统一的传输层接口
-// 负责特定协议(TCP/WebSocket)的网络通信实现
+// 负责特定协议(TCP/WebSocket/SSE/长轮询/IRC/行协议/Unix 域套接字)的网络通信实现
 type Transport interface {
 	Name() string
 	Start(ctx context.Context, addr string, gateway Gateway, opt Options) error
@@ -0,0 +1,194 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hongjun500/chat-go/pkg/logger"
+)
+
+// defaultUnixSocketMode 套接字文件默认权限：属主与属组可读写
+const defaultUnixSocketMode os.FileMode = 0o660
+
+// errPeerCredUnsupported 当前平台不支持读取对端凭据
+var errPeerCredUnsupported = errors.New("unix: peer credentials not supported on this platform")
+
+// peerCred 对端进程凭据（SO_PEERCRED）
+type peerCred struct {
+	PID int
+	UID int
+	GID int
+}
+
+// String 作为会话的远端地址
+func (c *peerCred) String() string {
+	return fmt.Sprintf("pid=%d,uid=%d,gid=%d", c.PID, c.UID, c.GID)
+}
+
+// identity 对端用户名，无法解析时为 uid-<uid>
+func (c *peerCred) identity() string {
+	if u, err := user.LookupId(strconv.Itoa(c.UID)); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "uid-" + strconv.Itoa(c.UID)
+}
+
+// unixConn Unix 域套接字连接，远端地址为对端凭据（未命名套接字的远端地址为空）
+type unixConn struct {
+	net.Conn
+	peer string
+}
+
+func (c *unixConn) RemoteAddr() net.Addr {
+	return &net.UnixAddr{Name: c.peer, Net: Unix}
+}
+
+// UnixServer 在 Unix 域套接字上提供与 TCP 相同的长度前缀帧协议（复用 tcpSession 的分帧与协议管理器），
+// 供同机的 sidecar 机器人与本地工具使用。Options.TLS 对本传输无效。
+type UnixServer struct {
+	Mode os.FileMode // 套接字文件权限，默认 0660
+	// Owner 套接字文件属主，格式 user[:group]，用户与组可为名称或数字 ID；为空时不修改
+	Owner string
+	// PeerIdentity 开启后以对端进程用户名（SO_PEERCRED）作为会话身份直接登录，仅 Linux 支持
+	PeerIdentity bool
+}
+
+// NewUnixServer 创建 Unix 域套接字服务器
+func NewUnixServer() *UnixServer {
+	return &UnixServer{Mode: defaultUnixSocketMode}
+}
+
+// Name 获取传输类型名称
+func (s *UnixServer) Name() string {
+	return Unix
+}
+
+// Start 在套接字路径 addr 上启动服务器；残留的套接字文件会被删除，关闭时移除套接字文件
+func (s *UnixServer) Start(ctx context.Context, addr string, gateway Gateway, opt Options) error {
+	if opt.MaxFrameSize <= 0 {
+		opt.MaxFrameSize = 1 << 20 // 默认 1MB
+	}
+	opt.TLS = nil
+	uid, gid, err := lookupOwner(s.Owner)
+	if err != nil {
+		return err
+	}
+	if err := removeStaleSocket(addr); err != nil {
+		return err
+	}
+	ln, err := net.Listen(Unix, addr)
+	if err != nil {
+		return err
+	}
+	mode := s.Mode
+	if mode == 0 {
+		mode = defaultUnixSocketMode
+	}
+	if err := os.Chmod(addr, mode); err != nil {
+		_ = ln.Close()
+		return err
+	}
+	if uid >= 0 || gid >= 0 {
+		if err := os.Chown(addr, uid, gid); err != nil {
+			_ = ln.Close()
+			return err
+		}
+	}
+	logger.L().Sugar().Infow("unix_listen", "path", addr, "mode", mode.String(), "owner", s.Owner, "peer_identity", s.PeerIdentity)
+
+	// 优雅关闭
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.L().Sugar().Warnw("unix_accept_error", "err", err)
+			continue
+		}
+		go s.handleConnection(ctx, conn, gateway, opt)
+	}
+}
+
+// handleConnection 读取对端凭据后按帧协议服务连接
+func (s *UnixServer) handleConnection(ctx context.Context, conn net.Conn, gateway Gateway, opt Options) {
+	id := uuid.New().String()
+	uc := &unixConn{Conn: conn}
+	var identity string
+	cred, err := peerCredentials(conn)
+	switch {
+	case err == nil:
+		uc.peer = cred.String()
+		if s.PeerIdentity {
+			identity = cred.identity()
+		}
+	case s.PeerIdentity:
+		// 要求凭据身份时拒绝无法识别的对端
+		logger.L().Sugar().Warnw("unix_peercred_error", "session", id, "err", err)
+		_ = conn.Close()
+		return
+	}
+	// 本地连接没有 IP，只受全局上限与接入速率限制
+	release, rejected := opt.Admission.Admit(s.Name(), "")
+	if rejected != nil {
+		rejectFramed(conn, opt.GetTCPProtocolManager(), rejected)
+		return
+	}
+	defer release()
+	serveFramed(ctx, id, uc, identity, gateway, opt)
+}
+
+// removeStaleSocket 删除上次运行残留的套接字文件；路径存在但不是套接字时报错，避免误删
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("unix: %s exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+// lookupOwner 解析 user[:group]，未指定的部分返回 -1（os.Chown 保持不变）
+func lookupOwner(owner string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if owner == "" {
+		return uid, gid, nil
+	}
+	userPart, groupPart, _ := strings.Cut(owner, ":")
+	if userPart != "" {
+		if uid, err = strconv.Atoi(userPart); err != nil {
+			u, err := user.Lookup(userPart)
+			if err != nil {
+				return -1, -1, fmt.Errorf("unix: owner: %w", err)
+			}
+			uid, _ = strconv.Atoi(u.Uid)
+		}
+	}
+	if groupPart != "" {
+		if gid, err = strconv.Atoi(groupPart); err != nil {
+			g, err := user.LookupGroup(groupPart)
+			if err != nil {
+				return -1, -1, fmt.Errorf("unix: group: %w", err)
+			}
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	}
+	return uid, gid, nil
+}
@@ -0,0 +1,135 @@
+package transport
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// dialUnix 连接测试套接字，等待监听就绪
+func dialUnix(t *testing.T, path string) *ClientConn {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c, err := DialClient("unix://"+path, &protocol.JSONCodec{}, nil)
+		if err == nil {
+			return c
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dial %s: %v", path, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// readText 读取直到出现包含 want 的文本消息
+func readText(t *testing.T, c *ClientConn, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		env, err := c.Read(1 << 20)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if p, err := protocol.DecodePayload[protocol.TextPayload](env); err == nil && strings.Contains(p.Text, want) {
+			return
+		}
+	}
+	t.Fatalf("no text containing %q", want)
+}
+
+// TestUnixTransport 套接字文件权限、残留文件清理与帧协议收发
+func TestUnixTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "chat.sock")
+	// 路径已被普通文件占用时拒绝启动
+	if err := os.WriteFile(path+".txt", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewUnixServer().Start(ctx, path+".txt", NewSimpleGateway(), Options{}); err == nil {
+		t.Fatal("regular file must not be replaced")
+	}
+
+	srv := NewUnixServer()
+	srv.Mode = 0o600
+	go func() {
+		_ = srv.Start(ctx, path, NewChatGateway(chat.NewHub(), reg), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+		})
+	}()
+	c := dialUnix(t, path)
+	defer c.Close()
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, %v", fi, err)
+	}
+	if err := c.Write(protocol.NewMessageFactory().CreateSetNickMessage("bot")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Write(protocol.NewMessageFactory().CreateCommandMessage("/who")); err != nil {
+		t.Fatal(err)
+	}
+	readText(t, c, "bot")
+}
+
+// TestUnixPeerIdentity 以对端进程用户名登录
+func TestUnixPeerIdentity(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_PEERCRED is linux only")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "chat.sock")
+	srv := NewUnixServer()
+	srv.PeerIdentity = true
+	go func() {
+		_ = srv.Start(ctx, path, NewChatGateway(chat.NewHub(), reg), Options{
+			TCPProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+		})
+	}()
+	c := dialUnix(t, path)
+	defer c.Close()
+
+	want := (&peerCred{UID: os.Getuid()}).identity()
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		want = u.Username
+	}
+	if err := c.Write(protocol.NewMessageFactory().CreateCommandMessage("/who")); err != nil {
+		t.Fatal(err)
+	}
+	readText(t, c, want)
+}
+
+func TestLookupOwner(t *testing.T) {
+	if uid, gid, err := lookupOwner(""); err != nil || uid != -1 || gid != -1 {
+		t.Errorf("empty owner = %d, %d, %v", uid, gid, err)
+	}
+	if uid, gid, err := lookupOwner("1000:50"); err != nil || uid != 1000 || gid != 50 {
+		t.Errorf("numeric owner = %d, %d, %v", uid, gid, err)
+	}
+	if uid, gid, err := lookupOwner(":50"); err != nil || uid != -1 || gid != 50 {
+		t.Errorf("group only = %d, %d, %v", uid, gid, err)
+	}
+	if _, _, err := lookupOwner("no-such-user-xyz"); err == nil {
+		t.Error("unknown user must fail")
+	}
+}
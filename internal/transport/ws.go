@@ -13,6 +13,31 @@ import (
 	"github.com/hongjun500/chat-go/pkg/logger"
 )
 
+// wsSubprotocols 支持的 Sec-WebSocket-Protocol 子协议及对应编解码器，按服务端偏好排序；
+// 客户端未声明子协议时使用监听器默认编解码器
+var wsSubprotocols = []struct {
+	name  string
+	codec int
+}{
+	{"chat.json", protocol.CodecJson},
+	{"chat.proto", protocol.CodecProtobuf},
+	{"chat.cbor", protocol.CodecCbor},
+}
+
+// defaultWSBufferSize 未配置时 WebSocket 读写缓冲区大小（字节）
+const defaultWSBufferSize = 1024
+
+// wsMessageType 编解码器对应的 WebSocket 帧类型：二进制编码（protobuf/cbor）使用二进制帧，
+// JSON 使用文本帧（二进制内容放进文本帧不是合法 UTF-8，浏览器会断开连接）
+func wsMessageType(codecName string) int {
+	switch codecName {
+	case protocol.Protobuf, protocol.Cbor:
+		return websocket.BinaryMessage
+	default:
+		return websocket.TextMessage
+	}
+}
+
 // wsSession WebSocket 会话实现
 type wsSession struct {
 	*Base
@@ -20,6 +45,8 @@ type wsSession struct {
 	protocolManager *protocol.Manager
 	writeMu         sync.Mutex
 	closeChan       chan struct{}
+	hb              *heartbeat
+	identity        string // mTLS 客户端证书身份
 }
 
 // newWsSession 创建 WebSocket 会话
@@ -29,6 +56,7 @@ func newWsSession(id string, conn *websocket.Conn, protocolManager *protocol.Man
 		conn:            conn,
 		protocolManager: protocolManager,
 		closeChan:       make(chan struct{}),
+		hb:              newHeartbeat(WebSocket),
 	}
 }
 
@@ -46,7 +74,22 @@ func (s *wsSession) SendEnvelope(envelope *protocol.Envelope) error {
 		return err
 	}
 
-	return s.conn.WriteMessage(websocket.TextMessage, buffer.Bytes())
+	return s.conn.WriteMessage(wsMessageType(s.CodecName()), buffer.Bytes())
+}
+
+// Identity 客户端证书身份，非 mTLS 连接为空
+func (s *wsSession) Identity() string {
+	return s.identity
+}
+
+// CodecName 本会话使用的编解码器名称
+func (s *wsSession) CodecName() string {
+	return s.protocolManager.GetCodec().Name()
+}
+
+// Latency 最近一次心跳测得的往返时延
+func (s *wsSession) Latency() time.Duration {
+	return s.hb.Latency()
 }
 
 // Close 关闭会话
@@ -62,6 +105,14 @@ func (s *wsSession) Close() error {
 // WebSocketServer WebSocket 服务器实现
 type WebSocketServer struct {
 	Path string // WebSocket endpoint path, defaults to "/ws"
+	// AllowedOrigins 允许发起升级的浏览器来源（见 originAllowed），为空时只允许同主机来源
+	AllowedOrigins []string
+	// Tokens 非 nil 时升级请求必须携带被接受的令牌（Authorization: Bearer、?token= 或 chat_token Cookie）
+	Tokens TokenVerifier
+	// MaxConnsPerIP 单个客户端 IP 的并发连接上限，0 表示不限制
+	MaxConnsPerIP int
+
+	limiter *ipLimiter
 }
 
 // NewWebSocketServer 创建 WebSocket 服务器
@@ -81,16 +132,18 @@ func (ws *WebSocketServer) Name() string {
 
 // Start 启动 WebSocket 服务器
 func (ws *WebSocketServer) Start(ctx context.Context, addr string, gateway Gateway, opt Options) error {
+	ws.limiter = newIPLimiter(ws.MaxConnsPerIP)
 	mux := http.NewServeMux()
 	mux.HandleFunc(ws.Path, func(w http.ResponseWriter, r *http.Request) {
 		ws.handleConnection(w, r, gateway, opt)
 	})
 
-	logger.L().Sugar().Infow("websocket_listen", "addr", addr, "path", ws.Path)
+	logger.L().Sugar().Infow("websocket_listen", "addr", addr, "path", ws.Path, "tls", opt.TLS != nil)
 
 	server := &http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Addr:      addr,
+		Handler:   mux,
+		TLSConfig: opt.TLS,
 	}
 
 	// 优雅关闭
@@ -101,57 +154,147 @@ func (ws *WebSocketServer) Start(ctx context.Context, addr string, gateway Gatew
 		_ = server.Shutdown(shutdownCtx)
 	}()
 
+	if opt.TLS != nil {
+		// 证书由 TLSConfig 提供（支持热加载），无需文件参数
+		return server.ListenAndServeTLS("", "")
+	}
 	return server.ListenAndServe()
 }
 
 // handleConnection 处理新的 WebSocket 连接
 func (ws *WebSocketServer) handleConnection(w http.ResponseWriter, r *http.Request, gateway Gateway, opt Options) {
+	// 升级前依次校验来源、令牌与单 IP 连接数，拒绝时返回普通 HTTP 错误
+	if !originAllowed(r, ws.AllowedOrigins) {
+		logger.L().Sugar().Warnw("websocket_origin_rejected", "origin", r.Header.Get("Origin"), "addr", r.RemoteAddr)
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	var tokenIdentity string
+	if ws.Tokens != nil {
+		var err error
+		if tokenIdentity, err = ws.Tokens(requestToken(r)); err != nil {
+			logger.L().Sugar().Warnw("websocket_auth_rejected", "addr", r.RemoteAddr, "err", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="chat"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	ip := hostOnly(r.RemoteAddr)
+	if !ws.limiter.acquire(ip) {
+		logger.L().Sugar().Warnw("websocket_ip_limit", "ip", ip, "max", ws.MaxConnsPerIP)
+		http.Error(w, "too many connections", http.StatusTooManyRequests)
+		return
+	}
+
+	subprotocols := make([]string, 0, len(wsSubprotocols))
+	for _, sp := range wsSubprotocols {
+		subprotocols = append(subprotocols, sp.name)
+	}
 	upgrader := websocket.Upgrader{
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
+		ReadBufferSize:    opt.WSReadBufferSize,
+		WriteBufferSize:   opt.WSWriteBufferSize,
+		Subprotocols:      subprotocols,
+		EnableCompression: opt.WSCompression,
+		// 来源已在升级前校验
 		CheckOrigin: func(r *http.Request) bool {
 			return true
 		},
 	}
+	if upgrader.ReadBufferSize <= 0 {
+		upgrader.ReadBufferSize = defaultWSBufferSize
+	}
+	if upgrader.WriteBufferSize <= 0 {
+		upgrader.WriteBufferSize = defaultWSBufferSize
+	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		ws.limiter.release(ip)
 		logger.L().Sugar().Warnw("websocket_upgrade_error", "err", err)
 		return
 	}
 
+	// 客户端同意 permessage-deflate 时按配置的级别压缩出站消息
+	if opt.WSCompression && opt.WSCompressionLevel != 0 {
+		if err := conn.SetCompressionLevel(opt.WSCompressionLevel); err != nil {
+			logger.L().Sugar().Warnw("websocket_compression_level_error", "level", opt.WSCompressionLevel, "err", err)
+		}
+	}
+	if opt.MaxFrameSize > 0 {
+		conn.SetReadLimit(int64(opt.MaxFrameSize))
+	}
+
 	id := uuid.New().String()
+	// 按协商的子协议选择本会话的编解码器
+	protocolManager := opt.GetWSProtocolManager()
+	if codec := wsSubprotocolCodec(conn.Subprotocol()); codec != nil {
+		protocolManager = protocolManager.WithCodec(codec)
+	}
+	// 准入检查在升级之后：拒绝原因以本会话的编解码器发送，随后以 1013 关闭
+	release, rejected := opt.Admission.Admit(ws.Name(), ip)
+	if rejected != nil {
+		ws.limiter.release(ip)
+		rejectWebSocket(conn, protocolManager, rejected)
+		return
+	}
 	// 创建会话
-	session := newWsSession(id, conn, opt.GetWSProtocolManager())
+	session := newWsSession(id, conn, protocolManager)
+	// 身份优先取 mTLS 客户端证书，其次取令牌绑定的身份
+	if session.identity = peerIdentity(r.TLS); session.identity == "" {
+		session.identity = tokenIdentity
+	}
+	go func() {
+		<-session.closeChan
+		ws.limiter.release(ip)
+		release()
+	}()
 	// 创建会话上下文
 	sc := NewSessionContext(session)
 	// 通知网关会话开启
 	gateway.OnSessionOpen(sc)
 
 	// 设置心跳
-	ws.setupHeartbeat(session, opt)
+	ws.setupHeartbeat(session, sc, opt)
 
 	// 启动读取循环
 	go ws.readLoop(session, gateway, sc, opt)
 }
 
-// setupHeartbeat 设置心跳机制
-func (ws *WebSocketServer) setupHeartbeat(session *wsSession, opt Options) {
-	// 设置读取超时
-	timeout := 60 * time.Second
-	if opt.ReadTimeout > 0 {
-		timeout = opt.ReadTimeout
+// wsSubprotocolCodec 返回子协议对应的编解码器，未协商或不支持时返回 nil
+func wsSubprotocolCodec(name string) protocol.MessageCodec {
+	for _, sp := range wsSubprotocols {
+		if sp.name == name {
+			codec, err := protocol.NewCodec(sp.codec)
+			if err != nil {
+				return nil
+			}
+			return codec
+		}
 	}
+	return nil
+}
+
+// defaultWSPingInterval 未配置心跳间隔时发送控制帧 ping 的间隔
+const defaultWSPingInterval = 30 * time.Second
 
-	_ = session.conn.SetReadDeadline(time.Now().Add(timeout))
+// setupHeartbeat 设置心跳机制：应用层 ping 测量往返时延并检测超时（见 heartbeat），
+// 另外按同一间隔发送控制帧 ping，只会自动应答控制帧的浏览器页面靠它保活
+func (ws *WebSocketServer) setupHeartbeat(session *wsSession, sc *SessionContext, opt Options) {
+	// 设置读取超时，任何入站数据都会延长
+	_ = session.conn.SetReadDeadline(time.Now().Add(wsReadTimeout(opt)))
 
 	// 设置pong处理器
 	session.conn.SetPongHandler(func(string) error {
-		return session.conn.SetReadDeadline(time.Now().Add(timeout))
+		session.hb.touch()
+		return session.conn.SetReadDeadline(time.Now().Add(wsReadTimeout(opt)))
 	})
 
-	// 定期发送ping
-	ticker := time.NewTicker(30 * time.Second)
+	// 定期发送控制帧 ping
+	interval := opt.HeartbeatInterval
+	if interval <= 0 {
+		interval = defaultWSPingInterval
+	}
+	ticker := time.NewTicker(interval)
 	go func() {
 		defer ticker.Stop()
 		for {
@@ -163,6 +306,16 @@ func (ws *WebSocketServer) setupHeartbeat(session *wsSession, opt Options) {
 			}
 		}
 	}()
+
+	go session.hb.run(sc, opt, session.closeChan)
+}
+
+// wsReadTimeout WebSocket 读取超时，未配置时为 60s
+func wsReadTimeout(opt Options) time.Duration {
+	if opt.ReadTimeout > 0 {
+		return opt.ReadTimeout
+	}
+	return 60 * time.Second
 }
 
 // readLoop 读取循环
@@ -181,17 +334,28 @@ func (ws *WebSocketServer) readLoop(session *wsSession, gateway Gateway, sc *Ses
 			return
 		}
 
-		if mt != websocket.TextMessage {
+		session.hb.touch()
+		_ = session.conn.SetReadDeadline(time.Now().Add(wsReadTimeout(opt)))
+		if mt != websocket.TextMessage && mt != websocket.BinaryMessage {
 			continue
 		}
 
-		// 尝试解析为 Envelope
+		// 尝试解析为 Envelope；文本帧与二进制帧都按本会话的编解码器解码
 		var envelope protocol.Envelope
-		if err := session.protocolManager.DecodeMessage(bytes.NewBuffer(data), &envelope, opt.MaxFrameSize); err == nil && envelope.Type != "" {
-			gateway.OnEnvelope(sc, &envelope)
-		} else {
+		err = session.protocolManager.DecodeMessage(bytes.NewBuffer(data), &envelope, opt.MaxFrameSize)
+		switch {
+		case err == nil && envelope.Type == protocol.MsgPong:
+			session.hb.pong(&envelope)
+		case err == nil && envelope.Type == protocol.MsgHeartbeat:
+			// 客户端心跳只用于保活，不交给网关
+		case err == nil && envelope.Type != "":
+			deliver(gateway, sc, &envelope)
+		case mt == websocket.TextMessage:
 			// 回退处理纯文本消息（向后兼容）
 			ws.handleLegacyTextMessage(session, sc, string(data), gateway)
+		default:
+			logger.L().Sugar().Warnw("websocket_decode_error", "session", session.ID(), "err", err)
+			sendError(sc, protocol.Errorf(protocol.CodeInvalidFrame, "decode frame: %v", err), "")
 		}
 	}
 }
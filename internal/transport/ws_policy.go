@@ -0,0 +1,176 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// WSTokenCookie 握手时携带令牌的 Cookie 名，浏览器的 WebSocket API 不能设置请求头时使用
+const WSTokenCookie = "chat_token"
+
+// errInvalidToken 令牌缺失或不被接受
+var errInvalidToken = errors.New("invalid or missing token")
+
+// TokenVerifier 校验 WebSocket 升级请求携带的令牌，返回令牌绑定的聊天身份（可为空，表示仅放行、仍需输入昵称）
+type TokenVerifier func(token string) (identity string, err error)
+
+type tokenEntry struct{ token, identity string }
+
+// parseTokens 解析逗号分隔的 token[:identity] 列表
+func parseTokens(spec string) []tokenEntry {
+	var entries []tokenEntry
+	for _, item := range strings.Split(spec, ",") {
+		token, identity, _ := strings.Cut(strings.TrimSpace(item), ":")
+		if token != "" {
+			entries = append(entries, tokenEntry{token, strings.TrimSpace(identity)})
+		}
+	}
+	return entries
+}
+
+// TokenIdentities 返回 token[:identity] 列表中绑定的聊天身份，用于在启动时为这些身份保留昵称
+func TokenIdentities(spec string) []string {
+	var ids []string
+	for _, e := range parseTokens(spec) {
+		if e.identity != "" {
+			ids = append(ids, e.identity)
+		}
+	}
+	return ids
+}
+
+// StaticTokens 由逗号分隔的 token[:identity] 列表构建令牌校验器，spec 为空时返回 nil（不校验）
+func StaticTokens(spec string) TokenVerifier {
+	entries := parseTokens(spec)
+	if len(entries) == 0 {
+		return nil
+	}
+	return func(token string) (string, error) {
+		for _, e := range entries {
+			if subtle.ConstantTimeCompare([]byte(e.token), []byte(token)) == 1 {
+				return e.identity, nil
+			}
+		}
+		return "", errInvalidToken
+	}
+}
+
+// requestToken 依次从 Authorization: Bearer 请求头、token 查询参数与 chat_token Cookie 中取令牌
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	if c, err := r.Cookie(WSTokenCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// originAllowed 检查升级请求的 Origin。没有 Origin 头（非浏览器客户端）总是放行；
+// allowed 为空时只允许与请求同主机（忽略端口，便于 HTTP 端口上的测试页连接）的来源。
+// allowed 条目可为 "*"、完整来源 "https://chat.example.com"、通配子域 "https://*.example.com"
+// 或不含协议的主机名 "chat.example.com"
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if len(allowed) == 0 {
+		return strings.EqualFold(u.Hostname(), hostOnly(r.Host))
+	}
+	for _, pattern := range allowed {
+		if originMatches(u, strings.TrimSpace(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// originMatches 单个允许条目是否匹配来源
+func originMatches(origin *url.URL, pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	scheme, host, ok := strings.Cut(pattern, "://")
+	if !ok {
+		scheme, host = "", pattern
+	}
+	switch {
+	case scheme != "" && !strings.EqualFold(scheme, origin.Scheme):
+		return false
+	case strings.Contains(host, ":"):
+		// 条目带端口：比较 host:port
+		return hostMatches(origin.Host, host)
+	case scheme == "":
+		// 仅主机名：任意协议与端口
+		return hostMatches(origin.Hostname(), host)
+	default:
+		// 完整来源不带端口：来源也必须使用默认端口
+		return hostMatches(origin.Hostname(), host) && origin.Port() == ""
+	}
+}
+
+// hostMatches 比较主机名，"*." 前缀匹配任意一级或多级子域（不含裸域）
+func hostMatches(host, pattern string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return len(host) > len(suffix) && strings.EqualFold(host[len(host)-len(suffix):], suffix)
+	}
+	return strings.EqualFold(host, pattern)
+}
+
+// hostOnly 去掉 host:port 中的端口
+func hostOnly(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return hostport
+}
+
+// ipLimiter 按客户端 IP 统计并发连接数
+type ipLimiter struct {
+	max   int
+	mu    sync.Mutex
+	conns map[string]int
+}
+
+func newIPLimiter(max int) *ipLimiter {
+	return &ipLimiter{max: max, conns: make(map[string]int)}
+}
+
+// acquire 占用一个连接名额，超过上限返回 false；max <= 0 不限制
+func (l *ipLimiter) acquire(ip string) bool {
+	if l.max <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conns[ip] >= l.max {
+		return false
+	}
+	l.conns[ip]++
+	return true
+}
+
+// release 归还 acquire 占用的名额
+func (l *ipLimiter) release(ip string) {
+	if l.max <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conns[ip]--; l.conns[ip] <= 0 {
+		delete(l.conns, ip)
+	}
+}
@@ -0,0 +1,259 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hongjun500/chat-go/internal/chat"
+	"github.com/hongjun500/chat-go/internal/command"
+	"github.com/hongjun500/chat-go/internal/protocol"
+)
+
+// readWSType 读取 protobuf 二进制帧直到出现指定类型的消息
+func readWSType(t *testing.T, conn *websocket.Conn, want protocol.MessageType) {
+	t.Helper()
+	for {
+		mt, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for %s: %v", want, err)
+		}
+		if mt != websocket.BinaryMessage {
+			t.Fatalf("message type = %d, want binary", mt)
+		}
+		var env protocol.Envelope
+		if err := (&protocol.ProtobufCodec{}).Decode(bytes.NewReader(data), &env, 1<<20); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if env.Type == want {
+			return
+		}
+	}
+}
+
+// TestWSBinaryFrames 二进制编解码器使用二进制帧收发，JSON 仍使用文本帧；协商 permessage-deflate
+func TestWSBinaryFrames(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+	go func() {
+		_ = NewWebSocketServer("/ws").Start(ctx, addr, NewChatGateway(chat.NewHub(), reg), Options{
+			WSProtocolManager:  protocol.NewProtocolManager(protocol.CodecProtobuf),
+			WSReadBufferSize:   4096,
+			WSWriteBufferSize:  4096,
+			WSCompression:      true,
+			WSCompressionLevel: 9,
+		})
+	}()
+	dialTCP(t, addr).Close()
+
+	// 未声明子协议：使用监听器默认的 protobuf，出站为二进制帧
+	dialer := websocket.Dialer{EnableCompression: true}
+	conn, resp, err := dialer.Dial("ws://"+addr+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+		t.Errorf("extensions = %q, want permessage-deflate", ext)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	mt, _, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if mt != websocket.BinaryMessage {
+		t.Fatalf("message type = %d, want binary", mt)
+	}
+
+	// 入站二进制帧按 protobuf 解码
+	var buf bytes.Buffer
+	if err := (&protocol.ProtobufCodec{}).Encode(&buf, protocol.NewMessageFactoryWithEncoding(protocol.EncodingProtobuf).CreateSetNickMessage("dave")); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	readWSType(t, conn, protocol.MsgAck)
+
+	// 无法解码的二进制帧回复错误而不是当作纯文本
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte{0xff, 0xff, 0xff}); err != nil {
+		t.Fatal(err)
+	}
+	readWSType(t, conn, protocol.MsgError)
+
+	// 声明 chat.json 子协议的客户端仍收到文本帧
+	jsonDialer := websocket.Dialer{Subprotocols: []string{"chat.json"}}
+	jconn, _, err := jsonDialer.Dial("ws://"+addr+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial json: %v", err)
+	}
+	defer jconn.Close()
+	_ = jconn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if mt, _, err := jconn.ReadMessage(); err != nil || mt != websocket.TextMessage {
+		t.Fatalf("json message type = %d, %v; want text", mt, err)
+	}
+
+	// DialClient 按编解码器选择帧类型
+	c, err := DialClient("ws://"+addr+"/ws", &protocol.CBORCodec{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Write(protocol.NewMessageFactoryWithEncoding(protocol.EncodingCBOR).CreateSetNickMessage("erin")); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		env, err := c.Read(1 << 20)
+		if err != nil {
+			t.Fatalf("cbor read: %v", err)
+		}
+		if env.Type == protocol.MsgAck {
+			break
+		}
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	cases := []struct {
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{"", nil, true},
+		{"http://chat.local:8082", nil, true},
+		{"http://evil.example", nil, false},
+		{"https://evil.example", []string{"*"}, true},
+		{"https://chat.example.com", []string{"https://chat.example.com"}, true},
+		{"http://chat.example.com", []string{"https://chat.example.com"}, false},
+		{"https://chat.example.com:8443", []string{"https://chat.example.com"}, false},
+		{"https://chat.example.com:8443", []string{"https://chat.example.com:8443"}, true},
+		{"https://a.b.example.com", []string{"https://*.example.com"}, true},
+		{"https://example.com", []string{"https://*.example.com"}, false},
+		{"http://CHAT.example.com:9000", []string{"chat.example.com"}, true},
+		{"null", []string{"chat.example.com"}, false},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "http://chat.local:8081/ws", nil)
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+		if got := originAllowed(r, c.allowed); got != c.want {
+			t.Errorf("originAllowed(%q, %q) = %v, want %v", c.origin, c.allowed, got, c.want)
+		}
+	}
+}
+
+// TestWSUpgradePolicy 来源、令牌与单 IP 连接数在升级前校验，拒绝时返回 HTTP 错误
+func TestWSUpgradePolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := command.NewRegistry()
+	if err := command.RegisterBuiltins(reg); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewWebSocketServer("/ws")
+	srv.AllowedOrigins = []string{"https://chat.example.com"}
+	srv.Tokens = StaticTokens("s3cret:botty, guest")
+	srv.MaxConnsPerIP = 2
+	addr := freeAddr(t)
+	go func() {
+		_ = srv.Start(ctx, addr, NewChatGateway(chat.NewHub(), reg), Options{
+			WSProtocolManager: protocol.NewProtocolManager(protocol.CodecJson),
+		})
+	}()
+	dialTCP(t, addr).Close()
+	url := "ws://" + addr + "/ws"
+
+	dial := func(target string, header http.Header) (*websocket.Conn, int) {
+		t.Helper()
+		conn, resp, err := websocket.DefaultDialer.Dial(target, header)
+		if err != nil {
+			if resp == nil {
+				t.Fatalf("dial: %v", err)
+			}
+			return nil, resp.StatusCode
+		}
+		return conn, http.StatusSwitchingProtocols
+	}
+
+	if _, code := dial(url, http.Header{"Origin": {"https://evil.example"}, "Authorization": {"Bearer s3cret"}}); code != http.StatusForbidden {
+		t.Errorf("foreign origin status = %d, want 403", code)
+	}
+	if _, code := dial(url, nil); code != http.StatusUnauthorized {
+		t.Errorf("missing token status = %d, want 401", code)
+	}
+	if _, code := dial(url+"?token=wrong", nil); code != http.StatusUnauthorized {
+		t.Errorf("wrong token status = %d, want 401", code)
+	}
+
+	// 令牌绑定的身份直接登录
+	bot, code := dial(url, http.Header{"Origin": {"https://chat.example.com"}, "Authorization": {"Bearer s3cret"}})
+	if bot == nil {
+		t.Fatalf("bearer token status = %d", code)
+	}
+	defer bot.Close()
+	_ = bot.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := bot.WriteMessage(websocket.TextMessage, []byte("/who")); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var env protocol.Envelope
+		if err := bot.ReadJSON(&env); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if env.Type == protocol.MsgText && strings.Contains(textOf(t, &env), "botty") {
+			break
+		}
+	}
+
+	guest, code := dial(url, http.Header{"Cookie": {WSTokenCookie + "=guest"}})
+	if guest == nil {
+		t.Fatalf("cookie token status = %d", code)
+	}
+	if _, code := dial(url+"?token=guest", nil); code != http.StatusTooManyRequests {
+		t.Errorf("third connection status = %d, want 429", code)
+	}
+	// 关闭后名额释放
+	guest.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, code := dial(url+"?token=guest", nil)
+		if conn != nil {
+			conn.Close()
+			break
+		}
+		if code != http.StatusTooManyRequests || time.Now().After(deadline) {
+			t.Fatalf("after close status = %d", code)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// TestTokenIdentities 令牌绑定的身份在登录前即保留昵称，未认证的会话不能冒用
+func TestTokenIdentities(t *testing.T) {
+	spec := "s3cret:botty, guest, k2: ops "
+	if got := strings.Join(TokenIdentities(spec), ","); got != "botty,ops" {
+		t.Errorf("TokenIdentities = %q", got)
+	}
+	hub := chat.NewHub()
+	hub.ReserveNames(TokenIdentities(spec))
+	if err := hub.Login(chat.NewClientWithBuffer("m", 8), "botty", false); !errors.Is(err, chat.ErrNameReserved) {
+		t.Errorf("unauthenticated botty: err = %v", err)
+	}
+	if err := hub.Login(chat.NewClientWithBuffer("b", 8), "botty", true); err != nil {
+		t.Errorf("token botty: %v", err)
+	}
+}